@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// Orders that the channel browser can list channels in.
+const (
+	BROWSE_SORT_MEMBERS = "members"
+	BROWSE_SORT_NAME    = "name"
+)
+
+// The number of messages shown when previewing a channel.
+const browsePreviewLength = 20
+
+// An item in the channel browser.
+type BrowseChannelItem struct {
+	Connection string
+	Channel    gateway.Channel
+}
+
+// Is the fuzzy picker showing the channel browser?
+func IsBrowsing(state *State) bool {
+	if !state.SelectionInput.Visible || len(state.SelectionInput.Items) == 0 {
+		return false
+	}
+	_, ok := state.SelectionInput.Items[0].(BrowseChannelItem)
+	return ok
+}
+
+// Fetch every public channel in the active connection, and list them in the fuzzy picker.
+func OpenChannelBrowser(state *State, sortBy string) error {
+	if sortBy != BROWSE_SORT_MEMBERS && sortBy != BROWSE_SORT_NAME {
+		return errors.New(fmt.Sprintf("Can't sort channels by %s. Try `members` or `name`.", sortBy))
+	}
+
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return errors.New("No active connection!")
+	}
+
+	// Fetch the channels again, so channels created since connecting are listed too.
+	channels, err := conn.FetchChannels()
+	if err != nil {
+		return err
+	}
+
+	var items []BrowseChannelItem
+	for _, channel := range channels {
+		if channel.SubType == gateway.TYPE_CHANNEL && !channel.IsPrivate && !channel.IsArchived {
+			items = append(items, BrowseChannelItem{Connection: conn.Name(), Channel: channel})
+		}
+	}
+	if len(items) == 0 {
+		return errors.New("No public channels to browse.")
+	}
+
+	ShowChannelBrowser(state, items, sortBy)
+	return nil
+}
+
+// List the given channels in the fuzzy picker. When nothing has been typed to filter them, they're
+// listed in the order given by `sortBy`.
+func ShowChannelBrowser(state *State, items []BrowseChannelItem, sortBy string) {
+	items = append([]BrowseChannelItem{}, items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		if sortBy == BROWSE_SORT_MEMBERS && items[i].Channel.MemberCount != items[j].Channel.MemberCount {
+			return items[i].Channel.MemberCount > items[j].Channel.MemberCount
+		}
+		return items[i].Channel.Name < items[j].Channel.Name
+	})
+
+	state.SelectionInput.Hide()
+	state.SelectionInput.Show(OnBrowseJoinChannel)
+	state.SelectionInput.IgnoreFrecency = true // Keep the channels in the order picked.
+	for _, item := range items {
+		state.SelectionInput.Items = append(state.SelectionInput.Items, item)
+		state.SelectionInput.StringItems = append(state.SelectionInput.StringItems, browseChannelLabel(item.Channel))
+	}
+	state.BrowseSort = sortBy
+	state.Mode = "pick"
+
+	// Show the topic and purpose of the highlighted channel in the status bar.
+	var highlighted string
+	state.SelectionInput.Resort(func(state *State) {
+		if item, ok := selectedBrowseChannel(state); ok && item.Channel.Id != highlighted {
+			highlighted = item.Channel.Id
+			state.Status.Printf("%s", browseChannelDetails(item.Channel))
+		}
+	})
+}
+
+func browseChannelLabel(channel gateway.Channel) string {
+	members := fmt.Sprintf("%d members", channel.MemberCount)
+	if channel.MemberCount == 1 {
+		members = "1 member"
+	}
+	if channel.IsMember {
+		return fmt.Sprintf("#%s\tjoined, %s", channel.Name, members)
+	}
+	return fmt.Sprintf("#%s\t%s", channel.Name, members)
+}
+
+func browseChannelDetails(channel gateway.Channel) string {
+	details := []string{"#" + channel.Name}
+	if len(channel.Topic) > 0 {
+		details = append(details, "Topic: "+channel.Topic)
+	}
+	if len(channel.Purpose) > 0 {
+		details = append(details, "Purpose: "+channel.Purpose)
+	}
+	if len(details) == 1 {
+		details = append(details, "No topic or purpose")
+	}
+	return strings.Replace(strings.Join(details, " | "), "\n", " ", -1)
+}
+
+func selectedBrowseChannel(state *State) (BrowseChannelItem, bool) {
+	if !state.SelectionInput.Visible || state.SelectionInput.SelectedItem >= len(state.SelectionInput.Items) {
+		return BrowseChannelItem{}, false
+	}
+	item, ok := state.SelectionInput.Items[state.SelectionInput.SelectedItem].(BrowseChannelItem)
+	return item, ok
+}
+
+func browseConnectionIndex(state *State, name string) (int, error) {
+	for index, conn := range state.Connections {
+		if conn.Name() == name {
+			return index, nil
+		}
+	}
+	return -1, errors.New("No such connection: " + name)
+}
+
+// Join a channel (if the user isn't a member already) and switch to it.
+func JoinAndSelectChannel(state *State, item BrowseChannelItem) error {
+	connectionIndex, err := browseConnectionIndex(state, item.Connection)
+	if err != nil {
+		return err
+	}
+	conn := state.Connections[connectionIndex]
+
+	channel := &item.Channel
+	if !channel.IsMember {
+		if channel, err = conn.JoinChannel(channel); err != nil {
+			return err
+		}
+		state.Status.Printf("Joined #%s.", channel.Name)
+	}
+
+	state.SelectChannel(connectionIndex, channel)
+	return nil
+}
+
+// When the user picks a channel in the channel browser, join it.
+func OnBrowseJoinChannel(state *State) {
+	if item, ok := selectedBrowseChannel(state); ok {
+		if err := JoinAndSelectChannel(state, item); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+	}
+}
+
+// Leave the channel highlighted in the channel browser, keeping the browser open.
+func BrowseLeaveChannel(state *State) error {
+	item, ok := selectedBrowseChannel(state)
+	if !ok {
+		return nil
+	}
+	if !item.Channel.IsMember {
+		return errors.New(fmt.Sprintf("You aren't a member of #%s.", item.Channel.Name))
+	}
+
+	connectionIndex, err := browseConnectionIndex(state, item.Connection)
+	if err != nil {
+		return err
+	}
+	channel, err := state.Connections[connectionIndex].LeaveChannel(&item.Channel)
+	if err != nil {
+		return err
+	}
+
+	item.Channel = *channel
+	state.SelectionInput.Items[state.SelectionInput.SelectedItem] = item
+	state.SelectionInput.StringItems[state.SelectionInput.SelectedItem] = browseChannelLabel(*channel)
+	state.Status.Printf("Left #%s.", channel.Name)
+	return nil
+}
+
+// Switch the channel browser between sorting by member count and by name.
+func BrowseToggleSort(state *State) {
+	var items []BrowseChannelItem
+	for _, item := range state.SelectionInput.Items {
+		if browseItem, ok := item.(BrowseChannelItem); ok {
+			items = append(items, browseItem)
+		}
+	}
+
+	if state.BrowseSort == BROWSE_SORT_MEMBERS {
+		ShowChannelBrowser(state, items, BROWSE_SORT_NAME)
+	} else {
+		ShowChannelBrowser(state, items, BROWSE_SORT_MEMBERS)
+	}
+}
+
+// Show the most recent messages in the channel highlighted in the channel browser in a modal,
+// without joining it. Pressing enter in the modal joins the channel.
+func BrowsePreviewChannel(state *State) error {
+	item, ok := selectedBrowseChannel(state)
+	if !ok {
+		return nil
+	}
+	connectionIndex, err := browseConnectionIndex(state, item.Connection)
+	if err != nil {
+		return err
+	}
+
+	messages, err := state.Connections[connectionIndex].FetchChannelMessages(item.Channel, nil, nil)
+	if err != nil {
+		return err
+	}
+	if len(messages) > browsePreviewLength {
+		messages = messages[len(messages)-browsePreviewLength:]
+	}
+
+	body := browseChannelDetails(item.Channel) + "\n\n"
+	if len(messages) == 0 {
+		body += "No messages yet.\n"
+	}
+	for _, message := range messages {
+		sender := "(unknown)"
+		if message.Sender != nil {
+			sender = message.Sender.Name
+		}
+		body += fmt.Sprintf("%s: %s\n", sender, message.Text)
+	}
+	if !item.Channel.IsMember {
+		body += "\nPress enter to join this channel."
+	}
+
+	state.SelectionInput.Hide()
+	state.Command = []rune{}
+	state.CommandCursorPosition = 0
+	state.Mode = "modl"
+	state.Modal.Reset()
+	state.Modal.Title = fmt.Sprintf("Preview of #%s", item.Channel.Name)
+	state.Modal.Body = body
+	state.Modal.Confirm = func() error {
+		return JoinAndSelectChannel(state, item)
+	}
+	return nil
+}
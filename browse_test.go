@@ -0,0 +1,107 @@
+package main_test
+
+import (
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestBrowseListsPublicChannels(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		httpmock.NewStringResponder(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.list?token=token&types=public_channel,private_channel,mpim,im&limit=200",
+		httpmock.NewStringResponder(200, `{"ok": true, "channels": [
+			{"id": "C1", "name": "general", "creator": "user-id", "is_member": true, "num_members": 10},
+			{"id": "C2", "name": "news", "creator": "user-id", "num_members": 50, "topic": {"value": "News"}},
+			{"id": "C3", "name": "old", "creator": "user-id", "num_members": 99, "is_archived": true},
+			{"id": "G1", "name": "secret", "creator": "user-id", "is_private": true, "num_members": 2}
+		]}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/channels.join?token=token&name=news&validate=true",
+		httpmock.NewStringResponder(200, `{"ok": true, "channel": {"id": "C2", "name": "news", "is_member": true}}`))
+
+	state := NewInitialStateMode("chat")
+	state.Connections = append(state.Connections, gatewaySlack.NewWithName("my-team", "token"))
+	state.SetActiveConnection(0)
+
+	if err := GetCommand("Browse").Handler([]string{"browse"}, state); err != nil {
+		t.Fatalf("Error browsing channels: %s", err)
+	}
+	if state.Mode != "pick" || !IsBrowsing(state) {
+		t.Fatalf("Browsing didn't open the fuzzy picker, in mode %s", state.Mode)
+	}
+	expected := []string{"#news\t50 members", "#general\tjoined, 10 members"}
+	if len(state.SelectionInput.StringItems) != 2 ||
+		state.SelectionInput.StringItems[0] != expected[0] ||
+		state.SelectionInput.StringItems[1] != expected[1] {
+		t.Errorf("Expected public channels by member count, got %q", state.SelectionInput.StringItems)
+	}
+
+	// Sort by name instead.
+	quit := make(chan struct{}, 1)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlS, ' ', tcell.ModNone), state, nil, quit)
+	if state.BrowseSort != "name" || state.SelectionInput.StringItems[0] != expected[1] {
+		t.Errorf("Expected channels sorted by name, got %q", state.SelectionInput.StringItems)
+	}
+
+	// Join the highlighted channel.
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlK, ' ', tcell.ModNone), state, nil, quit)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, nil, quit)
+	if state.Mode != "chat" || state.SelectionInput.Visible {
+		t.Errorf("Joining should close the browser, in mode %s", state.Mode)
+	}
+	channel := state.ActiveConnection().SelectedChannel()
+	if channel == nil || channel.Name != "news" || !channel.IsMember || channel.Topic != "News" || channel.MemberCount != 51 {
+		t.Errorf("Expected to join and select #news, got %+v", channel)
+	}
+}
+
+func TestBrowseFromCommandPickerStaysOpen(t *testing.T) {
+	state := NewInitialStateMode("pick")
+	conn := gatewaySlack.New("token")
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+
+	items := []BrowseChannelItem{{Connection: conn.Name(), Channel: gateway.Channel{Id: "C1", Name: "general", SubType: gateway.TYPE_CHANNEL}}}
+	state.SelectionInput.Show(func(state *State) { ShowChannelBrowser(state, items, "members") })
+	state.SelectionInput.Items = []interface{}{"browse"}
+	state.SelectionInput.StringItems = []string{"browse"}
+
+	quit := make(chan struct{}, 1)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, nil, quit)
+	if state.Mode != "pick" || !IsBrowsing(state) {
+		t.Errorf("A picker opened when picking an item should stay open, in mode %s", state.Mode)
+	}
+}
+
+func TestBrowseOrderIsntChangedByFrecency(t *testing.T) {
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.New("token")
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+
+	// #zebra has been picked before, but the channels should stay in the order that was picked.
+	state.Frecency.Record("#zebra")
+	state.Frecency.Record("#zebra")
+	items := []BrowseChannelItem{
+		{Connection: conn.Name(), Channel: gateway.Channel{Id: "C1", Name: "zebra", MemberCount: 5}},
+		{Connection: conn.Name(), Channel: gateway.Channel{Id: "C2", Name: "alpha", MemberCount: 10}},
+	}
+	ShowChannelBrowser(state, items, "members")
+	state.SelectionInput.Sort(state.Frecency)
+	if state.SelectionInput.StringItems[0] != "#alpha\t10 members" {
+		t.Errorf("Expected channels by member count, got %q", state.SelectionInput.StringItems)
+	}
+
+	ShowChannelBrowser(state, items, "name")
+	state.SelectionInput.Sort(state.Frecency)
+	if state.SelectionInput.StringItems[0] != "#alpha\t10 members" {
+		t.Errorf("Expected channels by name, got %q", state.SelectionInput.StringItems)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// The channel that commands like `/info` and `/topic` act on: the selected channel of the active
+// connection.
+func selectedChannelForInfo(state *State) (gateway.Connection, *gateway.Channel, error) {
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return nil, nil, errors.New("No active connection!")
+	}
+	channel := conn.SelectedChannel()
+	if channel == nil {
+		return nil, nil, errors.New("No channel is selected.")
+	}
+	return conn, channel, nil
+}
+
+// Render the details of a channel and a list of its members for a modal. Members that are online
+// are marked with `onlinePrefix`.
+func ChannelInfoBody(conn gateway.Connection, channel gateway.Channel, onlinePrefix string) string {
+	var body []string
+
+	if len(channel.Topic) > 0 {
+		body = append(body, "Topic: "+channel.Topic)
+	} else {
+		body = append(body, "Topic: (none, set one with /topic)")
+	}
+	if len(channel.Purpose) > 0 {
+		body = append(body, "Purpose: "+channel.Purpose)
+	} else {
+		body = append(body, "Purpose: (none, set one with /purpose)")
+	}
+
+	if channel.Created > 0 {
+		created := "Created " + time.Unix(int64(channel.Created), 0).Format("January 2, 2006")
+		if channel.Creator != nil && len(channel.Creator.Name) > 0 {
+			created += " by " + channel.Creator.Name
+		}
+		body = append(body, created)
+	}
+
+	var flags []string
+	if channel.IsPrivate {
+		flags = append(flags, "private")
+	}
+	if channel.IsShared {
+		flags = append(flags, "shared")
+	}
+	if channel.IsArchived {
+		flags = append(flags, "archived")
+	}
+	if !channel.IsMember {
+		flags = append(flags, "not a member")
+	}
+	if len(flags) > 0 {
+		body = append(body, "("+strings.Join(flags, ", ")+")")
+	}
+
+	// List members by name, with the members that are online first.
+	var members []*gateway.User
+	for _, id := range channel.Members {
+		if user, err := conn.UserById(id); err == nil && user != nil {
+			members = append(members, user)
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		if iOnline, jOnline := conn.UserOnline(members[i]), conn.UserOnline(members[j]); iOnline != jOnline {
+			return iOnline
+		}
+		return members[i].Name < members[j].Name
+	})
+
+	body = append(body, "", fmt.Sprintf("Members (%d):", channel.MemberCount))
+	offlinePrefix := strings.Repeat(" ", len(onlinePrefix))
+	for _, member := range members {
+		prefix := offlinePrefix
+		if conn.UserOnline(member) {
+			prefix = onlinePrefix
+		}
+		line := prefix + " " + member.Name
+		if len(member.RealName) > 0 {
+			line += " (" + member.RealName + ")"
+		}
+		body = append(body, line)
+	}
+
+	return strings.Join(body, "\n")
+}
@@ -0,0 +1,50 @@
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestChannelInfoBodyMarksOnlineMembers(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.list?token=token&limit=200",
+		httpmock.NewStringResponder(200, `{"ok": true, "members": [
+			{"id": "U1", "name": "zoe"},
+			{"id": "U2", "name": "alice", "profile": {"real_name": "Alice Smith"}},
+			{"id": "U3", "name": "bob"}
+		]}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	users, err := conn.FetchUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetUserOnline(&users[0], true)
+
+	body := ChannelInfoBody(conn, gateway.Channel{
+		Name:        "general",
+		Topic:       "Lunch",
+		MemberCount: 3,
+		IsMember:    true,
+		Members:     []string{"U1", "U2", "U3"},
+	}, "*")
+
+	expected := strings.Join([]string{
+		"Topic: Lunch",
+		"Purpose: (none, set one with /purpose)",
+		"",
+		"Members (3):",
+		"* zoe",
+		"  alice (Alice Smith)",
+		"  bob",
+	}, "\n")
+	if body != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, body)
+	}
+}
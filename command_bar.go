@@ -0,0 +1,211 @@
+package main
+
+import (
+	"unicode"
+)
+
+// How many pieces of killed text are remembered.
+const killRingSize = 10
+
+// Text removed from the command bar with Ctrl-K, Ctrl-U, Ctrl-W, or Alt-d, newest last. Ctrl-Y
+// yanks the newest piece back into the command bar.
+type KillRing struct {
+	items [][]rune
+}
+
+func (k *KillRing) Push(text []rune) {
+	if len(text) == 0 {
+		return
+	}
+	k.items = append(k.items, append([]rune{}, text...))
+	if len(k.items) > killRingSize {
+		k.items = k.items[len(k.items)-killRingSize:]
+	}
+}
+
+// The most recently killed text, or nil if nothing has been killed.
+func (k *KillRing) Newest() []rune {
+	if len(k.items) == 0 {
+		return nil
+	}
+	return k.items[len(k.items)-1]
+}
+
+// Where the word before the cursor starts, skipping any spaces between the cursor and the word.
+func PreviousWordBoundary(text []rune, cursor int) int {
+	index := cursor
+	for index > 0 && unicode.IsSpace(text[index-1]) {
+		index -= 1
+	}
+	for index > 0 && !unicode.IsSpace(text[index-1]) {
+		index -= 1
+	}
+	return index
+}
+
+// Where the word after the cursor ends, skipping any spaces between the cursor and the word.
+func NextWordBoundary(text []rune, cursor int) int {
+	index := cursor
+	for index < len(text) && unicode.IsSpace(text[index]) {
+		index += 1
+	}
+	for index < len(text) && !unicode.IsSpace(text[index]) {
+		index += 1
+	}
+	return index
+}
+
+// Remove the text between start and end from the command bar, adding it to the kill ring.
+func KillCommandText(state *State, start int, end int) {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(state.Command) {
+		end = len(state.Command)
+	}
+	if start >= end {
+		return
+	}
+
+	state.KillRing.Push(state.Command[start:end])
+	state.Command = append(state.Command[:start], state.Command[end:]...)
+	state.CommandCursorPosition = start
+}
+
+// Insert the most recently killed text at the cursor.
+func YankIntoCommand(state *State) {
+	text := state.KillRing.Newest()
+	if text == nil {
+		return
+	}
+
+	command := append([]rune{}, state.Command[:state.CommandCursorPosition]...)
+	command = append(command, text...)
+	state.Command = append(command, state.Command[state.CommandCursorPosition:]...)
+	state.CommandCursorPosition += len(text)
+}
+
+// Swap the characters on either side of the cursor, then move the cursor forward. At the end of
+// the command, the last two characters are swapped instead, just like emacs.
+func TransposeCommandChars(state *State) {
+	cursor := state.CommandCursorPosition
+	if cursor == len(state.Command) {
+		cursor -= 1
+	}
+	if cursor < 1 || len(state.Command) < 2 {
+		return
+	}
+
+	state.Command[cursor-1], state.Command[cursor] = state.Command[cursor], state.Command[cursor-1]
+	state.CommandCursorPosition = cursor + 1
+}
+
+// Delete the character underneath the cursor.
+func DeleteCommandCharForward(state *State) {
+	if state.CommandCursorPosition < len(state.Command) {
+		state.Command = append(
+			state.Command[:state.CommandCursorPosition],
+			state.Command[state.CommandCursorPosition+1:]...,
+		)
+	}
+}
+
+// In normal mode, the cursor sits on a character rather than between two, so it can't be past the
+// last one.
+func clampNormalModeCursor(state *State) {
+	if state.CommandCursorPosition > len(state.Command)-1 {
+		state.CommandCursorPosition = len(state.Command) - 1
+	}
+	if state.CommandCursorPosition < 0 {
+		state.CommandCursorPosition = 0
+	}
+}
+
+// Switch from normal mode back to writ mode, so that the user can type.
+func enterInsertMode(state *State) {
+	EmitEvent(state, EVENT_MODE_CHANGE, map[string]string{"from": state.Mode, "to": "writ"})
+	state.Mode = "writ"
+}
+
+// When `CommandBar.ViMode` is enabled, pressing escape in writ mode moves the command bar into
+// `norm` mode, which edits the command like vi's normal mode.
+func HandleNormalModeKey(state *State, key rune) {
+	switch key {
+	// Movement
+	case 'h':
+		state.CommandCursorPosition -= 1
+	case 'l':
+		state.CommandCursorPosition += 1
+	case '0', '^':
+		state.CommandCursorPosition = 0
+	case '$':
+		state.CommandCursorPosition = len(state.Command)
+	case 'b':
+		state.CommandCursorPosition = PreviousWordBoundary(state.Command, state.CommandCursorPosition)
+	case 'w':
+		// Move past the rest of this word, then to the start of the next one.
+		index := state.CommandCursorPosition
+		for index < len(state.Command) && !unicode.IsSpace(state.Command[index]) {
+			index += 1
+		}
+		for index < len(state.Command) && unicode.IsSpace(state.Command[index]) {
+			index += 1
+		}
+		state.CommandCursorPosition = index
+	case 'e':
+		state.CommandCursorPosition = NextWordBoundary(state.Command, state.CommandCursorPosition+1) - 1
+
+	// Editing
+	case 'x':
+		KillCommandText(state, state.CommandCursorPosition, state.CommandCursorPosition+1)
+	case 'D':
+		KillCommandText(state, state.CommandCursorPosition, len(state.Command))
+	case 'p', 'P':
+		if state.KillRing.Newest() == nil {
+			break
+		}
+		// `p` puts the text after the cursor, and `P` puts it before.
+		if key == 'p' && len(state.Command) > 0 {
+			state.CommandCursorPosition += 1
+		}
+		YankIntoCommand(state)
+		state.CommandCursorPosition -= 1
+
+	// History
+	case 'k':
+		if command, ok := state.InputHistory.Previous(InputHistoryKey(state), state.Command); ok {
+			state.Command = command
+			state.CommandCursorPosition = 0
+		}
+	case 'j':
+		if command, ok := state.InputHistory.Next(InputHistoryKey(state)); ok {
+			state.Command = command
+			state.CommandCursorPosition = 0
+		}
+
+	// Back to writ mode
+	case 'i':
+		enterInsertMode(state)
+	case 'a':
+		if len(state.Command) > 0 {
+			state.CommandCursorPosition += 1
+		}
+		enterInsertMode(state)
+	case 'I':
+		state.CommandCursorPosition = 0
+		enterInsertMode(state)
+	case 'A':
+		state.CommandCursorPosition = len(state.Command)
+		enterInsertMode(state)
+	case 'C':
+		KillCommandText(state, state.CommandCursorPosition, len(state.Command))
+		enterInsertMode(state)
+	case 'S':
+		KillCommandText(state, 0, len(state.Command))
+		enterInsertMode(state)
+	}
+
+	if state.Mode == "norm" {
+		clampNormalModeCursor(state)
+	}
+}
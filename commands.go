@@ -7,6 +7,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/1egoman/slick/frontend"
 	"github.com/1egoman/slick/gateway"
@@ -36,9 +37,10 @@ type Command struct {
 var COMMANDS = []Command{
 	//
 	// SPECIAL CASES
-	// `Quit` needs access to the `quit` channel to close the app and `Require` needs access to a
-	// reference to `term` to pass to `ParseScript`. Since these are "special cases", they don't
-	// have handlers and are taken care of separately in `OnCommandExecuted` in keyboard_events.go.
+	// `Quit` needs access to the `quit` channel to close the app, `Require` needs access to a
+	// reference to `term` to pass to `ParseScript`, and `Compose` needs `term` to give the terminal
+	// to the user's editor. Since these are "special cases", they don't have handlers and are taken
+	// care of separately in `OnCommandExecuted` in keyboard_events.go.
 	//
 	{
 		Name:         "Quit",
@@ -55,6 +57,14 @@ var COMMANDS = []Command{
 		Permutations: []string{"require", "req", "source"},
 		/* NO HANDLER, SPECIAL CASE */
 	},
+	{
+		Name:         "Compose",
+		Type:         NATIVE,
+		Description:  "Write a message in $VISUAL or $EDITOR, then put it in the command bar to send.",
+		Arguments:    "[initial text]",
+		Permutations: []string{"compose"},
+		/* NO HANDLER, SPECIAL CASE */
+	},
 
 	//
 	// CONNECT TO A TEAM
@@ -269,40 +279,105 @@ var COMMANDS = []Command{
 			return nil
 		},
 	},
+	{
+		Name:         "Paste",
+		Type:         NATIVE,
+		Description:  "Send the contents of the clipboard: an image is uploaded, long text is posted as a snippet, and short text is sent as a message.",
+		Arguments:    "",
+		Permutations: []string{"paste"},
+		Handler: func(args []string, state *State) error {
+			conn := state.ActiveConnection()
+			if conn == nil || conn.SelectedChannel() == nil {
+				return errors.New("No active connection or selected channel!")
+			}
+			channel := *conn.SelectedChannel()
+
+			paste, err := ReadPaste(maxInlinePasteLines(state))
+			if err != nil {
+				return err
+			}
+
+			// Confirm before sending, since what's in the clipboard isn't always what's expected.
+			state.Mode = "modl"
+			state.Modal.Reset()
+			state.Modal.Title = "Paste (enter to send)"
+			state.Modal.Body = paste.Describe(channel.Name)
+			state.Modal.Confirm = func() error {
+				return paste.Send(state, conn, &channel)
+			}
+			return nil
+		},
+	},
 	{
 		Name:         "Upload",
 		Type:         NATIVE,
-		Description:  "Upload a file to a channel.",
-		Arguments:    "<file path> [file name]",
+		Description:  "Upload files to a channel.",
+		Arguments:    "<file path or glob>... [-title file name] [-comment message] [-channel channel name]",
 		Permutations: []string{"upload", "up"},
 		Handler: func(args []string, state *State) error {
-			var title string
-			var uploadPath string
-			if len(args) == 2 { // /upload /path/to/file.xt
-				uploadPath = args[1]
-			} else if len(args) == 3 { // /upload /path/to/file.xt "file-title.png"
-				uploadPath = args[1]
-				title = args[2]
-			} else {
-				return errors.New("Please use more arguments. /upload path/to/file.png [file name]")
+			var patterns []string
+			var title, comment, channelName string
+			usedFlags := false
+			for index := 1; index < len(args); index++ {
+				switch args[index] {
+				case "-title", "-comment", "-channel":
+					if index+1 >= len(args) {
+						return errors.New(fmt.Sprintf("Please use more arguments. %s needs a value.", args[index]))
+					}
+					switch args[index] {
+					case "-title":
+						title = args[index+1]
+					case "-comment":
+						comment = args[index+1]
+					case "-channel":
+						channelName = strings.TrimPrefix(args[index+1], "#")
+					}
+					usedFlags = true
+					index += 1
+				default:
+					patterns = append(patterns, args[index])
+				}
 			}
 
-			// Read post from filesystem
-			uploadContent, err := ioutil.ReadFile(uploadPath)
+			if len(patterns) == 0 {
+				return errors.New("Please use more arguments. /upload path/to/file.png [-title file name] [-comment message] [-channel channel name]")
+			}
+
+			// For backwards compatibility, `/upload path/to/file.png "file name"` uses the second
+			// argument as the title.
+			if len(patterns) == 2 && !usedFlags {
+				if _, err := ExpandUploadPaths(patterns[1:]); err != nil {
+					title = patterns[1]
+					patterns = patterns[:1]
+				}
+			}
+
+			paths, err := ExpandUploadPaths(patterns)
 			if err != nil {
-				return errors.New(fmt.Sprintf("Couldn't read file %s: %s", uploadPath, err.Error()))
+				return err
 			}
 
 			if state.ActiveConnection() == nil {
 				return errors.New("No active connection!")
 			}
 
-			// Make the post
-			if err = state.ActiveConnection().PostBinary(title, uploadPath, uploadContent); err != nil {
-				return err
+			// Upload into the selected channel, unless another channel was given.
+			channel := state.ActiveConnection().SelectedChannel()
+			if len(channelName) > 0 {
+				channel = nil
+				for _, item := range state.ActiveConnection().Channels() {
+					if item.Name == channelName {
+						found := item
+						channel = &found
+						break
+					}
+				}
+				if channel == nil {
+					return errors.New(fmt.Sprintf("No such channel %s", channelName))
+				}
 			}
 
-			return nil
+			return state.Uploads.Start(state.ActiveConnection(), paths, channel, title, comment)
 		},
 	},
 
@@ -391,6 +466,58 @@ var COMMANDS = []Command{
 			return nil
 		},
 	},
+	{
+		Name:         "Download",
+		Type:         NATIVE,
+		Description:  "If a file is attached to the current message, download it.",
+		Arguments:    "[directory]",
+		Permutations: []string{"download", "dl"},
+		Handler: func(args []string, state *State) error {
+			var directory string
+			if len(args) == 1 { // /download
+				directory = state.Configuration["Download.Directory"]
+			} else if len(args) == 2 { // /download ~/Desktop
+				directory = args[1]
+			} else {
+				return errors.New("Please use more arguments. /download [directory]")
+			}
+
+			if state.ActiveConnection() == nil {
+				return errors.New("No active connection!")
+			}
+
+			selectedMessageIndex := len(state.ActiveConnection().MessageHistory()) - 1 - state.SelectedMessageIndex
+			if selectedMessageIndex < 0 {
+				return errors.New("No message selected!")
+			}
+			selectedMessage := state.ActiveConnection().MessageHistory()[selectedMessageIndex]
+			if selectedMessage.File == nil {
+				return errors.New("Selected message has no file")
+			}
+
+			download, err := state.Downloads.Start(state.ActiveConnection(), selectedMessage.File, directory)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Couldn't download %s: %s", selectedMessage.File.Name, err))
+			}
+
+			state.Status.Printf("%s", state.Downloads.StatusMessage(*download))
+			return nil
+		},
+	},
+	{
+		Name:         "Downloads",
+		Type:         NATIVE,
+		Description:  "Show all files downloaded in this session, and the progress of any that are downloading.",
+		Arguments:    "",
+		Permutations: []string{"downloads"},
+		Handler: func(args []string, state *State) error {
+			state.Mode = "modl"
+			state.Modal.Reset()
+			state.Modal.Title = downloadsModalTitle
+			state.Modal.Body = state.Downloads.ModalBody()
+			return nil
+		},
+	},
 	{
 		Name:         "OpenAttachmentLink",
 		Type:         NATIVE,
@@ -459,7 +586,7 @@ var COMMANDS = []Command{
 			selectedMessage := state.ActiveConnection().MessageHistory()[selectedMessageIndex]
 
 			var parsedMessage gateway.PrintableMessage
-			err = frontend.ParseSlackMessage(selectedMessage.Text, &parsedMessage, state.ActiveConnection().UserById)
+			err = frontend.ParseMessage(selectedMessage, &parsedMessage, state.ActiveConnection().UserById)
 			if err != nil {
 				return errors.New("Error making message print-worthy (probably because fetching user id => user name failed): " + err.Error())
 			}
@@ -485,7 +612,7 @@ var COMMANDS = []Command{
 	{
 		Name:         "ExpandAttachment",
 		Type:         NATIVE,
-		Description:  "Expand an attachment into a modal to view the full content.",
+		Description:  "Expand an attachment or snippet into a modal to view the full content.",
 		Arguments:    "<attachment index>",
 		Permutations: []string{"expandattachment", "expattach", "exat"},
 		Handler: func(args []string, state *State) error {
@@ -503,11 +630,28 @@ var COMMANDS = []Command{
 			selectedMessageIndex := len(state.ActiveConnection().MessageHistory()) - 1 - state.SelectedMessageIndex
 			selectedMessage := state.ActiveConnection().MessageHistory()[selectedMessageIndex]
 
-			if selectedMessage.Attachments == nil || len(*selectedMessage.Attachments) == 0 {
+			var attachments []gateway.Attachment
+			if selectedMessage.Attachments != nil {
+				attachments = *selectedMessage.Attachments
+			}
+
+			// A snippet is numbered after the message's attachments.
+			if file := selectedMessage.File; file != nil && len(file.Content) > 0 && attachmentIndex == len(attachments)+1 {
+				state.Mode = "modl"
+				state.Modal.Reset()
+				state.Modal.Title = file.Name
+				state.Modal.Body = file.Content
+				state.Modal.Language = file.Filetype
+				return nil
+			}
+
+			if len(attachments) == 0 {
 				return errors.New("Selected message has no attachments!")
 			}
 
-			if (attachmentIndex - 1) >= len(*selectedMessage.Attachments) {
+			if attachmentIndex < 1 {
+				return errors.New(fmt.Sprintf("Attachment index %d is too small!", attachmentIndex))
+			} else if (attachmentIndex - 1) >= len(*selectedMessage.Attachments) {
 				return errors.New(fmt.Sprintf("Attachment index %d is too large!", attachmentIndex))
 			} else if attachment := (*selectedMessage.Attachments)[attachmentIndex-1]; len(attachment.Body) > 0 {
 				// Open a modal with the atatchment content.
@@ -522,6 +666,16 @@ var COMMANDS = []Command{
 			return nil
 		},
 	},
+	{
+		Name:         "Interact",
+		Type:         NATIVE,
+		Description:  "Press a button or pick an option in a menu in the selected message.",
+		Arguments:    "",
+		Permutations: []string{"interact", "buttons"},
+		Handler: func(args []string, state *State) error {
+			return OpenMessageActionPicker(state)
+		},
+	},
 	{
 		Name:         "ResendMessage",
 		Type:         NATIVE,
@@ -541,8 +695,10 @@ var COMMANDS = []Command{
 				return errors.New("Message was not originally sent by you.")
 			}
 
+			encoded := selectedMessage
+			encoded.Text = EncodeMessageForConnection(state.ActiveConnection(), selectedMessage.Text)
 			responseMessage, err := state.ActiveConnection().SendMessage(
-				selectedMessage,
+				encoded,
 				state.ActiveConnection().SelectedChannel(),
 			)
 
@@ -645,6 +801,29 @@ var COMMANDS = []Command{
 		},
 	},
 
+	//
+	// JUMP TO A TIME IN THE MESSAGE HISTORY
+	//
+	{
+		Name:         "Goto",
+		Type:         NATIVE,
+		Description:  "Jump to a date or time in the active channel's message history.",
+		Arguments:    "<date or relative time>",
+		Permutations: []string{"goto"},
+		Handler: func(args []string, state *State) error {
+			if len(args) < 2 {
+				return errors.New("Please use more arguments. /goto <date or relative time>")
+			}
+
+			when, err := ParseGotoTime(strings.Join(args[1:], " "), time.Now())
+			if err != nil {
+				return err
+			}
+
+			return GotoMessageHistory(state, when)
+		},
+	},
+
 	//
 	// CHANGE THE ACTIVE CHANNEL
 	//
@@ -797,6 +976,221 @@ var COMMANDS = []Command{
 			return err
 		},
 	},
+	{
+		Name:         "Browse",
+		Type:         NATIVE,
+		Description:  "Browse all public channels, to preview, join, or leave them.",
+		Arguments:    "[members|name]",
+		Permutations: []string{"browse"},
+		Handler: func(args []string, state *State) error {
+			sortBy := BROWSE_SORT_MEMBERS
+			if len(args) == 2 {
+				sortBy = args[1]
+			} else if len(args) > 2 {
+				return errors.New("Please use less arguments. /browse [members|name]")
+			}
+			return OpenChannelBrowser(state, sortBy)
+		},
+	},
+	{
+		Name:         "Info",
+		Type:         NATIVE,
+		Description:  "Show the topic, purpose, and members of the selected channel.",
+		Permutations: []string{"info", "channelinfo"},
+		Handler: func(args []string, state *State) error {
+			conn, channel, err := selectedChannelForInfo(state)
+			if err != nil {
+				return err
+			}
+
+			// Fetch every user at once, rather than looking up each member one at a time.
+			if len(conn.Users()) == 0 {
+				if _, err := conn.FetchUsers(); err != nil {
+					return err
+				}
+			}
+			if channel, err = conn.FetchChannelInfo(channel); err != nil {
+				return err
+			}
+
+			state.Mode = "modl"
+			state.Modal.Reset()
+			state.Modal.Title = channel.Label()
+			state.Modal.Body = ChannelInfoBody(conn, *channel, state.Configuration["Message.Sender.OnlinePrefix"])
+			return nil
+		},
+	},
+	{
+		Name:         "Topic",
+		Type:         NATIVE,
+		Description:  "Set the topic of the selected channel, or show it if no topic is passed.",
+		Arguments:    "[topic]",
+		Permutations: []string{"topic"},
+		Handler: func(args []string, state *State) error {
+			conn, channel, err := selectedChannelForInfo(state)
+			if err != nil {
+				return err
+			}
+			if len(args) < 2 {
+				if len(channel.Topic) == 0 {
+					state.Status.Printf("#%s has no topic.", channel.Name)
+				} else {
+					state.Status.Printf("Topic of #%s: %s", channel.Name, channel.Topic)
+				}
+				return nil
+			}
+
+			_, err = conn.SetChannelTopic(channel, strings.Join(args[1:], " "))
+			return err
+		},
+	},
+	{
+		Name:         "Purpose",
+		Type:         NATIVE,
+		Description:  "Set the purpose of the selected channel, or show it if no purpose is passed.",
+		Arguments:    "[purpose]",
+		Permutations: []string{"purpose"},
+		Handler: func(args []string, state *State) error {
+			conn, channel, err := selectedChannelForInfo(state)
+			if err != nil {
+				return err
+			}
+			if len(args) < 2 {
+				if len(channel.Purpose) == 0 {
+					state.Status.Printf("#%s has no purpose.", channel.Name)
+				} else {
+					state.Status.Printf("Purpose of #%s: %s", channel.Name, channel.Purpose)
+				}
+				return nil
+			}
+
+			_, err = conn.SetChannelPurpose(channel, strings.Join(args[1:], " "))
+			return err
+		},
+	},
+	{
+		Name:         "Whois",
+		Type:         NATIVE,
+		Description:  "Show a user's profile. Without a user, show the profile of the selected message's sender.",
+		Arguments:    "[user]",
+		Permutations: []string{"whois", "profile"},
+		Handler: func(args []string, state *State) error {
+			conn := state.ActiveConnection()
+			if conn == nil {
+				return errors.New("No active connection!")
+			}
+
+			var user *gateway.User
+			var err error
+			if len(args) == 2 {
+				if user, err = FindUserByName(conn, args[1]); err != nil {
+					return err
+				}
+			} else if len(args) == 1 {
+				selectedMessageIndex := len(conn.MessageHistory()) - 1 - state.SelectedMessageIndex
+				if selectedMessageIndex < 0 || selectedMessageIndex >= len(conn.MessageHistory()) {
+					return errors.New("No message selected.")
+				}
+				sender := conn.MessageHistory()[selectedMessageIndex].Sender
+				if sender == nil {
+					return errors.New("Selected message has no sender.")
+				}
+				// Look up the sender again, since messages don't always have the full profile.
+				if user, err = conn.UserById(sender.Id); err != nil {
+					return err
+				}
+			} else {
+				return errors.New("Please use one argument. /whois <user>")
+			}
+
+			OpenUserProfile(state, user)
+			return nil
+		},
+	},
+	{
+		Name:         "DirectMessage",
+		Type:         NATIVE,
+		Description:  "Open a direct message with one or more users. Without users, pick them from a list.",
+		Arguments:    "[users...]",
+		Permutations: []string{"dm", "directmessage"},
+		Handler: func(args []string, state *State) error {
+			conn := state.ActiveConnection()
+			if conn == nil {
+				return errors.New("No active connection!")
+			}
+			if len(args) < 2 {
+				return OpenUserPicker(state)
+			}
+
+			var users []*gateway.User
+			for _, name := range args[1:] {
+				user, err := FindUserByName(conn, name)
+				if err != nil {
+					return err
+				}
+				users = append(users, user)
+			}
+			return OpenConversationWithUsers(state, users)
+		},
+	},
+
+	//
+	// SPLIT PANES
+	//
+	{
+		Name:         "Split",
+		Type:         NATIVE,
+		Description:  "Split the screen, showing a channel below the selected channel.",
+		Arguments:    "[connection name] [channel name]",
+		Permutations: []string{"split", "sp"},
+		Handler: func(args []string, state *State) error {
+			conn, channel, err := paneChannelFromArgs(args, state)
+			if err != nil {
+				return err
+			}
+			return SplitPane(state, SPLIT_HORIZONTAL, conn, *channel)
+		},
+	},
+	{
+		Name:         "VerticalSplit",
+		Type:         NATIVE,
+		Description:  "Split the screen, showing a channel beside the selected channel.",
+		Arguments:    "[connection name] [channel name]",
+		Permutations: []string{"vsplit", "vs"},
+		Handler: func(args []string, state *State) error {
+			conn, channel, err := paneChannelFromArgs(args, state)
+			if err != nil {
+				return err
+			}
+			return SplitPane(state, SPLIT_VERTICAL, conn, *channel)
+		},
+	},
+	{
+		Name:         "ClosePane",
+		Type:         NATIVE,
+		Description:  "Close the focused pane.",
+		Arguments:    "",
+		Permutations: []string{"close", "closepane"},
+		Handler: func(args []string, state *State) error {
+			return ClosePane(state)
+		},
+	},
+	{
+		Name:         "FocusPane",
+		Type:         NATIVE,
+		Description:  "Focus the next or previous pane.",
+		Arguments:    "[next|previous]",
+		Permutations: []string{"focus", "focuspane"},
+		Handler: func(args []string, state *State) error {
+			if len(args) == 1 || args[1] == "next" {
+				return FocusPane(state, 1)
+			} else if args[1] == "previous" || args[1] == "prev" {
+				return FocusPane(state, -1)
+			} else {
+				return errors.New("Please specify which pane to focus. /focus [next|previous]")
+			}
+		},
+	},
 
 	//
 	// OPEN IN SLACK
@@ -330,13 +330,50 @@ func TestCommandExpandAttachment(t *testing.T) {
 	}
 }
 
+func TestCommandExpandAttachmentSnippet(t *testing.T) {
+	// Create initial state
+	state := NewInitialStateMode("writ")
+	state.Connections = []gateway.Connection{
+		gatewaySlack.NewWithName("team name", "token"),
+	}
+	channels := []gateway.Channel{gateway.Channel{Name: "channel name", Id: "channel-id"}}
+
+	state.ActiveConnection().SetChannels(channels)
+	state.ActiveConnection().SetSelectedChannel(&channels[0])
+	state.ActiveConnection().SetMessageHistory([]gateway.Message{
+		gateway.Message{
+			Text: "My Message",
+			Attachments: &[]gateway.Attachment{
+				gateway.Attachment{Title: "title", Body: "body"},
+			},
+			File: &gateway.File{Name: "main.py", Filetype: "Python", Content: "print(1)"},
+		},
+	})
+
+	// The snippet comes after the message's attachment.
+	command := *GetCommand("ExpandAttachment")
+	err := RunCommand(command, []string{"expandattachment", "2"}, state)
+
+	// Verify the output
+	if err != nil {
+		t.Errorf("Failed to open modal for expanding snippet: %s", err)
+	}
+
+	if state.Mode != "modl" {
+		t.Errorf("Mode not set to `modl`: %s", state.Mode)
+	}
+	if state.Modal.Title != "main.py" || state.Modal.Body != "print(1)" || state.Modal.Language != "Python" {
+		t.Errorf("Modal title, body, and language not set to the right values: %+v", state.Modal)
+	}
+}
+
 func TestCommandResendMessage(t *testing.T) {
 	// Mock the http request
 	userSentMessage := false
 	httpmock.Activate()
 	httpmock.RegisterResponder(
 		"GET",
-		"https://slack.com/api/chat.postMessage?token=token&channel=channel-id&text=foo&link_names=true&parse=full&unfurl_links=true&as_user=true",
+		"https://slack.com/api/chat.postMessage?token=token&channel=channel-id&text=foo&link_names=true&unfurl_links=true&as_user=true",
 		func(req *http.Request) (*http.Response, error) {
 			userSentMessage = true
 			return httpmock.NewStringResponse(200, `{"ok": true}`), nil
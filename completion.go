@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/1egoman/slick/gateway"
+	"github.com/kyokomi/emoji"
+)
+
+// An item that can be completed in the command bar, ie, a user, channel, or emoji.
+type Completion struct {
+	Display string // What's shown in the fuzzy picker
+	Text    string // What's put in the command bar, ie `@alice`
+}
+
+// Mentions that notify everyone in a channel, rather than a single user.
+var specialMentions = []string{"here", "channel", "everyone"}
+
+// Find the word before the cursor that can be completed. Returns where the word starts, and the
+// character it starts with (`@`, `#`, or `:`).
+func CompletionPrefix(command []rune, cursor int) (int, rune, bool) {
+	start := cursor
+	for start > 0 && !unicode.IsSpace(command[start-1]) {
+		start -= 1
+	}
+	if start == cursor {
+		return 0, 0, false
+	}
+
+	switch trigger := command[start]; trigger {
+	case '@', '#':
+		return start, trigger, true
+	case ':':
+		// A colon at the start of the command bar starts a command, not an emoji.
+		return start, trigger, start > 0
+	default:
+		return 0, 0, false
+	}
+}
+
+// Everything that can be completed after the given character.
+func Completions(conn gateway.Connection, trigger rune) []Completion {
+	var completions []Completion
+
+	switch trigger {
+	case '@':
+		for _, mention := range specialMentions {
+			completions = append(completions, Completion{Display: mention, Text: "@" + mention})
+		}
+
+		users := append([]gateway.User{}, conn.Users()...)
+		sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+		for _, user := range users {
+			display := user.Name
+			if len(user.RealName) > 0 {
+				display = fmt.Sprintf("%s (%s)", user.Name, user.RealName)
+			}
+			completions = append(completions, Completion{Display: display, Text: "@" + user.Name})
+		}
+
+	case '#':
+		var names []string
+		for _, channel := range conn.Channels() {
+			if channel.SubType == gateway.TYPE_CHANNEL {
+				names = append(names, channel.Name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			completions = append(completions, Completion{Display: name, Text: "#" + name})
+		}
+
+	case ':':
+		var codes []string
+		for code := range emoji.CodeMap() {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			completions = append(completions, Completion{
+				Display: fmt.Sprintf("%s %s", strings.Trim(code, ":"), emoji.Sprint(code)),
+				Text:    code,
+			})
+		}
+	}
+
+	return completions
+}
+
+// Is the fuzzy picker showing completions for the command bar?
+func IsCompleting(state *State) bool {
+	if !state.SelectionInput.Visible || len(state.SelectionInput.Items) == 0 {
+		return false
+	}
+	_, ok := state.SelectionInput.Items[0].(Completion)
+	return ok
+}
+
+// If the word before the cursor can be completed, show the possible completions in the fuzzy
+// picker. Returns false if there's nothing to complete.
+func OpenCompletion(state *State) bool {
+	start, trigger, ok := CompletionPrefix(state.Command, state.CommandCursorPosition)
+	if !ok || state.ActiveConnection() == nil {
+		return false
+	}
+
+	// Users are fetched the first time they're needed, since large teams have a lot of them.
+	if trigger == '@' && len(state.ActiveConnection().Users()) == 0 {
+		if _, err := state.ActiveConnection().FetchUsers(); err != nil {
+			state.Status.Errorf("Error fetching users: %s", err)
+		}
+	}
+
+	completions := Completions(state.ActiveConnection(), trigger)
+	if len(completions) == 0 {
+		return false
+	}
+
+	state.SelectionInput.Hide()
+	state.SelectionInput.Show(InsertCompletion)
+	for _, completion := range completions {
+		state.SelectionInput.Items = append(state.SelectionInput.Items, completion)
+		state.SelectionInput.StringItems = append(state.SelectionInput.StringItems, completion.Display)
+	}
+	state.SelectionInput.ThrowAwayPrefix = start + 1 // Search with the text after the `@`, `#`, or `:`
+
+	// Once the user moves out of the word being completed, stop completing it.
+	state.SelectionInput.Resort(func(state *State) {
+		current, _, ok := CompletionPrefix(state.Command, state.CommandCursorPosition)
+		if !ok || current != start {
+			state.SelectionInput.Hide()
+		}
+	})
+	return true
+}
+
+// Replace the word being completed with the selected completion.
+func InsertCompletion(state *State) {
+	completion, ok := state.SelectionInput.Items[state.SelectionInput.SelectedItem].(Completion)
+	start := state.SelectionInput.ThrowAwayPrefix - 1
+	state.SelectionInput.Hide()
+	if !ok || start < 0 || start > state.CommandCursorPosition {
+		return
+	}
+
+	command := append([]rune{}, state.Command[:start]...)
+	command = append(command, []rune(completion.Text+" ")...)
+	state.Command = append(command, state.Command[state.CommandCursorPosition:]...)
+	state.CommandCursorPosition = start + len([]rune(completion.Text)) + 1
+}
+
+var mentionRegex = regexp.MustCompile(`(^|\s)([@#])([\w.\-]+)`)
+
+// Convert a message typed by the user into the format slack expects: escape the characters slack
+// uses for markup, and turn `@user`, `#channel`, and `@here` into mentions (`<@U123>`,
+// `<#C123|channel>`, `<!here>`) so that they notify people.
+func EncodeMessageText(text string, users []gateway.User, channels []gateway.Channel) string {
+	text = strings.Replace(text, "&", "&amp;", -1)
+	text = strings.Replace(text, "<", "&lt;", -1)
+	text = strings.Replace(text, ">", "&gt;", -1)
+
+	return mentionRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := mentionRegex.FindStringSubmatch(match)
+		space, trigger, name := parts[1], parts[2], parts[3]
+
+		// Punctuation at the end of a name usually ends the sentence, like `thanks @alice.`
+		for suffix := ""; len(name) > 0; name, suffix = name[:len(name)-1], name[len(name)-1:]+suffix {
+			if encoded, ok := encodeMention(trigger, name, users, channels); ok {
+				return space + encoded + suffix
+			}
+			if last := name[len(name)-1]; last != '.' && last != '-' {
+				break
+			}
+		}
+		return match
+	})
+}
+
+func encodeMention(trigger string, name string, users []gateway.User, channels []gateway.Channel) (string, bool) {
+	if trigger == "@" {
+		for _, mention := range specialMentions {
+			if name == mention {
+				return "<!" + mention + ">", true
+			}
+		}
+		for _, user := range users {
+			if user.Name == name {
+				return "<@" + user.Id + ">", true
+			}
+		}
+	} else {
+		for _, channel := range channels {
+			if channel.SubType == gateway.TYPE_CHANNEL && channel.Name == name {
+				return "<#" + channel.Id + "|" + channel.Name + ">", true
+			}
+		}
+	}
+	return "", false
+}
+
+// Encode a message to be sent on the given connection. Slash commands are sent as they were typed.
+func EncodeMessageForConnection(conn gateway.Connection, text string) string {
+	if strings.HasPrefix(text, "/") {
+		return text
+	}
+	return EncodeMessageText(text, conn.Users(), conn.Channels())
+}
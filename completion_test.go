@@ -0,0 +1,84 @@
+package main_test
+
+import (
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+)
+
+func TestCompletionPrefix(t *testing.T) {
+	for _, test := range []struct {
+		Command string
+		Start   int
+		Trigger rune
+		Ok      bool
+	}{
+		{"hello @al", 6, '@', true},
+		{"@al", 0, '@', true},
+		{"see #gen", 4, '#', true},
+		{"nice :smi", 5, ':', true},
+		{":smi", 0, 0, false}, // A command, not an emoji
+		{"hello al", 0, 0, false},
+		{"hello @al ", 0, 0, false},
+	} {
+		start, trigger, ok := CompletionPrefix([]rune(test.Command), len([]rune(test.Command)))
+		if ok != test.Ok || (ok && (start != test.Start || trigger != test.Trigger)) {
+			t.Errorf("CompletionPrefix(%q) = %d %q %v", test.Command, start, trigger, ok)
+		}
+	}
+}
+
+func TestEncodeMessageText(t *testing.T) {
+	users := []gateway.User{{Id: "U1", Name: "alice"}, {Id: "U2", Name: "bob.smith"}}
+	channels := []gateway.Channel{
+		{Id: "C1", Name: "general", SubType: gateway.TYPE_CHANNEL},
+		{Id: "D1", Name: "im-me-alice", SubType: gateway.TYPE_DIRECT_MESSAGE},
+	}
+
+	for _, test := range []struct {
+		Text     string
+		Expected string
+	}{
+		{"hi @alice", "hi <@U1>"},
+		{"thanks @alice.", "thanks <@U1>."},
+		{"@bob.smith, see #general", "<@U2>, see <#C1|general>"},
+		{"@here look", "<!here> look"},
+		{"@nobody and #nowhere", "@nobody and #nowhere"},
+		{"#im-me-alice", "#im-me-alice"},
+		{"email@alice.com", "email@alice.com"},
+		{"a < b && c > d", "a &lt; b &amp;&amp; c &gt; d"},
+	} {
+		if encoded := EncodeMessageText(test.Text, users, channels); encoded != test.Expected {
+			t.Errorf("EncodeMessageText(%q) = %q, expected %q", test.Text, encoded, test.Expected)
+		}
+	}
+}
+
+func TestTabCompletesChannel(t *testing.T) {
+	state := NewInitialStateMode("writ")
+	state.Connections = append(state.Connections, gatewaySlack.New("token"))
+	state.SetActiveConnection(len(state.Connections) - 1)
+	state.ActiveConnection().SetChannels([]gateway.Channel{
+		{Id: "C1", Name: "general", SubType: gateway.TYPE_CHANNEL},
+		{Id: "D1", Name: "im-me-alice", SubType: gateway.TYPE_DIRECT_MESSAGE},
+	})
+	state.Command = []rune("see #gen")
+	state.CommandCursorPosition = len(state.Command)
+
+	quit := make(chan struct{}, 1)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyTab, ' ', tcell.ModNone), state, nil, quit)
+	if !IsCompleting(state) || len(state.SelectionInput.StringItems) != 1 {
+		t.Fatalf("Tab didn't show channels to complete: %v", state.SelectionInput.StringItems)
+	}
+
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, nil, quit)
+	if string(state.Command) != "see #general " || state.CommandCursorPosition != len(state.Command) {
+		t.Errorf("Completion wasn't put in the command bar: %q", string(state.Command))
+	}
+	if state.Mode != "writ" || state.SelectionInput.Visible {
+		t.Errorf("Completing should keep writing, in mode %s", state.Mode)
+	}
+}
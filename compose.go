@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+)
+
+// The editor to use when $VISUAL and $EDITOR aren't set.
+const defaultEditor = "vi"
+
+// Which editor should messages be composed in? $VISUAL and $EDITOR can contain arguments, like
+// `code --wait`.
+func composeEditor() []string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.Fields(os.Getenv(variable)); len(editor) > 0 {
+			return editor
+		}
+	}
+	return []string{defaultEditor}
+}
+
+// Open the given text in the user's editor, giving it the terminal until it exits, and return the
+// edited text.
+func EditInEditor(term *frontend.TerminalDisplay, text string) (string, error) {
+	file, err := ioutil.TempFile("", "slick-message-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(text)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	editor := composeEditor()
+	err = term.Suspend(func() error {
+		cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	})
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error running editor %s: %s", editor[0], err))
+	}
+
+	edited, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	// Most editors add a newline to the end of the file.
+	return strings.TrimSuffix(string(edited), "\n"), nil
+}
+
+// Send a message to the active channel as the user, showing it in the message history until it's
+// confirmed.
+func SendMessageToActiveChannel(state *State, text string) error {
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return errors.New("No active connection!")
+	}
+	return SendMessage(state, conn, conn.SelectedChannel(), text)
+}
+
+// Send a message to a channel in a connection.
+func SendMessage(state *State, conn gateway.Connection, channel *gateway.Channel, text string) error {
+	// Emit event to to be handled by lua scripts
+	EmitEvent(state, EVENT_MESSAGE_SENT, map[string]string{
+		"sender": conn.Self().Name,
+		"text":   text,
+	})
+
+	// Append the message the the history, but make it disabled.
+	message := gateway.Message{
+		Sender:    conn.Self(),
+		Text:      text,
+		Confirmed: false,
+	}
+	conn.AppendMessageHistory(message)
+
+	// Sometimes, a message could have a response. This is for example true in the
+	// case of slash commands, sometimes.
+	// Send the message with mentions encoded, but show it in the history as it was typed.
+	encoded := message
+	encoded.Text = EncodeMessageForConnection(conn, text)
+	responseMessage, err := conn.SendMessage(encoded, channel)
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error sending message: %s", err))
+	} else if responseMessage != nil {
+		// Got a response command? Append it to the message history.
+		conn.AppendMessageHistory(*responseMessage)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/frontend"
+	"github.com/gdamore/tcell"
+)
+
+func TestComposeLoadsEditedMessageIntoCommandBar(t *testing.T) {
+	directory, err := ioutil.TempDir("", "slick-compose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	// An "editor" that replaces the message.
+	editor := filepath.Join(directory, "editor.sh")
+	ioutil.WriteFile(editor, []byte("printf 'Hello from the editor' > \"$1\"\n"), 0700)
+	defer os.Setenv("VISUAL", os.Getenv("VISUAL"))
+	os.Setenv("VISUAL", "sh "+editor)
+
+	term := frontend.NewTerminalDisplay(frontend.NewAsciiScreen())
+	term.SetNewScreen(func() (tcell.Screen, error) { return frontend.NewAsciiScreen(), nil })
+
+	state := NewInitialStateMode("chat")
+	quit := make(chan struct{}, 1)
+	for _, char := range "/compose Hi" {
+		HandleKeyboardEvent(NewRuneEvent(char), state, term, quit)
+	}
+	if !state.SelectionInput.Visible {
+		t.Fatalf("Expected the command picker to be open")
+	}
+
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, term, quit)
+	if string(state.Command) != "Hello from the editor" {
+		t.Errorf("Expected the edited message in the command bar, got %q", string(state.Command))
+	}
+	if state.Mode != "writ" || state.SelectionInput.Visible {
+		t.Errorf("Expected to be writing the message, in mode %s", state.Mode)
+	}
+}
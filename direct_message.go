@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// A user in the fuzzy picker opened by `/dm`. Users that are selected are added to a group direct
+// message.
+type DirectMessageUserItem struct {
+	User     gateway.User
+	Selected bool
+}
+
+// Is the fuzzy picker showing users to start a direct message with?
+func IsPickingUsers(state *State) bool {
+	if !state.SelectionInput.Visible || len(state.SelectionInput.Items) == 0 {
+		return false
+	}
+	_, ok := state.SelectionInput.Items[0].(DirectMessageUserItem)
+	return ok
+}
+
+func directMessageUserLabel(item DirectMessageUserItem) string {
+	label := item.User.Name
+	if len(item.User.RealName) > 0 {
+		label = fmt.Sprintf("%s (%s)", item.User.Name, item.User.RealName)
+	}
+	if item.Selected {
+		label += "\tselected"
+	}
+	return label
+}
+
+// List every user on the active connection in the fuzzy picker, to start a conversation with.
+func OpenUserPicker(state *State) error {
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return errors.New("No active connection!")
+	}
+	if len(conn.Users()) == 0 {
+		if _, err := conn.FetchUsers(); err != nil {
+			return err
+		}
+	}
+
+	users := append([]gateway.User{}, conn.Users()...)
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+
+	state.SelectionInput.Hide()
+	state.SelectionInput.Show(OnPickDirectMessageUsers)
+	for _, user := range users {
+		if self := conn.Self(); self != nil && user.Id == self.Id {
+			continue
+		}
+		item := DirectMessageUserItem{User: user}
+		state.SelectionInput.Items = append(state.SelectionInput.Items, item)
+		state.SelectionInput.StringItems = append(state.SelectionInput.StringItems, directMessageUserLabel(item))
+	}
+	if len(state.SelectionInput.Items) == 0 {
+		state.SelectionInput.Hide()
+		return errors.New("No users to send a direct message to.")
+	}
+
+	state.Mode = "pick"
+	state.Status.Printf("Press tab to select more than one user for a group direct message.")
+	return nil
+}
+
+// Select or deselect the highlighted user in the user picker.
+func ToggleDirectMessageUser(state *State) {
+	index := state.SelectionInput.SelectedItem
+	if item, ok := state.SelectionInput.Items[index].(DirectMessageUserItem); ok {
+		item.Selected = !item.Selected
+		state.SelectionInput.Items[index] = item
+		state.SelectionInput.StringItems[index] = directMessageUserLabel(item)
+	}
+}
+
+// When the user presses enter in the user picker, open a conversation with every selected user, or
+// the highlighted user if none are selected.
+func OnPickDirectMessageUsers(state *State) {
+	var users []*gateway.User
+	for _, item := range state.SelectionInput.Items {
+		if item, ok := item.(DirectMessageUserItem); ok && item.Selected {
+			users = append(users, &item.User)
+		}
+	}
+	if len(users) == 0 {
+		if item, ok := state.SelectionInput.Items[state.SelectionInput.SelectedItem].(DirectMessageUserItem); ok {
+			users = append(users, &item.User)
+		}
+	}
+
+	if err := OpenConversationWithUsers(state, users); err != nil {
+		state.Status.Errorf(err.Error())
+	}
+}
+
+// Open a direct message (or a group direct message, with more than one user), then switch to it.
+func OpenConversationWithUsers(state *State, users []*gateway.User) error {
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return errors.New("No active connection!")
+	}
+
+	channel, err := conn.OpenConversation(users)
+	if err != nil {
+		return err
+	}
+
+	state.SelectChannel(state.ActiveConnectionIndex(), channel)
+	return nil
+}
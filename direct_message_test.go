@@ -0,0 +1,54 @@
+package main_test
+
+import (
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestDirectMessagePickerSelectsSeveralUsers(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.list?token=token&limit=200",
+		httpmock.NewStringResponder(200, `{"ok": true, "members": [
+			{"id": "U0", "name": "me"},
+			{"id": "U2", "name": "bob"},
+			{"id": "U1", "name": "alice", "profile": {"real_name": "Alice Smith"}}
+		]}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.open?token=token&users=U1,U2&return_im=true",
+		httpmock.NewStringResponder(200, `{"ok": true, "channel": {"id": "G1", "name": "mpdm-me--alice--bob-1"}}`))
+
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelf(gateway.User{Id: "U0", Name: "me"})
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+
+	if err := GetCommand("DirectMessage").Handler([]string{"dm"}, state); err != nil {
+		t.Fatalf("Error opening the user picker: %s", err)
+	}
+	if state.Mode != "pick" || !IsPickingUsers(state) || len(state.SelectionInput.StringItems) != 2 {
+		t.Fatalf("Expected a picker with everyone but yourself, got %q", state.SelectionInput.StringItems)
+	}
+
+	// Select both users.
+	quit := make(chan struct{}, 1)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyTab, ' ', tcell.ModNone), state, nil, quit)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlK, ' ', tcell.ModNone), state, nil, quit)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyTab, ' ', tcell.ModNone), state, nil, quit)
+	if state.SelectionInput.StringItems[0] != "alice (Alice Smith)\tselected" || state.SelectionInput.StringItems[1] != "bob\tselected" {
+		t.Errorf("Expected both users to be selected, got %q", state.SelectionInput.StringItems)
+	}
+
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, nil, quit)
+	if channel := conn.SelectedChannel(); channel == nil || channel.Id != "G1" || channel.SubType != gateway.TYPE_GROUP_DIRECT_MESSAGE {
+		t.Errorf("Expected the group direct message to be selected, got %+v", channel)
+	}
+	if state.Mode != "chat" {
+		t.Errorf("Expected to be back in chat mode, got %s", state.Mode)
+	}
+}
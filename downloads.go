@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// How many files can be downloaded at once. More downloads wait for one of these to finish.
+const maxConcurrentDownloads = 3
+
+// How often progress updates are sent while a file is downloading.
+const downloadProgressInterval = 250 * time.Millisecond
+
+const downloadsModalTitle = "Downloads"
+
+type DownloadStatus int
+
+const (
+	DOWNLOAD_QUEUED DownloadStatus = iota
+	DOWNLOAD_IN_PROGRESS
+	DOWNLOAD_COMPLETE
+	DOWNLOAD_FAILED
+)
+
+type Download struct {
+	Name    string // The name of the file in slack
+	Path    string // Where the file is being saved
+	Size    int64  // In bytes, or -1 if the size isn't known
+	Written int64
+	Status  DownloadStatus
+	Error   error
+}
+
+// Keeps track of all files downloaded during this session. Whenever a download makes progress,
+// finishes, or fails, a copy of it is sent to `Updates`.
+type Downloads struct {
+	mutex   sync.Mutex
+	items   []*Download
+	slots   chan bool
+	Updates chan Download
+}
+
+func NewDownloads() *Downloads {
+	return &Downloads{
+		slots:   make(chan bool, maxConcurrentDownloads),
+		Updates: make(chan Download, maxConcurrentDownloads),
+	}
+}
+
+// Expand a leading `~` in a path to the user's home directory.
+func ExpandHomeDirectory(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return os.Getenv("HOME") + path[1:]
+	}
+	return path
+}
+
+// Create an empty file to download into, so that two downloads can never pick the same name. If
+// a file with the name already exists, add a number to the name (`foo.png` becomes
+// `foo (1).png`).
+func CreateDownloadFile(directory string, name string) (*os.File, error) {
+	// Never let a file name from slack escape the download directory.
+	name = filepath.Base(name)
+	if name == "." || name == "/" || len(name) == 0 {
+		name = "download"
+	}
+	extension := path.Ext(name)
+	base := strings.TrimSuffix(name, extension)
+
+	for attempt := 0; ; attempt++ {
+		filename := name
+		if attempt > 0 {
+			filename = fmt.Sprintf("%s (%d)%s", base, attempt, extension)
+		}
+
+		file, err := os.OpenFile(filepath.Join(directory, filename), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		return file, err
+	}
+}
+
+// Start downloading a file into the given directory in the background.
+func (d *Downloads) Start(conn gateway.Connection, file *gateway.File, directory string) (*Download, error) {
+	directory = ExpandHomeDirectory(directory)
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+
+	output, err := CreateDownloadFile(directory, file.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	download := &Download{
+		Name:   file.Name,
+		Path:   output.Name(),
+		Size:   int64(file.Size),
+		Status: DOWNLOAD_QUEUED,
+	}
+	if download.Size == 0 {
+		download.Size = -1
+	}
+
+	d.mutex.Lock()
+	d.items = append(d.items, download)
+	d.mutex.Unlock()
+
+	go d.run(conn, file, download, output)
+	return download, nil
+}
+
+func (d *Downloads) run(conn gateway.Connection, file *gateway.File, download *Download, output *os.File) {
+	defer output.Close()
+
+	// Wait for a slot to open up.
+	d.slots <- true
+	defer func() { <-d.slots }()
+
+	d.update(download, func() { download.Status = DOWNLOAD_IN_PROGRESS })
+	log.Printf("Downloading %s to %s", file.Name, download.Path)
+
+	body, size, err := conn.FetchFile(file)
+	if err == nil {
+		defer body.Close()
+		if size >= 0 {
+			d.update(download, func() { download.Size = size })
+		}
+		_, err = io.Copy(output, &downloadProgressReader{reader: body, downloads: d, download: download})
+	}
+
+	if err != nil {
+		log.Printf("Error downloading %s: %s", file.Name, err)
+		os.Remove(download.Path)
+		d.finish(download, func() {
+			download.Status = DOWNLOAD_FAILED
+			download.Error = err
+		})
+	} else {
+		d.finish(download, func() { download.Status = DOWNLOAD_COMPLETE })
+	}
+}
+
+// Change a download, then let anyone listening know about the change if they aren't busy.
+func (d *Downloads) update(download *Download, change func()) {
+	d.mutex.Lock()
+	change()
+	snapshot := *download
+	d.mutex.Unlock()
+
+	select {
+	case d.Updates <- snapshot:
+	default:
+	}
+}
+
+// Like update, but always sent, so that a download finishing is never missed.
+func (d *Downloads) finish(download *Download, change func()) {
+	d.mutex.Lock()
+	change()
+	snapshot := *download
+	d.mutex.Unlock()
+
+	d.Updates <- snapshot
+}
+
+// Return a copy of every download, oldest first.
+func (d *Downloads) List() []Download {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var downloads []Download
+	for _, download := range d.items {
+		downloads = append(downloads, *download)
+	}
+	return downloads
+}
+
+// How many downloads haven't finished?
+func (d *Downloads) Active() int {
+	var active int
+	for _, download := range d.List() {
+		if download.Status == DOWNLOAD_QUEUED || download.Status == DOWNLOAD_IN_PROGRESS {
+			active += 1
+		}
+	}
+	return active
+}
+
+// A message to show in the status bar when a download changes.
+func (d *Downloads) StatusMessage(download Download) string {
+	var message string
+	switch download.Status {
+	case DOWNLOAD_COMPLETE:
+		message = fmt.Sprintf("Downloaded %s to %s", download.Name, download.Path)
+	case DOWNLOAD_FAILED:
+		message = fmt.Sprintf("Couldn't download %s: %s", download.Name, download.Error)
+	default:
+		message = fmt.Sprintf("Downloading %s %s", download.Name, download.Progress())
+	}
+
+	// Mention the other downloads that are still happening.
+	others := d.Active()
+	if download.Status == DOWNLOAD_QUEUED || download.Status == DOWNLOAD_IN_PROGRESS {
+		others -= 1
+	}
+	if others > 0 {
+		message += fmt.Sprintf(" (%d more downloading, /downloads to see all)", others)
+	}
+	return message
+}
+
+// Describe how far along the download is, ie `45% (1.2 MB of 2.6 MB)`.
+func (download Download) Progress() string {
+	switch download.Status {
+	case DOWNLOAD_QUEUED:
+		return "(queued)"
+	case DOWNLOAD_FAILED:
+		return "(failed)"
+	default:
+		return FormatTransferProgress(download.Written, download.Size)
+	}
+}
+
+// Describe how much of a file has been transferred, ie `45% (1.2 MB of 2.6 MB)`. If the size of
+// the file isn't known, only the amount transferred is shown.
+func FormatTransferProgress(transferred int64, size int64) string {
+	if size > 0 {
+		return fmt.Sprintf(
+			"%d%% (%s of %s)",
+			transferred*100/size,
+			FormatByteSize(transferred),
+			FormatByteSize(size),
+		)
+	} else {
+		return fmt.Sprintf("(%s)", FormatByteSize(transferred))
+	}
+}
+
+// Format a number of bytes to be read by a human, ie `1.2 MB`.
+func FormatByteSize(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	size := float64(bytes) / 1024
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		if size < 1024 || unit == "GB" {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+		size /= 1024
+	}
+	return ""
+}
+
+// The body of the `/downloads` modal.
+func (d *Downloads) ModalBody() string {
+	downloads := d.List()
+	if len(downloads) == 0 {
+		return "No files have been downloaded."
+	}
+
+	var lines []string
+	for index := len(downloads) - 1; index >= 0; index-- { // Newest first
+		download := downloads[index]
+		switch download.Status {
+		case DOWNLOAD_COMPLETE:
+			lines = append(lines, fmt.Sprintf("done  %s -> %s (%s)", download.Name, download.Path, FormatByteSize(download.Written)))
+		case DOWNLOAD_FAILED:
+			lines = append(lines, fmt.Sprintf("error %s: %s", download.Name, download.Error))
+		default:
+			lines = append(lines, fmt.Sprintf("      %s -> %s %s", download.Name, download.Path, download.Progress()))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Counts the bytes read from a download, sending an update every so often.
+type downloadProgressReader struct {
+	reader     io.Reader
+	downloads  *Downloads
+	download   *Download
+	lastUpdate time.Time
+}
+
+func (r *downloadProgressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	r.downloads.mutex.Lock()
+	r.download.Written += int64(n)
+	r.downloads.mutex.Unlock()
+
+	if time.Since(r.lastUpdate) > downloadProgressInterval {
+		r.lastUpdate = time.Now()
+		r.downloads.update(r.download, func() {})
+	}
+	return n, err
+}
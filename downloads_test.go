@@ -0,0 +1,85 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestCreateDownloadFileAvoidsCollisions(t *testing.T) {
+	directory, err := ioutil.TempDir("", "slick-downloads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	for _, expected := range []string{"image.png", "image (1).png", "image (2).png"} {
+		file, err := CreateDownloadFile(directory, "image.png")
+		if err != nil {
+			t.Fatalf("Error creating download file: %s", err)
+		}
+		file.Close()
+
+		if filepath.Base(file.Name()) != expected {
+			t.Errorf("Download was named %s, expected %s", filepath.Base(file.Name()), expected)
+		}
+	}
+
+	// File names can't be used to write outside of the download directory.
+	file, err := CreateDownloadFile(directory, "../../etc/passwd")
+	if err != nil {
+		t.Fatalf("Error creating download file: %s", err)
+	}
+	file.Close()
+	if filepath.Dir(file.Name()) != directory {
+		t.Errorf("Download escaped the download directory: %s", file.Name())
+	}
+}
+
+func TestDownloadsStart(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://files.slack.com/files-pri/T1-F1/notes.txt",
+		httpmock.NewStringResponder(200, `Hello World`))
+
+	directory, err := ioutil.TempDir("", "slick-downloads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	downloads := NewDownloads()
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	_, err = downloads.Start(conn, &gateway.File{
+		Name:       "notes.txt",
+		PrivateUrl: "https://files.slack.com/files-pri/T1-F1/notes.txt",
+	}, directory)
+	if err != nil {
+		t.Fatalf("Error starting download: %s", err)
+	}
+
+	// Wait for the download to finish.
+	var download Download
+	for download = range downloads.Updates {
+		if download.Status == DOWNLOAD_COMPLETE || download.Status == DOWNLOAD_FAILED {
+			break
+		}
+	}
+
+	if download.Status != DOWNLOAD_COMPLETE {
+		t.Fatalf("Download didn't complete: %s", download.Error)
+	}
+	if data, _ := ioutil.ReadFile(download.Path); string(data) != "Hello World" {
+		t.Errorf("Downloaded file had the wrong contents: %s", data)
+	}
+	if download.Written != 11 || downloads.Active() != 0 {
+		t.Errorf("Download wasn't tracked: %+v", download)
+	}
+}
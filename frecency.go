@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// An item can't be boosted more than this, so that a much better fuzzy match still wins.
+const maxFrecencyScore = 100
+
+func PathToFrecency() string {
+	return PathToCache() + "frecency"
+}
+
+type FrecencyEntry struct {
+	Count    int
+	LastUsed time.Time
+}
+
+// Remembers how often and how recently each item in the fuzzy picker was picked, so that items
+// picked often are ranked higher. Pass an empty path to keep it only in memory.
+type Frecency struct {
+	mutex   sync.Mutex
+	entries map[string]FrecencyEntry
+	path    string
+	dirty   bool // Has anything been picked since the last save?
+}
+
+func NewFrecency(path string) *Frecency {
+	return &Frecency{entries: make(map[string]FrecencyEntry), path: path}
+}
+
+// Read the frecency saved in the given file. If it can't be read, start from scratch.
+func LoadFrecency(path string) *Frecency {
+	frecency := NewFrecency(path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return frecency
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&frecency.entries); err != nil {
+		log.Printf("Couldn't read frecency %s: %s", path, err)
+		frecency.entries = make(map[string]FrecencyEntry)
+	}
+	return frecency
+}
+
+// Remember that the item was picked. It's written to disk on the next `Save`.
+func (f *Frecency) Record(key string) {
+	if len(key) == 0 {
+		return
+	}
+
+	f.mutex.Lock()
+	entry := f.entries[key]
+	entry.Count += 1
+	entry.LastUsed = time.Now()
+	f.entries[key] = entry
+	f.dirty = true
+	f.mutex.Unlock()
+}
+
+// How much to boost an item by. Items picked more often score higher, and picks in the last few
+// days count for more than picks months ago.
+func (f *Frecency) Score(key string, now time.Time) int {
+	f.mutex.Lock()
+	entry, ok := f.entries[key]
+	f.mutex.Unlock()
+	if !ok {
+		return 0
+	}
+
+	var weight int
+	switch age := now.Sub(entry.LastUsed); {
+	case age < 4*24*time.Hour:
+		weight = 4
+	case age < 14*24*time.Hour:
+		weight = 3
+	case age < 31*24*time.Hour:
+		weight = 2
+	default:
+		weight = 1
+	}
+
+	score := entry.Count * weight
+	if score > maxFrecencyScore {
+		score = maxFrecencyScore
+	}
+	return score
+}
+
+// Write the frecency to disk, if anything was picked since it was last written.
+func (f *Frecency) Save() error {
+	f.mutex.Lock()
+	if len(f.path) == 0 || !f.dirty {
+		f.mutex.Unlock()
+		return nil
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(f.entries)
+	f.dirty = err != nil
+	f.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.path, buf.Bytes(), 0644)
+}
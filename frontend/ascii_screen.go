@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"github.com/gdamore/tcell"
 	"github.com/fatih/color"
+	"github.com/1egoman/slick/textwidth"
 )
 
 /*
@@ -23,21 +24,33 @@ if ok {
 
 The `.Print` method logs the current contents of the screen to stdout, via `fmt.Println`. It takes
 no arguments.
+
+Like a real terminal, a wide character (ie, emoji or CJK text) takes up two cells, and combining
+characters (ie, accents) are drawn in the same cell as the character before them. Test files are
+compared cell by cell in the same way.
 */
 type AsciiScreen struct {
 	Content [][]rune
 	Width int
 	Height int
+
+	// The combining characters drawn in each cell.
+	combining [][][]rune
 }
 
+// Stored in the cell after a wide character, which is covered up by the wide character.
+const continuationCell rune = -1
+
 func NewAsciiScreen() *AsciiScreen {
 	screenWidth := 80
 	screenHeight := 24
 
 	// Initialize to a collection of spaces
 	content := make([][]rune, screenHeight)
+	combining := make([][][]rune, screenHeight)
 	for i := 0; i < screenHeight; i++ {
 		content[i] = make([]rune, screenWidth)
+		combining[i] = make([][]rune, screenWidth)
 		for j := 0; j < screenWidth; j++ {
 			content[i][j] = ' '
 		}
@@ -47,6 +60,7 @@ func NewAsciiScreen() *AsciiScreen {
 		Content: content,
 		Width: screenWidth,
 		Height: screenHeight,
+		combining: combining,
 	}
 }
 
@@ -96,13 +110,69 @@ func (screen AsciiScreen) HasKey(key tcell.Key) bool {
 func (screen AsciiScreen) Sync() {
 }
 func (screen AsciiScreen) GetContent(x int, y int) (mainc rune, combc []rune, style tcell.Style, width int) {
-	return screen.Content[y][x], []rune{}, tcell.StyleDefault, 1
+	if x < 0 || y < 0 || x >= screen.Width || y >= screen.Height {
+		return ' ', nil, tcell.StyleDefault, 1
+	}
+	return screen.Content[y][x], screen.combining[y][x], tcell.StyleDefault, screen.cellWidth(x, y)
 }
 func (screen AsciiScreen) SetContent(x int, y int, mainc rune, combc []rune, style tcell.Style) {
+	// Like a real terminal, anything drawn off the edge of the screen is dropped.
+	if x < 0 || y < 0 || x >= screen.Width || y >= screen.Height {
+		return
+	}
+
+	// Drawing over half of a wide character erases the other half.
+	if screen.Content[y][x] == continuationCell && x > 0 {
+		screen.Content[y][x-1] = ' '
+		screen.combining[y][x-1] = nil
+	}
+	if screen.cellWidth(x, y) == 2 && x+1 < screen.Width {
+		screen.Content[y][x+1] = ' '
+	}
+
 	screen.Content[y][x] = mainc
+	screen.combining[y][x] = combc
+	if screen.cellWidth(x, y) == 2 && x+1 < screen.Width {
+		screen.Content[y][x+1] = continuationCell
+		screen.combining[y][x+1] = nil
+	}
 }
 func (screen AsciiScreen) SetCell(x int, y int, style tcell.Style, ch ...rune) {
-	screen.Content[y][x] = ch[0]
+	if len(ch) > 0 {
+		screen.SetContent(x, y, ch[0], ch[1:], style)
+	}
+}
+
+// How many cells the character in a cell takes up.
+func (screen AsciiScreen) cellWidth(x int, y int) int {
+	if screen.Content[y][x] == continuationCell {
+		return 0
+	}
+	return textwidth.Width(string(screen.Content[y][x]))
+}
+
+// The characters drawn in each cell of a row. The cell covered by a wide character is empty.
+func (screen AsciiScreen) cells(y int) []string {
+	cells := make([]string, screen.Width)
+	for x := 0; x < screen.Width; x++ {
+		if screen.Content[y][x] != continuationCell {
+			cells[x] = string(append([]rune{screen.Content[y][x]}, screen.combining[y][x]...))
+		}
+	}
+	return cells
+}
+
+// Split a line of a test file into cells, in the same way as `cells`.
+func lineCells(line string) []string {
+	var cells []string
+	for _, cluster := range textwidth.Clusters(line) {
+		cells = append(cells, line[:cluster.Bytes])
+		line = line[cluster.Bytes:]
+		for i := 1; i < cluster.Width; i++ {
+			cells = append(cells, "")
+		}
+	}
+	return cells
 }
 
 func (screen AsciiScreen) Compare(filename string) (string, bool) {
@@ -116,17 +186,22 @@ func (screen AsciiScreen) Compare(filename string) (string, bool) {
 	var ok bool = true
 	for i := 0; i < screen.Height; i++ {
 		var line string
+		var expected []string
+		if i < len(lines) {
+			expected = lineCells(lines[i])
+		}
+		actualCells := screen.cells(i)
 		for j := 0; j < screen.Width; j++ {
-			if i < len(lines) && j < len(lines[i]) {
-				actual := rune(lines[i][j])
-				test := rune(screen.Content[i][j])
+			if j < len(expected) {
+				actual := expected[j]
+				test := actualCells[j]
 				if actual == test {
-					line += color.GreenString(string(actual))
-				} else if actual == ' ' {
-					line += color.CyanString(string(test))
+					line += color.GreenString(actual)
+				} else if actual == " " {
+					line += color.CyanString(test)
 					ok = false
 				} else {
-					line += color.RedString(string(actual))
+					line += color.RedString(actual)
 					ok = false
 				}
 			} else {
@@ -147,10 +222,7 @@ func (screen AsciiScreen) Compare(filename string) (string, bool) {
 func (screen AsciiScreen) Print() {
 	var total string
 	for i := 0; i < screen.Height; i++ {
-		var line string
-		for j := 0; j < screen.Width; j++ {
-			line += string(screen.Content[i][j])
-		}
+		line := strings.Join(screen.cells(i), "")
 		total += fmt.Sprintf("%s\n", line)
 	}
 
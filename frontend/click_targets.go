@@ -0,0 +1,81 @@
+package frontend
+
+const (
+	CLICK_TARGET_MESSAGE = iota
+	CLICK_TARGET_LINK
+	CLICK_TARGET_ATTACHMENT
+	CLICK_TARGET_CONNECTION
+	CLICK_TARGET_SELECTION_ITEM
+	CLICK_TARGET_PANE
+)
+
+// Something drawn on the screen that can be clicked on with the mouse.
+type ClickTarget struct {
+	Type int
+
+	// The index of the message, connection, item in the fuzzy picker, or pane that was clicked on.
+	Index int
+	// Which link or attachment in the message was clicked on, starting at 1.
+	Part int
+
+	X, Y          int
+	Width, Height int
+}
+
+func (t ClickTarget) contains(x int, y int) bool {
+	return x >= t.X && y >= t.Y && x < t.X+t.Width && y < t.Y+t.Height
+}
+
+// Remember that something that can be clicked on was drawn. Targets drawn into a region are clipped
+// to it, and remembered by the display that owns the screen.
+func (term *TerminalDisplay) AddClickTarget(target ClickTarget) {
+	if term.parent != nil {
+		width, height := term.screen.Size()
+		if target.X < 0 {
+			target.Width += target.X
+			target.X = 0
+		}
+		if target.Y < 0 {
+			target.Height += target.Y
+			target.Y = 0
+		}
+		if target.X+target.Width > width {
+			target.Width = width - target.X
+		}
+		if target.Y+target.Height > height {
+			target.Height = height - target.Y
+		}
+		if target.Width <= 0 || target.Height <= 0 {
+			return
+		}
+
+		target.X += term.offsetX
+		target.Y += term.offsetY
+		term.parent.AddClickTarget(target)
+		return
+	}
+
+	term.targetsMutex.Lock()
+	defer term.targetsMutex.Unlock()
+	term.targets = append(term.targets, target)
+}
+
+// Forget every target, before the screen is drawn again.
+func (term *TerminalDisplay) ClearClickTargets() {
+	term.targetsMutex.Lock()
+	defer term.targetsMutex.Unlock()
+	term.targets = nil
+}
+
+// Find what was clicked on at a position on the screen. When targets overlap, the one drawn last is
+// on top.
+func (term *TerminalDisplay) ClickTargetAt(x int, y int) (ClickTarget, bool) {
+	term.targetsMutex.Lock()
+	defer term.targetsMutex.Unlock()
+	for index := len(term.targets) - 1; index >= 0; index-- {
+		if term.targets[index].contains(x, y) {
+			return term.targets[index], true
+		}
+	}
+	return ClickTarget{}, false
+}
@@ -0,0 +1,74 @@
+package frontend_test
+
+import (
+	"testing"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/1egoman/slick/status"
+)
+
+func TestClickTargetsInMessages(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	sender := &gateway.User{Name: "foo"}
+
+	// Draw the messages to the right of a sidebar, so the targets are offset.
+	term.Region(10, 0, 70, 24).DrawMessages([]gateway.Message{
+		{Sender: sender, Text: "Hello", Confirmed: true},
+		{Sender: sender, Text: "Visit <https://example.com|example> now", Confirmed: true},
+	}, -1, 0, "", userById, userOnline, nil, map[string]string{
+		"Message.TimestampFormat": "Jan 2",
+	})
+
+	for _, test := range []struct {
+		X, Y  int
+		Found bool
+		Type  int
+		Index int
+		Part  int
+	}{
+		{12, 21, true, frontend.CLICK_TARGET_MESSAGE, 1, 0},
+		{12, 20, true, frontend.CLICK_TARGET_MESSAGE, 0, 0},
+		{26, 21, true, frontend.CLICK_TARGET_LINK, 1, 1},
+		{32, 21, true, frontend.CLICK_TARGET_LINK, 1, 1},
+		{33, 21, true, frontend.CLICK_TARGET_MESSAGE, 1, 0},
+		{5, 21, false, 0, 0, 0},  // In the sidebar
+		{12, 22, false, 0, 0, 0}, // Below the messages
+	} {
+		target, ok := term.ClickTargetAt(test.X, test.Y)
+		if ok != test.Found {
+			t.Errorf("Expected a target at (%d, %d) to be found: %t, got %+v", test.X, test.Y, test.Found, target)
+		} else if ok && (target.Type != test.Type || target.Index != test.Index || target.Part != test.Part) {
+			t.Errorf("Expected target at (%d, %d) to be %+v, got %+v", test.X, test.Y, test, target)
+		}
+	}
+
+	term.ClearClickTargets()
+	if target, ok := term.ClickTargetAt(12, 21); ok {
+		t.Errorf("Expected no targets after clearing, got %+v", target)
+	}
+}
+
+func TestClickTargetsInStatusBar(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	str := status.Status{Type: status.STATUS_LOG, Message: "", Show: false}
+
+	// "chat | 1: helloworld 2: example"
+	term.DrawStatusBar("chat", []gateway.Connection{
+		gatewaySlack.NewWithName("helloworld", "token"),
+		gatewaySlack.NewWithName("example", "token"),
+	}, nil, str, map[string]string{})
+
+	if target, ok := term.ClickTargetAt(7, 23); !ok || target.Type != frontend.CLICK_TARGET_CONNECTION || target.Index != 0 {
+		t.Errorf("Expected the first connection to be clicked on, got %+v", target)
+	}
+	if target, ok := term.ClickTargetAt(25, 23); !ok || target.Type != frontend.CLICK_TARGET_CONNECTION || target.Index != 1 {
+		t.Errorf("Expected the second connection to be clicked on, got %+v", target)
+	}
+	if target, ok := term.ClickTargetAt(2, 23); ok {
+		t.Errorf("Expected the mode not to be clickable, got %+v", target)
+	}
+}
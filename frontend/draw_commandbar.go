@@ -2,16 +2,18 @@ package frontend
 
 import (
 	// "log"
-	// "strings"
+	"strings"
 
 	"github.com/1egoman/slick/color"
 	"github.com/1egoman/slick/gateway" // The thing to interface with slack
+	"github.com/1egoman/slick/textwidth"
 )
 
 func (term *TerminalDisplay) DrawCommandBar(
 	command string,
 	cursorPosition int,
 	currentChannel *gateway.Channel,
+	userOnline func(user *gateway.User) bool,
 	currentTeamName string,
 	isOffline bool,
 	config map[string]string,
@@ -21,7 +23,13 @@ func (term *TerminalDisplay) DrawCommandBar(
 	// Generate prefix for given team and channel
 	prefix := currentTeamName
 	if currentChannel != nil {
-		prefix += "#" + currentChannel.Name
+		prefix += currentChannel.Label()
+
+		// Show if the other user in a direct message is online.
+		if currentChannel.SubType == gateway.TYPE_DIRECT_MESSAGE && len(currentChannel.DirectMessageUser) > 0 &&
+			userOnline != nil && userOnline(&gateway.User{Id: currentChannel.DirectMessageUser}) {
+			prefix += config["Message.Sender.OnlinePrefix"]
+		}
 
 		if currentChannel.IsMember == false {
 			if isOffline {
@@ -44,19 +52,24 @@ func (term *TerminalDisplay) DrawCommandBar(
 		prefix += " >"
 	}
 
-	// How many chars at max can be in a line?
-	maxLineWidth := width - 2 - len(prefix) - 1
+	prefixWidth := textwidth.Width(prefix)
 
+	// How many cells at max can be in a line?
+	maxLineWidth := width - 2 - prefixWidth - 1
+
+	// (Split by character, so a wide character or an accent is never split across two lines)
 	commandLines := make([]string, 0)
 	lastBreak := 0
-	currentLineIndex := 0
-	for index, char := range command {
-		if char == '\n' || currentLineIndex > maxLineWidth {
-			commandLines = append(commandLines, command[lastBreak:index+1])
-			lastBreak = index+1
-			currentLineIndex = 0
+	index := 0
+	currentLineWidth := 0
+	for _, cluster := range textwidth.Clusters(command) {
+		index += cluster.Bytes
+		if cluster.Base == '\n' || currentLineWidth > maxLineWidth {
+			commandLines = append(commandLines, command[lastBreak:index])
+			lastBreak = index
+			currentLineWidth = 0
 		} else {
-			currentLineIndex += 1
+			currentLineWidth += cluster.Width
 		}
 	}
 	commandLines = append(commandLines, command[lastBreak:])
@@ -71,7 +84,7 @@ func (term *TerminalDisplay) DrawCommandBar(
 	term.WriteTextStyle(0, row, color.DeSerializeStyleTcell(config["CommandBar.PrefixColor"]), prefix)
 	for index, line := range commandLines {
 		term.WriteTextStyle(
-			len(prefix)+1,
+			prefixWidth+1,
 			row+index,
 			color.DeSerializeStyleTcell(config["CommandBar.TextColor"]),
 			line,
@@ -80,7 +93,7 @@ func (term *TerminalDisplay) DrawCommandBar(
 		// Render a backslash before each line.
 		if len(line) > 0 && line[len(line)-1] == '\n' {
 			term.WriteTextStyle(
-				len(prefix)+1+len(line)-1,
+				prefixWidth+1+textwidth.Width(line),
 				row+index,
 				color.DeSerializeStyleTcell(config["CommandBar.NewLineColor"]),
 				"\\",
@@ -88,26 +101,40 @@ func (term *TerminalDisplay) DrawCommandBar(
 		}
 	}
 
-	// Show the cursor at the cursor position
+	// When nothing has been typed, show the channel's topic in its place.
+	if len(command) == 0 && currentChannel != nil && len(currentChannel.Topic) > 0 && maxLineWidth > 0 {
+		topic := textwidth.Truncate(strings.Replace(currentChannel.Topic, "\n", " ", -1), maxLineWidth)
+		term.WriteTextStyle(
+			prefixWidth+1,
+			row,
+			color.DeSerializeStyleTcell(config["CommandBar.TopicColor"]),
+			topic,
+		)
+	}
+
+	// Show the cursor at the cursor position. The cursor position is counted in runes, and the
+	// cursor is drawn after the cells taken up by the runes before it.
 	x := 0
 	y := 0
 	totalCharactersCounted := 0
 	for yPos, line := range commandLines {
+		lineRunes := []rune(line)
+
 		// Is the cursor on the given line of the output?
-		if cursorPosition <= totalCharactersCounted + len(line) {
-			// If so, the y position is the index of the line, and the x positino is the difference
-			// in the number of the characters traversed and the actual cursor position.
+		if cursorPosition <= totalCharactersCounted + len(lineRunes) {
+			// If so, the y position is the index of the line, and the x positino is the width of
+			// the characters traversed before the actual cursor position.
 			y = yPos
-			x = cursorPosition - totalCharactersCounted
+			x = textwidth.Width(string(lineRunes[:cursorPosition - totalCharactersCounted]))
 
 			// When thre's a newline at the end of the current line, render on the next line.
-			if cursorPosition == totalCharactersCounted + len(line) && len(line) > 0 && line[len(line) - 1] == '\n' {
+			if cursorPosition == totalCharactersCounted + len(lineRunes) && len(line) > 0 && line[len(line) - 1] == '\n' {
 				y += 1
 				x = 0
 			}
 			break
 		}
-		totalCharactersCounted += len(line)
+		totalCharactersCounted += len(lineRunes)
 	}
-	term.screen.ShowCursor(len(prefix)+1+x, row+y)
+	term.screen.ShowCursor(prefixWidth+1+x, row+y)
 }
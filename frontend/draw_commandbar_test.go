@@ -14,6 +14,7 @@ func TestCommandbarEmptyCommand(t *testing.T) {
 		"",
 		0,
 		&gateway.Channel{Name: "bar", IsMember: true, IsArchived: false},
+		nil,
 		"foo",
 		false,
 		map[string]string{},
@@ -33,6 +34,7 @@ func TestCommandbarArchivedNotAMember(t *testing.T) {
 		"",
 		0,
 		&gateway.Channel{Name: "bar", IsMember: false, IsArchived: true},
+		nil,
 		"foo",
 		false,
 		map[string]string{},
@@ -52,6 +54,7 @@ func TestCommandbarOffline(t *testing.T) {
 		"",
 		0,
 		&gateway.Channel{Name: "bar", IsMember: true, IsArchived: false},
+		nil,
 		"foo",
 		true,
 		map[string]string{},
@@ -71,6 +74,7 @@ func TestCommandbarOfflineNotAMember(t *testing.T) {
 		"",
 		0,
 		&gateway.Channel{Name: "bar", IsMember: false, IsArchived: true},
+		nil,
 		"foo",
 		true,
 		map[string]string{},
@@ -90,6 +94,7 @@ func TestCommandbarLongCommand(t *testing.T) {
 		"hello world - The quick brown fox jumped over the lazy dog",
 		0,
 		&gateway.Channel{Name: "bar", IsMember: true, IsArchived: false},
+		nil,
 		"foo",
 		false,
 		map[string]string{},
@@ -109,6 +114,7 @@ func TestCommandbarLongCommandWithNewlines(t *testing.T) {
 		"hello world - The quick brown \nfox jumped over the lazy dog",
 		0,
 		&gateway.Channel{Name: "bar", IsMember: true, IsArchived: false},
+		nil,
 		"foo",
 		false,
 		map[string]string{},
@@ -119,3 +125,49 @@ func TestCommandbarLongCommandWithNewlines(t *testing.T) {
 		t.Errorf("Error:\n%s", result)
 	}
 }
+
+func TestCommandbarShowsTopicWhenEmpty(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+
+	term.DrawCommandBar(
+		"",
+		0,
+		&gateway.Channel{Name: "bar", IsMember: true, Topic: "Lunch at noon"},
+		nil,
+		"foo",
+		false,
+		map[string]string{},
+	)
+
+	result, ok := screen.Compare("./tests/draw_commandbar_test/commandbar_topic.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
+
+func TestCommandbarDirectMessageShowsPresence(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+
+	term.DrawCommandBar(
+		"",
+		0,
+		&gateway.Channel{
+			Name:              "im-me-alice",
+			SubType:           gateway.TYPE_DIRECT_MESSAGE,
+			IsMember:          true,
+			DisplayName:       "alice",
+			DirectMessageUser: "U1",
+		},
+		func(user *gateway.User) bool { return user.Id == "U1" },
+		"foo",
+		false,
+		map[string]string{"Message.Sender.OnlinePrefix": "*"},
+	)
+
+	result, ok := screen.Compare("./tests/draw_commandbar_test/commandbar_direct_message_online.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
@@ -0,0 +1,291 @@
+package frontend
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"sort"
+
+	"github.com/gdamore/tcell"
+)
+
+// Images can be drawn in a few different ways:
+// - `halfblock` draws each cell as two pixels using the `▀` character with a truecolor foreground
+//   and background. It works in any terminal with truecolor support.
+// - `sixel` and `kitty` send the image to the terminal with an escape sequence, which looks much
+//   better but is only supported by some terminals.
+const (
+	IMAGE_BACKEND_HALFBLOCK = "halfblock"
+	IMAGE_BACKEND_SIXEL     = "sixel"
+	IMAGE_BACKEND_KITTY     = "kitty"
+)
+
+// The terminal doesn't tell us how large a cell is in pixels, so assume a common size when
+// rendering images that are sent to the terminal as pixels.
+const assumedCellWidth = 10
+const assumedCellHeight = 20
+
+// Kitty images are sent to the terminal in base64 chunks of at most this size.
+const kittyChunkSize = 4096
+
+// An image that is drawn with an escape sequence after tcell draws the rest of the screen.
+type placedImage struct {
+	X, Y          int
+	Columns, Rows int
+	Image         image.Image
+}
+
+// Images drawn with escape sequences are written directly to the terminal, bypassing tcell.
+// Without an output, images are always drawn with half blocks.
+func (term *TerminalDisplay) SetImageOutput(output io.Writer) {
+	term.imageOutput = output
+}
+
+// Resize an image by averaging the pixels that make up each pixel in the output image.
+func ScaleImage(img image.Image, width int, height int) *image.RGBA {
+	bounds := img.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		top := bounds.Min.Y + y*bounds.Dy()/height
+		bottom := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if bottom <= top {
+			bottom = top + 1
+		}
+
+		for x := 0; x < width; x++ {
+			left := bounds.Min.X + x*bounds.Dx()/width
+			right := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if right <= left {
+				right = left + 1
+			}
+
+			var r, g, b, a, count uint32
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+pr, g+pg, b+pb, a+pa
+					count += 1
+				}
+			}
+
+			offset := scaled.PixOffset(x, y)
+			scaled.Pix[offset+0] = uint8(r / count >> 8)
+			scaled.Pix[offset+1] = uint8(g / count >> 8)
+			scaled.Pix[offset+2] = uint8(b / count >> 8)
+			scaled.Pix[offset+3] = uint8(a / count >> 8)
+		}
+	}
+
+	return scaled
+}
+
+// How many cells should an image take up? Cells are about twice as tall as they are wide, so each
+// row of cells is two rows of pixels in the image.
+func imagePreviewSize(bounds image.Rectangle, maxColumns int, maxRows int) (int, int) {
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 || maxColumns <= 0 || maxRows <= 0 {
+		return 0, 0
+	}
+
+	// Never scale an image up.
+	columns := bounds.Dx()
+	if columns > maxColumns {
+		columns = maxColumns
+	}
+	rows := (bounds.Dy()*columns/bounds.Dx() + 1) / 2
+
+	if rows > maxRows {
+		rows = maxRows
+		columns = bounds.Dx() * rows * 2 / bounds.Dy()
+	}
+	if columns < 1 {
+		columns = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return columns, rows
+}
+
+// Given an image, draw it within the given cells using half block characters. Rows off the top of
+// the screen are skipped.
+func (term *TerminalDisplay) drawImageHalfBlocks(img image.Image, x int, y int, columns int, rows int) {
+	scaled := ScaleImage(img, columns, rows*2)
+
+	for row := 0; row < rows; row++ {
+		if y+row < 0 {
+			continue
+		}
+
+		for column := 0; column < columns; column++ {
+			top := scaled.RGBAAt(column, row*2)
+			bottom := scaled.RGBAAt(column, row*2+1)
+			style := tcell.StyleDefault.
+				Foreground(tcell.NewRGBColor(int32(top.R), int32(top.G), int32(top.B))).
+				Background(tcell.NewRGBColor(int32(bottom.R), int32(bottom.G), int32(bottom.B)))
+			term.screen.SetCell(x+column, y+row, style, '▀')
+		}
+	}
+}
+
+// Draw an image within the given cells with the configured backend. Images drawn with an escape
+// sequence are only sent to the terminal once the screen is rendered.
+func (term *TerminalDisplay) drawImage(backend string, img image.Image, x int, y int, columns int, rows int) {
+	// Images drawn with escape sequences are sent by the display that owns the terminal.
+	if term.parent != nil {
+		term.parent.drawImage(backend, img, x+term.offsetX, y+term.offsetY, columns, rows)
+		return
+	}
+
+	// Escape sequences can't draw part of an image, so images scrolled partially off the top of the
+	// screen fall back to half blocks.
+	if term.imageOutput == nil || y < 0 || backend == IMAGE_BACKEND_HALFBLOCK || len(backend) == 0 {
+		term.drawImageHalfBlocks(img, x, y, columns, rows)
+		return
+	}
+
+	term.images = append(term.images, placedImage{X: x, Y: y, Columns: columns, Rows: rows, Image: img})
+	term.imageBackend = backend
+}
+
+// Are the images about to be drawn in the same place as the ones already on the screen?
+func samePlacedImages(a []placedImage, b []placedImage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// Send images that are drawn with escape sequences to the terminal. Tcell doesn't know about these
+// images, so when they move the screen has to be redrawn from scratch to erase the old ones.
+func (term *TerminalDisplay) renderImages() {
+	if term.imageOutput == nil || samePlacedImages(term.images, term.drawnImages) {
+		term.screen.Show()
+		term.images = nil
+		return
+	}
+
+	if term.imageBackend == IMAGE_BACKEND_KITTY {
+		// Kitty keeps images in a separate layer, so they can be removed without a full redraw.
+		fmt.Fprint(term.imageOutput, "\x1b_Ga=d,q=2\x1b\\")
+		term.screen.Show()
+	} else {
+		term.screen.Sync()
+	}
+
+	for _, placed := range term.images {
+		// Save the cursor, so that tcell's idea of where the cursor is stays correct.
+		fmt.Fprintf(term.imageOutput, "\x1b7\x1b[%d;%dH", placed.Y+1, placed.X+1)
+		if term.imageBackend == IMAGE_BACKEND_KITTY {
+			writeKittyImage(term.imageOutput, placed.Image, placed.Columns, placed.Rows)
+		} else {
+			writeSixelImage(term.imageOutput, placed.Image, placed.Columns, placed.Rows)
+		}
+		fmt.Fprint(term.imageOutput, "\x1b8")
+	}
+
+	term.drawnImages = term.images
+	term.images = nil
+}
+
+// Send an image using kitty's graphics protocol. The image is sent as a png, and kitty scales it
+// to fit within the given cells.
+// More info: https://sw.kovidgoyal.net/kitty/graphics-protocol/
+func writeKittyImage(output io.Writer, img image.Image, columns int, rows int) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, ScaleImage(img, columns*assumedCellWidth, rows*assumedCellHeight)); err != nil {
+		return
+	}
+	data := base64.StdEncoding.EncodeToString(buffer.Bytes())
+
+	for start := 0; start < len(data); start += kittyChunkSize {
+		end := start + kittyChunkSize
+		more := 1
+		if end >= len(data) {
+			end = len(data)
+			more = 0
+		}
+
+		if start == 0 {
+			fmt.Fprintf(output, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", columns, rows, more, data[start:end])
+		} else {
+			fmt.Fprintf(output, "\x1b_Gm=%d;%s\x1b\\", more, data[start:end])
+		}
+	}
+}
+
+// Send an image as sixels. Each color is mapped into a 6x6x6 color cube, and then each band of six
+// rows of pixels is drawn once per color in the band.
+// More info: https://vt100.net/docs/vt3xx-gp/chapter14.html
+func writeSixelImage(output io.Writer, img image.Image, columns int, rows int) {
+	width, height := columns*assumedCellWidth, rows*assumedCellHeight
+	scaled := ScaleImage(img, width, height)
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "\x1bPq\"1;1;%d;%d", width, height)
+	for index := 0; index < 216; index++ {
+		fmt.Fprintf(&buffer, "#%d;2;%d;%d;%d", index, index/36*20, index/6%6*20, index%6*20)
+	}
+
+	for band := 0; band < height; band += 6 {
+		// For each color, which pixels in each column of the band are that color?
+		bandColors := map[int][]byte{}
+		for x := 0; x < width; x++ {
+			for bit := 0; bit < 6 && band+bit < height; bit++ {
+				pixel := scaled.RGBAAt(x, band+bit)
+				if pixel.A < 128 {
+					continue // Leave transparent pixels as the background
+				}
+				index := int(pixel.R)*5/255*36 + int(pixel.G)*5/255*6 + int(pixel.B)*5/255
+				if bandColors[index] == nil {
+					bandColors[index] = make([]byte, width)
+				}
+				bandColors[index][x] |= 1 << uint(bit)
+			}
+		}
+
+		var indexes []int
+		for index := range bandColors {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+
+		for _, index := range indexes {
+			fmt.Fprintf(&buffer, "#%d", index)
+			writeSixelRun(&buffer, bandColors[index])
+			buffer.WriteByte('$') // Go back to the start of the band for the next color
+		}
+		buffer.WriteByte('-') // Move to the next band
+	}
+
+	buffer.WriteString("\x1b\\")
+	output.Write(buffer.Bytes())
+}
+
+// Write a row of sixels, compressing repeated sixels with `!<count><sixel>`.
+func writeSixelRun(buffer *bytes.Buffer, sixels []byte) {
+	for start := 0; start < len(sixels); {
+		end := start
+		for end < len(sixels) && sixels[end] == sixels[start] {
+			end += 1
+		}
+
+		char := 63 + sixels[start]
+		if count := end - start; count > 3 {
+			fmt.Fprintf(buffer, "!%d%c", count, char)
+		} else {
+			for i := 0; i < count; i++ {
+				buffer.WriteByte(char)
+			}
+		}
+		start = end
+	}
+}
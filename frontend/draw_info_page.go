@@ -2,6 +2,7 @@ package frontend
 
 import (
 	"fmt"
+	"github.com/1egoman/slick/textwidth"
 	"github.com/1egoman/slick/version"
 	"strings"
 )
@@ -25,7 +26,7 @@ func (term *TerminalDisplay) DrawInfoPage() {
 	firstRowPosition := (height - len(rows)) / 2
 
 	for index, row := range rows {
-		xPos := (width - len(row)) / 2
+		xPos := (width - textwidth.Width(row)) / 2
 		term.WriteText(xPos, firstRowPosition+index, row)
 	}
 }
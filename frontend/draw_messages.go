@@ -2,7 +2,9 @@ package frontend
 
 import (
 	"fmt"
+	"image"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,10 +13,14 @@ import (
 
 	"github.com/1egoman/slick/color"
 	"github.com/1egoman/slick/gateway" // The thing to interface with slack
+	"github.com/1egoman/slick/textwidth"
 )
 
 const attachmentBodyPreviewLines = 4;
 
+// The format of the date shown in the separator between messages sent on different days.
+const dateSeparatorFormat = "Monday, January 2, 2006"
+
 // Given an array of reactions and a row to render them on, render them.
 func renderReactions(
 	term *TerminalDisplay,
@@ -43,7 +49,7 @@ func renderReactions(
 		)
 
 		// Offset the next reaction.
-		reactionOffset += len(reactionEmoji) + 1
+		reactionOffset += textwidth.Width(reactionEmoji) + 1
 	}
 }
 
@@ -97,7 +103,7 @@ func renderFile(
 	if file != nil {
 		var messageActions []string
 		if isSelected {
-			messageActions = []string{"Open", "Copy"}
+			messageActions = []string{"Open", "Copy", "Download"}
 		}
 
 		fileRow := fmt.Sprintf(
@@ -108,7 +114,7 @@ func renderFile(
 		term.WriteTextStyle(leftOffset, row, color.DeSerializeStyleTcell(config["Message.FileColor"]), fileRow)
 
 		// Render actions after the file
-		messageActionOffset := leftOffset + len(fileRow) + 1 // Add a space netween file and actions
+		messageActionOffset := leftOffset + textwidth.Width(fileRow) + 1 // Add a space netween file and actions
 		renderActions(term, config, messageActions, messageActionOffset, row)
 	}
 }
@@ -137,9 +143,10 @@ func renderAttachment(
 		Bold(true)
 
 	title := attachment.Title
-	if len(title) > maxAttachmentWidth {
-		title = title[:maxAttachmentWidth]
+	if maxAttachmentWidth < 0 {
+		maxAttachmentWidth = 0
 	}
+	title = textwidth.Truncate(title, maxAttachmentWidth)
 
 	term.WriteTextStyle(leftOffset, row, attachmentColor, "+ ")
 
@@ -154,7 +161,7 @@ func renderAttachment(
 	// Render actions after the attachment title
 	if isSelected {
 		actionsPositionOnEndOfRow := windowWidth - selectedActionsWidth - 1
-		actionsPositionOnEndOfText := leftOffset + 2 + len(title) + 1
+		actionsPositionOnEndOfText := leftOffset + 2 + textwidth.Width(title) + 1
 		var actionPosition int
 
 		if actionsPositionOnEndOfRow > actionsPositionOnEndOfText {
@@ -194,7 +201,7 @@ func renderAttachment(
 			field.Title+":",
 		)
 		term.WriteTextStyle(
-			leftOffset+2+len(field.Title)+2,
+			leftOffset+2+textwidth.Width(field.Title)+2,
 			row+index+1,
 			color.DeSerializeStyleTcell(config["Message.Attachment.FieldValueColor"]),
 			field.Value,
@@ -241,6 +248,102 @@ func getSenderInfo(msg gateway.Message) (string, tcell.Style) {
 	return sender, senderStyle
 }
 
+// Were the two timestamps (in seconds) on the same day?
+func sameDay(a int, b int) bool {
+	aYear, aMonth, aDay := time.Unix(int64(a), 0).Date()
+	bYear, bMonth, bDay := time.Unix(int64(b), 0).Date()
+	return aYear == bYear && aMonth == bMonth && aDay == bDay
+}
+
+// Should `msg` be grouped under `previous`, the message sent before it? Messages are grouped if
+// they were sent by the same person on the same day within `window` seconds of each other.
+func shouldGroupMessages(previous gateway.Message, msg gateway.Message, window int) bool {
+	if window <= 0 || previous.Sender == nil || msg.Sender == nil {
+		return false
+	}
+
+	sameSender := previous.Sender.Name == msg.Sender.Name
+	if len(previous.Sender.Id) > 0 && len(msg.Sender.Id) > 0 {
+		sameSender = previous.Sender.Id == msg.Sender.Id
+	}
+
+	return sameSender &&
+		msg.Timestamp-previous.Timestamp <= window &&
+		sameDay(previous.Timestamp, msg.Timestamp)
+}
+
+// Is `msg` the first message that was sent after the last read timestamp?
+func isNewMessage(previous gateway.Message, msg gateway.Message, lastRead string) bool {
+	if len(lastRead) == 0 {
+		return false
+	}
+	lastReadTimestamp, err := strconv.ParseFloat(lastRead, 64)
+	if err != nil {
+		return false
+	}
+	previousTimestamp, _ := strconv.ParseFloat(previous.Hash, 64)
+	msgTimestamp, _ := strconv.ParseFloat(msg.Hash, 64)
+
+	return previousTimestamp <= lastReadTimestamp && msgTimestamp > lastReadTimestamp
+}
+
+// Draw a line across the screen with a label in the center.
+func renderSeparator(term *TerminalDisplay, style tcell.Style, label string, row int, width int) {
+	label = " " + label + " "
+	left := (width - textwidth.Width(label)) / 2
+	if left < 0 {
+		left = 0
+	}
+	right := width - left - textwidth.Width(label)
+	if right < 0 {
+		right = 0
+	}
+
+	term.WriteTextStyle(0, row, style, strings.Repeat("-", left)+label+strings.Repeat("-", right))
+}
+
+// Draw the timestamp, sender's online status, and sender at the start of a message. Returns the
+// offset of the end of the sender.
+func drawMessageHeader(
+	term *TerminalDisplay,
+	config map[string]string,
+
+	msg gateway.Message,
+	sender string,
+	senderStyle tcell.Style,
+	timestamp string,
+	selectedStyle tcell.Style,
+	userOnline func(user *gateway.User) bool,
+	messageOffset int,
+	row int,
+) int {
+	term.WriteTextStyle(messageOffset, row, selectedStyle, timestamp)
+	messageOffset += textwidth.Width(timestamp) + 1
+
+	if msg.Sender != nil && userOnline(msg.Sender) {
+		// Render online status for sender
+		term.WriteTextStyle(
+			messageOffset,
+			row,
+			color.DeSerializeStyleTcell(config["Message.Sender.OnlinePrefixColor"]),
+			config["Message.Sender.OnlinePrefix"],
+		)
+		messageOffset += textwidth.Width(config["Message.Sender.OnlinePrefix"])
+	} else if msg.Sender != nil {
+		// Render offline status for sender
+		term.WriteTextStyle(
+			messageOffset,
+			row,
+			color.DeSerializeStyleTcell(config["Message.Sender.OfflinePrefixColor"]),
+			config["Message.Sender.OfflinePrefix"],
+		)
+		messageOffset += textwidth.Width(config["Message.Sender.OfflinePrefix"])
+	}
+
+	term.WriteTextStyle(messageOffset, row, senderStyle, sender)
+	return messageOffset + textwidth.Width(sender)
+}
+
 func getRelativeLineNumber(activeLine int, currentLine int) int {
 	value := currentLine - activeLine
 	if value < 0 {
@@ -255,8 +358,10 @@ func (term *TerminalDisplay) DrawMessages(
 	messages []gateway.Message, // A list of messages to render
 	selectedMessageIndex int, // Index of selected message (-1 for no selected message)
 	bottomDisplayedItem int, // The bottommost message. If 0, bottommost message is most recent.
+	lastRead string, // Timestamp of the last message read. Newer messages are below a divider.
 	userById func(string) (*gateway.User, error),
 	userOnline func(user *gateway.User) bool,
+	imagePreview func(file *gateway.File) image.Image, // Returns nil if there's no preview (yet)
 	config map[string]string,
 ) (renderedMessageNumber int, renderedAllMessages bool) { // Return how many messages were rendered.
 	width, height := term.screen.Size()
@@ -271,6 +376,21 @@ func (term *TerminalDisplay) DrawMessages(
 		}
 	}
 
+	// How close together messages from the same sender need to be to be grouped together.
+	groupWindow, err := strconv.Atoi(config["Message.GroupWindow"])
+	if err != nil {
+		groupWindow = 0
+	}
+
+	// Image previews are never taller than this many rows, or the window.
+	maxPreviewRows, err := strconv.Atoi(config["Message.ImagePreview.MaxHeight"])
+	if err != nil {
+		maxPreviewRows = 0
+	}
+	if maxPreviewRows > height-BottomPadding-1 {
+		maxPreviewRows = height - BottomPadding - 1
+	}
+
 	// The relative line gutter width should be the same length as the height. If we've got
 	// over one hundred lines then we're going to have three digit relative line numbers.
 	relativeLineWidth := len(fmt.Sprintf("%d", height / 2)) + 1
@@ -287,17 +407,27 @@ func (term *TerminalDisplay) DrawMessages(
 
 		// Calculate the width of the message prefix.
 		timestamp := time.Unix(int64(msg.Timestamp), 0).Format(config["Message.TimestampFormat"])
-		prefixWidth := 0
+		// The header is the timestamp, online status, and sender.
+		headerWidth := textwidth.Width(timestamp) + 1
+		if msg.Sender != nil && userOnline(msg.Sender) {
+			headerWidth += textwidth.Width(config["Message.Sender.OnlinePrefix"])
+		} else if msg.Sender != nil {
+			headerWidth += textwidth.Width(config["Message.Sender.OfflinePrefix"])
+		}
+		headerWidth += textwidth.Width(sender)
+
+		prefixWidth := headerWidth + 1
 		if _, ok := config["Message.RelativeLine"]; ok {
 			prefixWidth += relativeLineWidth
 		}
-		prefixWidth += len(timestamp) + 1
-		if msg.Sender != nil && userOnline(msg.Sender) {
-			prefixWidth += len(config["Message.Sender.OnlinePrefix"])
-		} else if msg.Sender != nil {
-			prefixWidth += len(config["Message.Sender.OfflinePrefix"])
+
+		// Should the message be grouped with the message before it? If so, the timestamp and
+		// sender don't need to be shown again.
+		var grouped bool
+		if index > 0 && index != selectedMessageIndex {
+			grouped = shouldGroupMessages(messages[index-1], msg, groupWindow) &&
+				!isNewMessage(messages[index-1], msg, lastRead)
 		}
-		prefixWidth += len(sender) + 1
 
 		// Is the message selected?
 		var selectedStyle tcell.Style
@@ -315,13 +445,15 @@ func (term *TerminalDisplay) DrawMessages(
 		// Take our message text and convert it to message parts
 		if msg.Tokens == nil {
 			var parsedMessage gateway.PrintableMessage
-			err := ParseSlackMessage(msg.Text, &parsedMessage, userById)
+			err := ParseMessage(msg, &parsedMessage, userById)
 			if err != nil {
 				// FIXME: Probably should return an error here? And not return 0?
 				log.Println("Error making message print-worthy:", err)
 				return 0, false
 			}
 
+			highlightCodeBlocks(&parsedMessage, config["Message.Part.CodeBlockTheme"])
+
 			// Fetch message lines. Cache a pointer to the lines to use for further renders.
 			parsedMessageLines := parsedMessage.Lines(messageColumnWidth)
 			msg.Tokens = &parsedMessageLines
@@ -329,7 +461,7 @@ func (term *TerminalDisplay) DrawMessages(
 
 		messageRows := len(*msg.Tokens)
 		accessoryRow := row // The row to start rendering "message accessories" on
-		if len(msg.Text) == 0 {
+		if len(msg.Text) == 0 && len(msg.Blocks) == 0 {
 			accessoryRow -= 1
 		}
 		if len(msg.Reactions) > 0 { // Reactions need one row
@@ -340,6 +472,17 @@ func (term *TerminalDisplay) DrawMessages(
 			messageRows += 1
 			accessoryRow -= 1
 		}
+
+		// Images can be previewed underneath the file row, offset to line up with the file name.
+		var preview image.Image
+		var previewColumns, previewRows int
+		if msg.File != nil && imagePreview != nil {
+			if preview = imagePreview(msg.File); preview != nil {
+				previewColumns, previewRows = imagePreviewSize(preview.Bounds(), messageColumnWidth-2, maxPreviewRows)
+				messageRows += previewRows
+				accessoryRow -= previewRows
+			}
+		}
 		if msg.Attachments != nil { // Attachments need a lot of rows. :(
 			// Collect the total attachment height
 			var attachmentSize int
@@ -351,6 +494,15 @@ func (term *TerminalDisplay) DrawMessages(
 			accessoryRow -= attachmentSize
 		}
 
+		// Clicking anywhere in a message selects it.
+		term.AddClickTarget(ClickTarget{
+			Type:   CLICK_TARGET_MESSAGE,
+			Index:  index,
+			Y:      row - messageRows + 1,
+			Width:  width,
+			Height: messageRows,
+		})
+
 		// The number of characters from the left that messages should be offset by.
 		messageOffset := 0
 
@@ -390,32 +542,14 @@ func (term *TerminalDisplay) DrawMessages(
 		}
 
 		// Draw the sender, the sender's online status, and the timestamp on the first row of a message
-		term.WriteTextStyle(messageOffset, row-messageRows+1, selectedStyle, timestamp)
-		messageOffset += len(timestamp) + 1
-
-		if msg.Sender != nil && userOnline(msg.Sender) {
-			// Render online status for sender
-			term.WriteTextStyle(
-				messageOffset,
-				row-messageRows+1,
-				color.DeSerializeStyleTcell(config["Message.Sender.OnlinePrefixColor"]),
-				config["Message.Sender.OnlinePrefix"],
-			)
-			messageOffset += len(config["Message.Sender.OnlinePrefix"])
-		} else if msg.Sender != nil {
-			// Render offline status for sender
-			term.WriteTextStyle(
-				messageOffset,
-				row-messageRows+1,
-				color.DeSerializeStyleTcell(config["Message.Sender.OfflinePrefixColor"]),
-				config["Message.Sender.OfflinePrefix"],
-			)
-			messageOffset += len(config["Message.Sender.OfflinePrefix"])
+		// (unless the message is grouped with the one before it, in which case the space is left
+		// blank so that the message text lines up.)
+		if grouped {
+			messageOffset += headerWidth
+		} else {
+			messageOffset = drawMessageHeader(term, config, msg, sender, senderStyle, timestamp, selectedStyle, userOnline, messageOffset, row-messageRows+1)
 		}
 
-		term.WriteTextStyle(messageOffset, row-messageRows+1, senderStyle, sender)
-		messageOffset += len(sender)
-
 		// Render optional reactions, file, or attachment after message
 		if msg.File != nil {
 			accessoryRow += 1
@@ -428,6 +562,18 @@ func (term *TerminalDisplay) DrawMessages(
 			)
 		}
 
+		if previewRows > 0 {
+			term.drawImage(
+				config["Message.ImagePreview.Backend"],
+				preview,
+				prefixWidth+2,  // Line up with the file name, after the `| `
+				accessoryRow+1, // The row after the file
+				previewColumns,
+				previewRows,
+			)
+			accessoryRow += previewRows
+		}
+
 		if msg.Attachments != nil {
 			for attachmentIndex, attachment := range *msg.Attachments {
 				accessoryRow += 1
@@ -440,6 +586,15 @@ func (term *TerminalDisplay) DrawMessages(
 					width,                         // Width of the window
 					attachmentIndex,               // The index of the given attachent in the selected message
 				)
+				term.AddClickTarget(ClickTarget{
+					Type:   CLICK_TARGET_ATTACHMENT,
+					Index:  index,
+					Part:   attachmentIndex + 1,
+					X:      prefixWidth,
+					Y:      accessoryRow,
+					Width:  width - prefixWidth,
+					Height: getAttachmentHeight(attachment),
+				})
 				accessoryRow += getAttachmentHeight(attachment) - 1
 			}
 		}
@@ -456,6 +611,8 @@ func (term *TerminalDisplay) DrawMessages(
 
 		// Render the sender and the message
 		// NOTE: The msg.Tokens dereference is guarded above, so should never be nil
+		linkIndex := 0
+		var previousPart gateway.PrintableMessagePart
 		for lineIndex, line := range *msg.Tokens {
 			totalWidth := 0
 			for _, part := range line {
@@ -473,6 +630,30 @@ func (term *TerminalDisplay) DrawMessages(
 					style = color.DeSerializeStyleTcell(config["Message.Part.ChannelColor"])
 				} else if part.Type == gateway.PRINTABLE_MESSAGE_LINK {
 					style = color.DeSerializeStyleTcell(config["Message.Part.LinkColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD {
+					style = color.DeSerializeStyleTcell(config["Message.Part.BoldColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_FORMATTING_ITALIC {
+					style = color.DeSerializeStyleTcell(config["Message.Part.ItalicColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_FORMATTING_STRIKETHROUGH {
+					style = color.DeSerializeStyleTcell(config["Message.Part.StrikethroughColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_FORMATTING_CODE || part.Type == gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED {
+					style = color.DeSerializeStyleTcell(config["Message.Part.CodeColor"])
+				} else if codeStyle, ok := part.Metadata["Style"].(string); ok && part.Type == gateway.PRINTABLE_MESSAGE_CODE_BLOCK {
+					style = color.DeSerializeStyleTcell(codeStyle)
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_CODE_BLOCK {
+					style = color.DeSerializeStyleTcell(config["Message.Part.CodeBlockColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_CODE_BLOCK_BORDER {
+					style = color.DeSerializeStyleTcell(config["Message.Part.CodeBlockBorderColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_BLOCKQUOTE {
+					style = color.DeSerializeStyleTcell(config["Message.Part.BlockquoteColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_LIST_ITEM {
+					style = color.DeSerializeStyleTcell(config["Message.Part.ListItemColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_BLOCK_CONTEXT {
+					style = color.DeSerializeStyleTcell(config["Message.Part.ContextColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_BLOCK_DIVIDER {
+					style = color.DeSerializeStyleTcell(config["Message.Part.DividerColor"])
+				} else if part.Type == gateway.PRINTABLE_MESSAGE_BLOCK_ELEMENT {
+					style = color.DeSerializeStyleTcell(config["Message.Part.ElementColor"])
 				}
 
 				// Render the next message part
@@ -483,7 +664,25 @@ func (term *TerminalDisplay) DrawMessages(
 					part.Content,
 				)
 
-				totalWidth += len(part.Content)
+				// Links can be clicked on. A link that wraps onto the next line is split in two, but
+				// both halves are the same link.
+				if part.Type == gateway.PRINTABLE_MESSAGE_LINK {
+					if previousPart.Type != gateway.PRINTABLE_MESSAGE_LINK || previousPart.Metadata["Href"] != part.Metadata["Href"] {
+						linkIndex += 1
+					}
+					term.AddClickTarget(ClickTarget{
+						Type:   CLICK_TARGET_LINK,
+						Index:  index,
+						Part:   linkIndex,
+						X:      messageOffset + 1 + totalWidth,
+						Y:      row - messageRows + lineIndex + 1,
+						Width:  textwidth.Width(part.Content),
+						Height: 1,
+					})
+				}
+				previousPart = part
+
+				totalWidth += textwidth.Width(part.Content)
 			}
 
 			// On the last line of the selected message, render actions for that message (attachment
@@ -498,6 +697,11 @@ func (term *TerminalDisplay) DrawMessages(
 					messageActions = append(messageActions, "Send again")
 				}
 
+				// Press a button or pick an option in a menu
+				if len(msg.Actions()) > 0 {
+					messageActions = append(messageActions, "Interact")
+				}
+
 				renderActions(
 					term,
 					config,
@@ -512,6 +716,24 @@ func (term *TerminalDisplay) DrawMessages(
 		// Subtract the message's height.
 		row -= messageRows
 		index -= 1
+
+		// If the message is the first one that hasn't been read, draw a divider above it.
+		if index >= 0 && row >= 0 && isNewMessage(messages[index], msg, lastRead) {
+			renderSeparator(term, color.DeSerializeStyleTcell(config["Message.NewMessagesColor"]), "new messages", row, width)
+			row -= 1
+		}
+
+		// If the message above was sent on a different day, draw a separator between the two.
+		if index >= 0 && row >= 0 && !sameDay(messages[index].Timestamp, msg.Timestamp) {
+			renderSeparator(
+				term,
+				color.DeSerializeStyleTcell(config["Message.DateSeparatorColor"]),
+				time.Unix(int64(msg.Timestamp), 0).Format(dateSeparatorFormat),
+				row,
+				width,
+			)
+			row -= 1
+		}
 	}
 
 	// Return how many messages were rendered to the screen
@@ -0,0 +1,241 @@
+package frontend_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+	"github.com/gdamore/tcell"
+)
+
+func userById(id string) (*gateway.User, error) {
+	return &gateway.User{Id: id, Name: id}, nil
+}
+func userOnline(user *gateway.User) bool {
+	return false
+}
+
+// Noon UTC, so that the day is the same in every timezone that tests are run in.
+func noonOn(month time.Month, day int) int {
+	return int(time.Date(2017, month, day, 12, 0, 0, 0, time.UTC).Unix())
+}
+
+func TestMessagesDateSeparator(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	sender := &gateway.User{Name: "foo"}
+
+	term.DrawMessages([]gateway.Message{
+		{Sender: sender, Text: "Before the holiday", Timestamp: noonOn(time.July, 3), Confirmed: true},
+		{Sender: sender, Text: "Happy fourth!", Timestamp: noonOn(time.July, 4), Confirmed: true},
+		{Sender: sender, Text: "Back at work", Timestamp: noonOn(time.July, 5), Confirmed: true},
+		{Sender: sender, Text: "Still at work", Timestamp: noonOn(time.July, 5) + 60, Confirmed: true},
+	}, -1, 0, "", userById, userOnline, nil, map[string]string{
+		"Message.TimestampFormat": "Jan 2",
+	})
+
+	result, ok := screen.Compare("./tests/draw_messages_test/messages_date_separator.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
+
+func TestMessagesGrouping(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	foo := &gateway.User{Id: "foo-id", Name: "foo"}
+	bar := &gateway.User{Id: "bar-id", Name: "bar"}
+	start := noonOn(time.July, 4)
+
+	term.DrawMessages([]gateway.Message{
+		{Sender: foo, Text: "Hello", Timestamp: start, Confirmed: true},
+		{Sender: foo, Text: "Are you there?", Timestamp: start + 60, Confirmed: true},
+		{Sender: bar, Text: "Yep", Timestamp: start + 90, Confirmed: true},
+		{Sender: bar, Text: "What's up?", Timestamp: start + 100, Confirmed: true},
+		{Sender: bar, Text: "Selected, so not grouped", Timestamp: start + 110, Confirmed: true},
+		{Sender: bar, Text: "Much later", Timestamp: start + 3600, Confirmed: true},
+	}, 4, 0, "", userById, userOnline, nil, map[string]string{
+		"Message.TimestampFormat": "Jan 2",
+		"Message.GroupWindow":     "300",
+	})
+
+	result, ok := screen.Compare("./tests/draw_messages_test/messages_grouping.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
+
+func TestMessagesNewMessagesDivider(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	foo := &gateway.User{Id: "foo-id", Name: "foo"}
+	start := noonOn(time.July, 4)
+
+	term.DrawMessages([]gateway.Message{
+		{Sender: foo, Text: "Already read", Timestamp: start, Hash: fmt.Sprintf("%d.000000", start), Confirmed: true},
+		{Sender: foo, Text: "Also read", Timestamp: start + 10, Hash: fmt.Sprintf("%d.000000", start+10), Confirmed: true},
+		{Sender: foo, Text: "Not read yet", Timestamp: start + 20, Hash: fmt.Sprintf("%d.000000", start+20), Confirmed: true},
+	}, -1, 0, fmt.Sprintf("%d.000000", start+10), userById, userOnline, nil, map[string]string{
+		"Message.TimestampFormat": "Jan 2",
+		"Message.GroupWindow":     "300",
+	})
+
+	result, ok := screen.Compare("./tests/draw_messages_test/messages_new_messages_divider.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
+
+func TestMessagesImagePreview(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	screen.Init()
+	screen.SetSize(80, 24)
+	term := frontend.NewTerminalDisplay(screen)
+
+	// A red pixel on top of a blue pixel.
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 1, 2))
+	img.Set(0, 0, red)
+	img.Set(0, 1, blue)
+
+	file := &gateway.File{Id: "file-id", Name: "screenshot.png", Permalink: "https://example.com"}
+	term.DrawMessages([]gateway.Message{
+		{Sender: &gateway.User{Name: "foo"}, Text: "Look", File: file, Timestamp: noonOn(time.July, 4), Confirmed: true},
+	}, -1, 0, "", userById, userOnline, func(previewed *gateway.File) image.Image {
+		if previewed != file {
+			t.Errorf("Preview requested for the wrong file: %+v", previewed)
+		}
+		return img
+	}, map[string]string{
+		"Message.TimestampFormat":        "Jan 2",
+		"Message.ImagePreview.MaxHeight": "12",
+	})
+
+	// The preview is on the last row of the message, underneath the file.
+	width, height := screen.Size()
+	row := height - frontend.BottomPadding - 1
+	var found bool
+	for column := 0; column < width; column++ {
+		char, _, style, _ := screen.GetContent(column, row)
+		if char != '▀' {
+			continue
+		}
+		found = true
+
+		foreground, background, _ := style.Decompose()
+		if foreground != tcell.NewRGBColor(255, 0, 0) || background != tcell.NewRGBColor(0, 0, 255) {
+			t.Errorf("Preview wasn't drawn with the image's colors: %v on %v", foreground, background)
+		}
+	}
+	if !found {
+		t.Errorf("Preview wasn't drawn under the file")
+	}
+
+	// The file is still shown above the preview, lined up with it.
+	for column := 0; column < width; column++ {
+		char, _, _, _ := screen.GetContent(column, row-1)
+		if char == '|' {
+			if previewChar, _, _, _ := screen.GetContent(column+2, row); previewChar != '▀' {
+				t.Errorf("Preview wasn't lined up with the file name")
+			}
+			return
+		}
+	}
+	t.Errorf("File wasn't drawn above the preview")
+}
+
+// Wide characters take up two cells and accents take up none, so text after them (and wrapped
+// text) should still line up.
+func TestMessagesWideCharacters(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	jose := &gateway.User{Id: "jose-id", Name: "José"}
+	yuki := &gateway.User{Id: "yuki-id", Name: "雪"}
+	start := noonOn(time.July, 4)
+
+	term.DrawMessages([]gateway.Message{
+		{Sender: jose, Text: "Café at noon? 😀 :thumbsup:", Timestamp: start, Confirmed: true},
+		{Sender: yuki, Text: "日本語のテキストは二つのセルを使います。日本語のテキストは二つのセルを使います。折り返しても大丈夫", Timestamp: start + 3600, Confirmed: true},
+		{Sender: jose, Text: "Still aligned", Timestamp: start + 7200, Confirmed: true, Reactions: []gateway.Reaction{
+			{Name: "thumbsup", Users: []*gateway.User{yuki}},
+			{Name: "smile", Users: []*gateway.User{yuki}},
+		}},
+	}, -1, 0, "", userById, userOnline, nil, map[string]string{
+		"Message.TimestampFormat": "Jan 2",
+	})
+
+	result, ok := screen.Compare("./tests/draw_messages_test/messages_wide_characters.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
+
+func TestMessagesMarkdown(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	foo := &gateway.User{Id: "foo-id", Name: "foo"}
+	start := noonOn(time.July, 4)
+
+	term.DrawMessages([]gateway.Message{
+		{Sender: foo, Text: "*Release notes* for <https://example.com|the new version>:", Timestamp: start, Confirmed: true},
+		{Sender: foo, Text: "- ~Slow~ fast startup\n- A list item that is long enough that it has to wrap onto the next line of the screen\n1. `one`", Timestamp: start + 10, Confirmed: true},
+		{Sender: foo, Text: "&gt; A quote that is also long enough that it has to wrap onto the next line of the screen", Timestamp: start + 20, Confirmed: true},
+		{Sender: foo, Text: "Try this:\n```\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\nThen run it.", Timestamp: start + 30, Confirmed: true},
+	}, -1, 0, "", userById, userOnline, nil, map[string]string{
+		"Message.TimestampFormat": "Jan 2",
+		"Message.GroupWindow":     "300",
+	})
+
+	result, ok := screen.Compare("./tests/draw_messages_test/messages_markdown.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
+
+func TestMessagesBlocks(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	bot := &gateway.User{Name: "deploy-bot"}
+	start := noonOn(time.July, 4)
+
+	// Attachments are drawn underneath a message's blocks.
+	term.DrawMessages([]gateway.Message{
+		{
+			Sender:    bot,
+			Text:      "Deploy finished",
+			Timestamp: start,
+			Confirmed: true,
+			Blocks: []gateway.Block{
+				{Type: "header", Text: &gateway.BlockElement{Type: "plain_text", Text: "Deploy finished"}},
+				{Type: "section", Text: &gateway.BlockElement{Type: "mrkdwn", Text: "*api* is live"}},
+				{Type: "context", Elements: []gateway.BlockElement{{Type: "mrkdwn", Text: "Deployed by foo"}}},
+				{Type: "divider"},
+				{Type: "actions", Elements: []gateway.BlockElement{
+					{Type: "button", Text: "Roll back"},
+					{Type: "static_select", Placeholder: "Environment"},
+				}},
+			},
+			Attachments: &[]gateway.Attachment{{Title: "Release notes", Color: "00ff00"}},
+		},
+		{
+			Sender:      bot,
+			Text:        "Build failed",
+			Timestamp:   start + 600,
+			Confirmed:   true,
+			Attachments: &[]gateway.Attachment{{Title: "Build #12", Color: "ff0000"}},
+		},
+	}, -1, 0, "", userById, userOnline, nil, map[string]string{
+		"Message.TimestampFormat": "Jan 2",
+		"Message.GroupWindow":     "300",
+	})
+
+	result, ok := screen.Compare("./tests/draw_messages_test/messages_blocks.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
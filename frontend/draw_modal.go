@@ -7,6 +7,9 @@ import (
 	"strings"
 
 	"github.com/gdamore/tcell"
+
+	"github.com/1egoman/slick/color"
+	"github.com/1egoman/slick/textwidth"
 )
 
 const idealModalWidth = 120
@@ -50,8 +53,6 @@ func calculateScrollBarProperties(currentLine int, totalLines int, modalHeight i
 }
 
 func (term *TerminalDisplay) DrawModal(title string, body string, scrollPosition int, editable bool) {
-	width, height := term.screen.Size()
-
 	// Given the scroll position and the body, trim away `scrollPosition` lines at the start of the
 	// body.
 	bodyLines := strings.Split(body, "\n")
@@ -59,6 +60,89 @@ func (term *TerminalDisplay) DrawModal(title string, body string, scrollPosition
 		body = strings.Join(bodyLines[scrollPosition:], "\n")
 	}
 
+	modalUpperLeftX, modalUpperLeftY, modalWidth, modalHeight := term.drawModalFrame(title, scrollPosition, len(bodyLines))
+
+	// ----------------------------------------------------------------------------
+	// 	Render content
+	// ------------------------------------------------------------------------------
+
+	lastRenderedX, lastRenderedY := term.WriteParagraphStyle(
+		modalUpperLeftX+horizontalGutter,
+		modalUpperLeftY+verticalGutter,
+		modalWidth - horizontalGutter - horizontalGutter,
+		modalHeight - verticalGutter - verticalGutter,
+		tcell.StyleDefault,
+		body,
+	)
+
+	if editable {
+		term.screen.ShowCursor(lastRenderedX, lastRenderedY)
+	}
+}
+
+// Draw code in a modal, highlighted with a theme. If the code can't be highlighted (ie, its language
+// isn't known), it's drawn like any other modal instead.
+func (term *TerminalDisplay) DrawCodeModal(title string, code string, language string, theme string, scrollPosition int) {
+	code = strings.Replace(code, "\t", "    ", -1)
+	spans := HighlightCode(code, language, theme)
+	if spans == nil {
+		term.DrawModal(title, code, scrollPosition, false)
+		return
+	}
+
+	modalUpperLeftX, modalUpperLeftY, modalWidth, modalHeight := term.drawModalFrame(
+		title,
+		scrollPosition,
+		len(strings.Split(code, "\n")),
+	)
+	left := modalUpperLeftX + horizontalGutter
+	top := modalUpperLeftY + verticalGutter
+	width := modalWidth - horizontalGutter - horizontalGutter
+	height := modalHeight - verticalGutter - verticalGutter
+
+	// Draw each span in its style, skipping the first `scrollPosition` lines. Code isn't reflowed
+	// like text is, long lines are cut wherever they reach the side of the modal.
+	x, y, line, offset := 0, 0, 0, 0
+	for _, span := range spans {
+		end := offset + span.Length
+		if end > len(code) {
+			end = len(code)
+		}
+		text := code[offset:end]
+		offset = end
+
+		style := color.DeSerializeStyleTcell(span.Style)
+		for _, cluster := range textwidth.Clusters(text) {
+			if cluster.Base == '\n' {
+				line += 1
+				if line > scrollPosition {
+					x, y = 0, y+1
+				}
+				continue
+			} else if line < scrollPosition || cluster.Width == 0 {
+				continue
+			}
+
+			if x+cluster.Width > width {
+				x, y = 0, y+1
+			}
+			if y >= height {
+				return
+			}
+			term.screen.SetContent(left+x, top+y, cluster.Base, cluster.Combining, style)
+			x += cluster.Width
+		}
+	}
+}
+
+// Draw the border, title, and scroll bar of a modal, and clear its inside. Returns the position and
+// size of the modal.
+func (term *TerminalDisplay) drawModalFrame(title string, scrollPosition int, totalLines int) (int, int, int, int) {
+	width, height := term.screen.Size()
+
+	// Images drawn with escape sequences would be drawn on top of the modal.
+	term.images = nil
+
 	// Calculate the modal width and height
 	var modalWidth int
 	if width > idealModalWidth {
@@ -85,8 +169,8 @@ func (term *TerminalDisplay) DrawModal(title string, body string, scrollPosition
 	modalHint := fmt.Sprintf(
 		" %d/%d (%d%%) %s +",
 		scrollPosition, // Current line
-		len(bodyLines), // Total lines
-		int(float32(scrollPosition) / float32(len(bodyLines)) * 100), // Percent
+		totalLines, // Total lines
+		int(float32(scrollPosition) / float32(totalLines) * 100), // Percent
 		closeModalMessage, // Hint on how to close the modal
 	)
 
@@ -116,7 +200,7 @@ func (term *TerminalDisplay) DrawModal(title string, body string, scrollPosition
 	)
 
 	// Sides
-	scrollBarCharacter := calculateScrollBarProperties(scrollPosition, len(bodyLines), modalHeight)
+	scrollBarCharacter := calculateScrollBarProperties(scrollPosition, totalLines, modalHeight)
 	for i := 1; i < modalHeight; i++ {
 		// Left side
 		term.screen.SetCell(modalUpperLeftX, modalUpperLeftY+i, tcell.StyleDefault, '|')
@@ -151,20 +235,5 @@ func (term *TerminalDisplay) DrawModal(title string, body string, scrollPosition
 		}
 	}
 
-	// ----------------------------------------------------------------------------
-	// 	Render content
-	// ------------------------------------------------------------------------------
-
-	lastRenderedX, lastRenderedY := term.WriteParagraphStyle(
-		modalUpperLeftX+horizontalGutter,
-		modalUpperLeftY+verticalGutter,
-		modalWidth - horizontalGutter - horizontalGutter,
-		modalHeight - verticalGutter - verticalGutter,
-		tcell.StyleDefault,
-		body,
-	)
-
-	if editable {
-		term.screen.ShowCursor(lastRenderedX, lastRenderedY)
-	}
+	return modalUpperLeftX, modalUpperLeftY, modalWidth, modalHeight
 }
@@ -41,3 +41,21 @@ func TestRenderModalWithContentScroll(t *testing.T) {
 		t.Errorf("Error:\n%s", result)
 	}
 }
+
+func TestRenderCodeModal(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+
+	term.DrawCodeModal(
+		"main.go",
+		"package main\n\nfunc main() {\n\tfmt.Println(\"a line of code that is long enough to be wrapped onto the next line of the modal\")\n}",
+		"Go",
+		"monokai",
+		1,
+	)
+
+	result, ok := screen.Compare("./tests/draw_modal_test/modal_code.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
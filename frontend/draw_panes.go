@@ -0,0 +1,30 @@
+package frontend
+
+import (
+	"github.com/1egoman/slick/color"
+	"github.com/1egoman/slick/textwidth"
+)
+
+// Draw the title of a pane across its top row, so it's clear which channel each pane shows.
+func (term *TerminalDisplay) DrawPaneTitle(title string, focused bool, config map[string]string) {
+	width, _ := term.screen.Size()
+
+	style := color.DeSerializeStyleTcell(config["Pane.TitleColor"])
+	if focused {
+		style = color.DeSerializeStyleTcell(config["Pane.ActiveTitleColor"])
+	}
+
+	for column := 0; column < width; column++ {
+		term.screen.SetCell(column, 0, style, ' ')
+	}
+	term.WriteTextStyle(0, 0, style, textwidth.Truncate(" "+title, width))
+}
+
+// Draw the border between two panes beside each other, in the leftmost column.
+func (term *TerminalDisplay) DrawPaneBorder(config map[string]string) {
+	_, height := term.screen.Size()
+	style := color.DeSerializeStyleTcell(config["Pane.BorderColor"])
+	for row := 0; row < height; row++ {
+		term.screen.SetCell(0, row, style, ' ')
+	}
+}
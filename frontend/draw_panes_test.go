@@ -0,0 +1,21 @@
+package frontend_test
+
+import (
+	"testing"
+
+	"github.com/1egoman/slick/frontend"
+)
+
+func TestPaneTitles(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+
+	term.Region(0, 0, 39, 1).DrawPaneTitle("#general (my-team)", true, map[string]string{})
+	term.Region(39, 0, 1, 10).DrawPaneBorder(map[string]string{})
+	term.Region(40, 0, 20, 1).DrawPaneTitle("#a-channel-with-a-long-name (my-team)", false, map[string]string{})
+
+	result, ok := screen.Compare("./tests/draw_panes_test/pane_titles.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
@@ -2,6 +2,7 @@ package frontend
 
 import (
 	"github.com/1egoman/slick/color"
+	"github.com/1egoman/slick/textwidth"
 	"github.com/gdamore/tcell"
 	"strings"
 	// "fmt"
@@ -14,15 +15,18 @@ func (term *TerminalDisplay) DrawSelectionInput(
 	preItems []string,
 	selectedIndex int,
 	bottomDisplayedItem int,
-	rank func(string) int,
+	match func(string) (int, []int), // Score an item, and return the index of each matched rune
 	config map[string]string,
 ) {
 	width, height := term.screen.Size()
 
+	// Images drawn with escape sequences would be drawn on top of the picker.
+	term.images = nil
+
 	// Filter items to remove those that have a negitive rank.
 	var items []string
 	for _, item := range preItems {
-		if rank(item) >= 0 {
+		if score, _ := match(item); score >= 0 {
 			items = append(items, item)
 		}
 	}
@@ -55,6 +59,14 @@ func (term *TerminalDisplay) DrawSelectionInput(
 			}
 		}
 
+		term.AddClickTarget(ClickTarget{
+			Type:   CLICK_TARGET_SELECTION_ITEM,
+			Index:  ct + bottomDisplayedItem,
+			Y:      row,
+			Width:  width,
+			Height: 1,
+		})
+
 		// Add selected prefix for selected item
 		style := tcell.StyleDefault
 		if ct == projectedSelectedIndex {
@@ -64,12 +76,44 @@ func (term *TerminalDisplay) DrawSelectionInput(
 
 		// Draw item
 		// If a tab is present, then the part after the tab should on on the right
+		_, positions := match(item)
 		tabIndex := strings.Index(item, "\t")
 		if tabIndex >= 0 {
-			term.WriteTextStyle(width-(len(item)-tabIndex)-1, row, style, item[tabIndex:]) // right bit
-			term.WriteTextStyle(2, row, style, item[:tabIndex])                            // left bit
+			rightBit := item[tabIndex+1:]
+			term.WriteTextStyle(width-textwidth.Width(rightBit)-1, row, style, rightBit) // right bit
+			term.writeMatchedText(2, row, style, item[:tabIndex], positions, config)     // left bit
 		} else {
-			term.WriteTextStyle(2, row, style, item)
+			term.writeMatchedText(2, row, style, item, positions, config)
+		}
+	}
+}
+
+// Write an item in the picker, highlighting the characters that matched what the user typed.
+func (term *TerminalDisplay) writeMatchedText(
+	x int,
+	y int,
+	style tcell.Style,
+	text string,
+	positions []int,
+	config map[string]string,
+) {
+	matchStyle := color.DeSerializeStyleTcell(config["FuzzyPicker.MatchColor"])
+	matched := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		matched[position] = true
+	}
+
+	// Positions are counted in runes, and a character is highlighted if any of its runes matched.
+	index := 0
+	for _, cluster := range textwidth.Clusters(text) {
+		clusterStyle := style
+		for i := 0; i <= len(cluster.Combining); i++ {
+			if matched[index+i] {
+				clusterStyle = matchStyle
+			}
 		}
+		term.screen.SetContent(x, y, cluster.Base, cluster.Combining, clusterStyle)
+		x += cluster.Width
+		index += len(cluster.Combining) + 1
 	}
 }
@@ -3,6 +3,7 @@ package frontend_test
 import (
 	"testing"
 	"github.com/1egoman/slick/frontend"
+	"github.com/gdamore/tcell"
 )
 
 func TestSelectionInputRenderItems(t *testing.T) {
@@ -29,7 +30,7 @@ func TestSelectionInputRenderItems(t *testing.T) {
 		},
 		0, // Selected index
 		0, // Bottom displayed item, used to control scrolling
-		func(a string) int { return 0 },
+		func(a string) (int, []int) { return 0, nil },
 		map[string]string{},
 	)
 
@@ -63,7 +64,7 @@ func TestSelectionInputRenderItemsSelectedIndex4(t *testing.T) {
 		},
 		4, // Selected index
 		0, // Bottom displayed item, used to control scrolling
-		func(a string) int { return 0 },
+		func(a string) (int, []int) { return 0, nil },
 		map[string]string{},
 	)
 
@@ -97,7 +98,7 @@ func TestSelectionInputRenderItemsSelectedIndex4BottomDisplayedItem2(t *testing.
 		},
 		4, // Selected index
 		2, // Bottom displayed item, used to control scrolling
-		func(a string) int { return 0 },
+		func(a string) (int, []int) { return 0, nil },
 		map[string]string{},
 	)
 
@@ -106,3 +107,27 @@ func TestSelectionInputRenderItemsSelectedIndex4BottomDisplayedItem2(t *testing.
 		t.Errorf("Error:\n%s", result)
 	}
 }
+
+func TestSelectionInputHighlightsMatchedCharacters(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	screen.Init()
+	term := frontend.NewTerminalDisplay(screen)
+	_, height := screen.Size()
+
+	term.DrawSelectionInput(
+		[]string{"general\tmy-team"},
+		0, // Selected index
+		0, // Bottom displayed item, used to control scrolling
+		func(a string) (int, []int) { return 1, []int{0, 2} }, // `g` and `n` matched
+		map[string]string{"FuzzyPicker.MatchColor": "red::"},
+	)
+
+	row := height - 1 - frontend.BottomPadding
+	for index, expected := range []bool{true, false, true, false} {
+		char, _, style, _ := screen.GetContent(2+index, row)
+		foreground, _, _ := style.Decompose()
+		if highlighted := foreground == tcell.ColorRed; highlighted != expected {
+			t.Errorf("Character %c at %d should be highlighted: %v", char, index, expected)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package frontend
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/1egoman/slick/color"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/textwidth"
+)
+
+// The channels listed in the sidebar for a connection, in the order they're shown: channels, then
+// direct messages, then group direct messages, each sorted by name. Channels the user isn't a
+// member of and archived channels aren't listed.
+func SidebarChannels(conn gateway.Connection) []gateway.Channel {
+	var channels []gateway.Channel
+	for _, channel := range conn.Channels() {
+		if channel.IsMember && !channel.IsArchived {
+			channels = append(channels, channel)
+		}
+	}
+
+	sort.SliceStable(channels, func(i, j int) bool {
+		if channels[i].SubType != channels[j].SubType {
+			return channels[i].SubType < channels[j].SubType
+		}
+		return channels[i].Label() < channels[j].Label()
+	})
+	return channels
+}
+
+var sidebarHeadings = map[gateway.ChannelType]string{
+	gateway.TYPE_CHANNEL:              "Channels",
+	gateway.TYPE_DIRECT_MESSAGE:       "Direct messages",
+	gateway.TYPE_GROUP_DIRECT_MESSAGE: "Group messages",
+}
+
+// Draw a list of every connection and its channels down the left side of the screen. `unread`
+// returns the number of unread messages and mentions in a channel.
+func (term *TerminalDisplay) DrawSidebar(
+	connections []gateway.Connection,
+	activeConnection gateway.Connection,
+	unread func(conn gateway.Connection, channel gateway.Channel) (int, int),
+	config map[string]string,
+) {
+	width, height := term.screen.Size()
+	defaultStyle := color.DeSerializeStyleTcell(config["Sidebar.Color"])
+	onlinePrefix := config["Message.Sender.OnlinePrefix"]
+
+	// The rightmost column is a border between the sidebar and the messages.
+	width -= 1
+	for row := 0; row < height; row++ {
+		for column := 0; column < width; column++ {
+			term.screen.SetCell(column, row, defaultStyle, ' ')
+		}
+		term.screen.SetCell(width, row, color.DeSerializeStyleTcell(config["Sidebar.BorderColor"]), ' ')
+	}
+
+	row := 0
+	writeRow := func(indent int, text string, style string) {
+		if available := width - indent; available >= 0 {
+			text = textwidth.Truncate(text, available)
+		}
+		term.WriteTextStyle(indent, row, color.DeSerializeStyleTcell(style), text)
+	}
+
+	for _, conn := range connections {
+		if row >= height {
+			break
+		}
+
+		style := config["Sidebar.ConnectionColor"]
+		if conn == activeConnection {
+			style = config["Sidebar.ActiveConnectionColor"]
+		}
+		writeRow(0, conn.Name(), style)
+		row += 1
+
+		var selectedChannelId string
+		if selected := conn.SelectedChannel(); selected != nil {
+			selectedChannelId = selected.Id
+		}
+
+		heading := gateway.ChannelType(-1)
+		for _, channel := range SidebarChannels(conn) {
+			if row >= height {
+				break
+			}
+
+			// Each type of channel is listed under a heading.
+			if channel.SubType != heading {
+				heading = channel.SubType
+				writeRow(1, sidebarHeadings[heading], config["Sidebar.HeadingColor"])
+				row += 1
+				if row >= height {
+					break
+				}
+			}
+
+			messages, mentions := 0, 0
+			if unread != nil {
+				messages, mentions = unread(conn, channel)
+			}
+
+			style := config["Sidebar.Color"]
+			if conn == activeConnection && channel.Id == selectedChannelId {
+				style = config["Sidebar.ActiveChannelColor"]
+			} else if mentions > 0 {
+				style = config["Sidebar.MentionColor"]
+			} else if messages > 0 {
+				style = config["Sidebar.UnreadColor"]
+			}
+
+			// Online users in direct messages are marked, like the senders of messages.
+			label := channel.Label()
+			if channel.SubType == gateway.TYPE_DIRECT_MESSAGE && len(channel.DirectMessageUser) > 0 {
+				if conn.UserOnline(&gateway.User{Id: channel.DirectMessageUser}) {
+					term.WriteTextStyle(2, row, color.DeSerializeStyleTcell(config["Message.Sender.OnlinePrefixColor"]), onlinePrefix)
+				}
+				label = channel.DisplayName
+			}
+			if mentions > 0 {
+				label = fmt.Sprintf("%s (%d)", label, mentions)
+			}
+
+			writeRow(2+textwidth.Width(onlinePrefix), label, style)
+			row += 1
+		}
+	}
+}
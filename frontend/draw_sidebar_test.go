@@ -0,0 +1,76 @@
+package frontend_test
+
+import (
+	"testing"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+)
+
+func TestSidebarChannelsGroupedByType(t *testing.T) {
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetChannels([]gateway.Channel{
+		gateway.Channel{Id: "C1", Name: "random", SubType: gateway.TYPE_CHANNEL, IsMember: true},
+		gateway.Channel{Id: "D1", Name: "im-me-alice", DisplayName: "alice", DirectMessageUser: "U1", SubType: gateway.TYPE_DIRECT_MESSAGE, IsMember: true},
+		gateway.Channel{Id: "C2", Name: "general", SubType: gateway.TYPE_CHANNEL, IsMember: true},
+		gateway.Channel{Id: "C3", Name: "old", SubType: gateway.TYPE_CHANNEL, IsMember: true, IsArchived: true},
+		gateway.Channel{Id: "C4", Name: "other", SubType: gateway.TYPE_CHANNEL, IsMember: false},
+		gateway.Channel{Id: "G1", Name: "mpdm-me--alice--bob-1", DisplayName: "alice, bob", SubType: gateway.TYPE_GROUP_DIRECT_MESSAGE, IsMember: true},
+	})
+
+	var names []string
+	for _, channel := range frontend.SidebarChannels(conn) {
+		names = append(names, channel.Name)
+	}
+
+	expected := []string{"general", "random", "im-me-alice", "mpdm-me--alice--bob-1"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for index, name := range expected {
+		if names[index] != name {
+			t.Errorf("Expected %v, got %v", expected, names)
+		}
+	}
+}
+
+func TestSidebarRender(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	channels := []gateway.Channel{
+		gateway.Channel{Id: "C1", Name: "general", SubType: gateway.TYPE_CHANNEL, IsMember: true},
+		gateway.Channel{Id: "C2", Name: "random", SubType: gateway.TYPE_CHANNEL, IsMember: true},
+		gateway.Channel{Id: "C3", Name: "a-channel-with-a-very-long-name", SubType: gateway.TYPE_CHANNEL, IsMember: true},
+		gateway.Channel{Id: "D1", Name: "im-me-alice", DisplayName: "alice", DirectMessageUser: "U1", SubType: gateway.TYPE_DIRECT_MESSAGE, IsMember: true},
+		gateway.Channel{Id: "D2", Name: "im-me-bob", DisplayName: "bob", DirectMessageUser: "U2", SubType: gateway.TYPE_DIRECT_MESSAGE, IsMember: true},
+	}
+	conn.SetChannels(channels)
+	conn.SetSelectedChannel(&channels[0])
+	conn.SetUserOnline(&gateway.User{Id: "U1"}, true)
+
+	term.Region(0, 0, 24, 12).DrawSidebar(
+		[]gateway.Connection{conn},
+		conn,
+		func(conn gateway.Connection, channel gateway.Channel) (int, int) {
+			switch channel.Id {
+			case "C2":
+				return 3, 0
+			case "D2":
+				return 2, 2
+			default:
+				return 0, 0
+			}
+		},
+		map[string]string{
+			"Message.Sender.OnlinePrefix": "*",
+		},
+	)
+
+	result, ok := screen.Compare("./tests/draw_sidebar_test/sidebar_render.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	// "log"
 	"fmt"
 	"github.com/gdamore/tcell"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/1egoman/slick/color"
 	"github.com/1egoman/slick/gateway" // The thing to interface with slack
 	"github.com/1egoman/slick/status"
+	"github.com/1egoman/slick/textwidth"
 )
 
 func (term *TerminalDisplay) DrawStatusBar(
@@ -78,10 +81,15 @@ func (term *TerminalDisplay) DrawStatusBar(
 	} else {
 		// Otherwise, render each connection
 		for index, item := range connections {
+			// Is slack rate limiting the connection?
+			throttledFor := item.ThrottledUntil().Sub(time.Now())
+
 			// How should the connection look?
 			var style tcell.Style
 			if item == activeConnection {
 				style = color.DeSerializeStyleTcell(config["StatusBar.ActiveConnectionColor"])
+			} else if throttledFor > 0 {
+				style = color.DeSerializeStyleTcell(config["StatusBar.GatewayThrottledColor"])
 			} else if item.Status() == gateway.CONNECTING {
 				style = color.DeSerializeStyleTcell(config["StatusBar.GatewayConnectingColor"])
 			} else if item.Status() == gateway.FAILED {
@@ -95,8 +103,12 @@ func (term *TerminalDisplay) DrawStatusBar(
 
 			// Draw each connection
 			label := fmt.Sprintf("%d: %s", index+1, item.Name())
+			if throttledFor > 0 {
+				label += fmt.Sprintf(" (rate limited %ds)", int(math.Ceil(throttledFor.Seconds())))
+			}
 			term.WriteTextStyle(position, lastRow, style, label)
-			position += len(label) + 1
+			term.AddClickTarget(ClickTarget{Type: CLICK_TARGET_CONNECTION, Index: index, X: position, Y: lastRow, Width: textwidth.Width(label), Height: 1})
+			position += textwidth.Width(label) + 1
 		}
 
 		// And the users that are currently typing
@@ -111,7 +123,7 @@ func (term *TerminalDisplay) DrawStatusBar(
 				} else {
 					typingUsers = "Several typing..."
 				}
-				typingUsersXPos := width - len(typingUsers) - 1
+				typingUsersXPos := width - textwidth.Width(typingUsers) - 1
 				term.WriteTextStyle(typingUsersXPos, lastRow, tcell.StyleDefault, typingUsers)
 			}
 		}
@@ -77,3 +77,28 @@ func TestStatusbarTypingUsers(t *testing.T) {
 		t.Errorf("Error:\n%s", result)
 	}
 }
+
+// A connection that slack is rate limiting for the next 30 seconds.
+type throttledConnection struct {
+	*gatewaySlack.SlackConnection
+}
+
+func (c throttledConnection) ThrottledUntil() time.Time {
+	return time.Now().Add(30 * time.Second)
+}
+
+func TestStatusbarThrottledConnection(t *testing.T) {
+	screen := frontend.NewAsciiScreen()
+	term := frontend.NewTerminalDisplay(screen)
+	str := status.Status{Type: status.STATUS_LOG, Message: "", Show: false}
+
+	term.DrawStatusBar("chat", []gateway.Connection{
+		throttledConnection{gatewaySlack.NewWithName("helloworld", "token")},
+		gatewaySlack.NewWithName("example", "token"),
+	}, nil, str, map[string]string{})
+
+	result, ok := screen.Compare("./tests/draw_statusbar_test/statusbar_throttled_connection.txt")
+	if !ok {
+		t.Errorf("Error:\n%s", result)
+	}
+}
@@ -1,8 +1,14 @@
 package frontend
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
 	"github.com/gdamore/tcell"
 	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/textwidth"
 	// "log"
 )
 
@@ -17,6 +23,28 @@ func NewTerminalDisplay(screen tcell.Screen) *TerminalDisplay {
 
 type TerminalDisplay struct {
 	screen tcell.Screen
+
+	// Images that are drawn with escape sequences instead of with cells. `images` are the images
+	// to draw in the next render, and `drawnImages` are the ones currently on the screen.
+	imageOutput  io.Writer
+	imageBackend string
+	images       []placedImage
+	drawnImages  []placedImage
+
+	// Held while another program (ie, an editor) has the terminal. If a new screen can't be created
+	// once it's done, `screenErr` says why.
+	suspended   sync.Mutex
+	isSuspended bool
+	screenErr   error
+	newScreen   func() (tcell.Screen, error) // Creates the screen afterwards, tcell's by default
+
+	// A display created with `Region` draws into part of its parent.
+	parent           *TerminalDisplay
+	offsetX, offsetY int
+
+	// What can be clicked on with the mouse, from the last render.
+	targets      []ClickTarget
+	targetsMutex sync.Mutex
 }
 
 func (term *TerminalDisplay) Screen() tcell.Screen {
@@ -24,7 +52,55 @@ func (term *TerminalDisplay) Screen() tcell.Screen {
 }
 
 func (term *TerminalDisplay) Render() {
-	term.screen.Show()
+	// Don't draw over a program that has the terminal.
+	if term.isSuspended {
+		return
+	}
+	term.renderImages()
+}
+
+// Give the terminal to another program (ie, an editor) while `run` is running. The screen is torn
+// down beforehand and a new one is created afterwards, since tcell can't reuse a screen after it's
+// been finalized.
+func (term *TerminalDisplay) Suspend(run func() error) error {
+	term.suspended.Lock()
+	defer term.suspended.Unlock()
+
+	term.isSuspended = true
+	defer func() { term.isSuspended = false }()
+	term.screen.Fini()
+	runErr := run()
+
+	newScreen := term.newScreen
+	if newScreen == nil {
+		newScreen = tcell.NewScreen
+	}
+	screen, err := newScreen()
+	if err == nil {
+		err = screen.Init()
+	}
+	if err != nil {
+		term.screenErr = errors.New(fmt.Sprintf("Couldn't restore the screen: %s", err))
+		return term.screenErr
+	}
+	term.screen = screen
+	term.drawnImages = nil
+
+	return runErr
+}
+
+// Change how the screen is created after another program is done with the terminal (ie, to use an
+// AsciiScreen in tests).
+func (term *TerminalDisplay) SetNewScreen(newScreen func() (tcell.Screen, error)) {
+	term.newScreen = newScreen
+}
+
+// Wait until the terminal isn't being used by another program. Returns an error if the screen
+// couldn't be restored afterwards.
+func (term *TerminalDisplay) WaitForScreen() error {
+	term.suspended.Lock()
+	defer term.suspended.Unlock()
+	return term.screenErr
 }
 
 func (term *TerminalDisplay) Close() {
@@ -34,9 +110,16 @@ func (term *TerminalDisplay) Close() {
 func (term *TerminalDisplay) WriteText(x int, y int, text string) {
 	term.WriteTextStyle(x, y, tcell.StyleDefault, text)
 }
+// Write text starting at the given position. Wide characters (like emoji) take up two cells, and
+// combining characters (like accents) are drawn in the cell of the character before them.
 func (term *TerminalDisplay) WriteTextStyle(x int, y int, style tcell.Style, text string) {
-	for ct, char := range text {
-		term.screen.SetCell(x+ct, y, style, char)
+	for _, cluster := range textwidth.Clusters(text) {
+		// Control characters (ie, newlines) don't take up any space, and aren't drawn.
+		if cluster.Width == 0 {
+			continue
+		}
+		term.screen.SetContent(x, y, cluster.Base, cluster.Combining, style)
+		x += cluster.Width
 	}
 }
 func (term *TerminalDisplay) WriteParagraphStyle(
@@ -66,19 +149,25 @@ func (term *TerminalDisplay) WriteParagraphStyle(
 					style.Foreground(tcell.ColorBlack).Background(tcell.ColorSilver),
 					part.Content,
 				)
-			case gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED:
+			case gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED, gateway.PRINTABLE_MESSAGE_CODE_BLOCK:
 				term.WriteTextStyle(
 					x+xOffset,
 					y+yOffset,
 					style.Foreground(tcell.ColorBlack).Background(tcell.ColorSilver),
 					part.Content,
 				)
+			case gateway.PRINTABLE_MESSAGE_FORMATTING_STRIKETHROUGH,
+				gateway.PRINTABLE_MESSAGE_BLOCKQUOTE,
+				gateway.PRINTABLE_MESSAGE_CODE_BLOCK_BORDER:
+				term.WriteTextStyle(x+xOffset, y+yOffset, style.Foreground(tcell.ColorGray), part.Content)
+			case gateway.PRINTABLE_MESSAGE_LIST_ITEM:
+				term.WriteTextStyle(x+xOffset, y+yOffset, style.Bold(true), part.Content)
 			default:
 				// Normal text
 				term.WriteTextStyle(x+xOffset, y+yOffset, style, part.Content)
 			}
 
-			xOffset += len(part.Content)
+			xOffset += textwidth.Width(part.Content)
 		}
 
 		// Store the last x and y position ot be rendered.
@@ -0,0 +1,80 @@
+package frontend
+
+import (
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+
+	"github.com/1egoman/slick/color"
+	"github.com/1egoman/slick/gateway" // The thing to interface with slack
+)
+
+// Find the lexer for a language. The language can be the name slack gives a snippet's filetype
+// (ie, `Python`), the tag after a code fence (ie, ```py), or a file extension. Returns nil if the
+// language isn't known.
+func codeLexer(language string) chroma.Lexer {
+	if len(language) == 0 {
+		return nil
+	}
+	return lexers.Get(language)
+}
+
+// Highlight some code in a language with a theme (like `monokai`, see
+// https://xyproto.github.io/splash/docs/ for the rest). Each token in the code becomes a span with
+// the theme's foreground color and formatting for it. Backgrounds are left alone, so highlighted
+// code fits in with the rest of the terminal. Returns nil if the language or theme isn't known.
+func HighlightCode(code string, language string, theme string) []gateway.PrintableMessageSpan {
+	lexer := codeLexer(language)
+	style, ok := styles.Registry[theme]
+	if lexer == nil || !ok {
+		return nil
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return nil
+	}
+
+	var spans []gateway.PrintableMessageSpan
+	for _, token := range iterator.Tokens() {
+		entry := style.Get(token.Type)
+
+		foreground := ""
+		if entry.Colour.IsSet() {
+			foreground = entry.Colour.String()
+		}
+		formatting := color.STYLE_PLAIN
+		if entry.Bold == chroma.Yes {
+			formatting |= color.STYLE_BOLD
+		}
+		if entry.Underline == chroma.Yes {
+			formatting |= color.STYLE_UNDERLINE
+		}
+
+		spans = append(spans, gateway.PrintableMessageSpan{
+			Length: len(token.Value),
+			Style:  color.SerializeStyle(foreground, "", formatting),
+		})
+	}
+	return spans
+}
+
+// Highlight each code block in a message that has a language.
+func highlightCodeBlocks(message *gateway.PrintableMessage, theme string) {
+	parts := message.Parts()
+	for index, part := range parts {
+		language, _ := part.Metadata["Language"].(string)
+		if part.Type != gateway.PRINTABLE_MESSAGE_CODE_BLOCK || len(language) == 0 {
+			continue
+		}
+
+		if spans := HighlightCode(part.Content, language, theme); spans != nil {
+			metadata := map[string]interface{}{"Spans": spans}
+			for key, value := range part.Metadata {
+				metadata[key] = value
+			}
+			parts[index].Metadata = metadata
+		}
+	}
+	message.SetParts(parts)
+}
@@ -0,0 +1,35 @@
+package frontend_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+)
+
+func TestHighlightCode(t *testing.T) {
+	spans := frontend.HighlightCode("func main() {}", "go", "monokai")
+	if !reflect.DeepEqual(spans, []gateway.PrintableMessageSpan{
+		{Length: 4, Style: "#66d9ef::"}, // func
+		{Length: 1, Style: "#f8f8f2::"},
+		{Length: 4, Style: "#a6e22e::"}, // main
+		{Length: 2, Style: "#f8f8f2::"},
+		{Length: 1, Style: "#f8f8f2::"},
+		{Length: 2, Style: "#f8f8f2::"},
+	}) {
+		t.Errorf("Code wasn't highlighted properly: %+v", spans)
+	}
+}
+
+func TestHighlightCodeUnknown(t *testing.T) {
+	if spans := frontend.HighlightCode("foo", "not a language", "monokai"); spans != nil {
+		t.Errorf("Code in an unknown language was highlighted: %+v", spans)
+	}
+	if spans := frontend.HighlightCode("foo", "go", "not a theme"); spans != nil {
+		t.Errorf("Code was highlighted with an unknown theme: %+v", spans)
+	}
+	if spans := frontend.HighlightCode("foo", "go", ""); spans != nil {
+		t.Errorf("Code was highlighted without a theme: %+v", spans)
+	}
+}
@@ -0,0 +1,138 @@
+package frontend
+
+import (
+	"strings"
+
+	"github.com/1egoman/slick/gateway" // The thing to interface with slack
+)
+
+// Given a message, return a *PrintableMessage with a token for each part of it. Messages built with
+// Block Kit are drawn using their blocks, since their text is only a summary for notifications.
+func ParseMessage(message gateway.Message, printableMessage *gateway.PrintableMessage, UserById func(string) (*gateway.User, error)) error {
+	if len(message.Blocks) > 0 {
+		return ParseBlocks(message.Blocks, printableMessage, UserById)
+	}
+	return ParseSlackMessage(message.Text, printableMessage, UserById)
+}
+
+// Given the blocks of a message built with Block Kit, return a *PrintableMessage that draws each
+// block on its own lines:
+//
+// Deploy finished       <= header
+// api is live           <= section, then its fields and accessory
+// Env: prod
+// [ Logs ]
+// by @me                <= context
+// --------------------- <= divider
+// [ Approve ] [ Env ▾ ] <= actions
+func ParseBlocks(blocks []gateway.Block, printableMessage *gateway.PrintableMessage, UserById func(string) (*gateway.User, error)) error {
+	parser := mrkdwnParser{userById: UserById}
+	for _, block := range blocks {
+		parser.block(block)
+	}
+	printableMessage.SetParts(parser.parts)
+	return nil
+}
+
+// Start a new line, unless nothing has been added yet.
+func (p *mrkdwnParser) lineBreak() {
+	if len(p.parts) > 0 {
+		p.add(gateway.PRINTABLE_MESSAGE_NEWLINE, "")
+	}
+}
+
+func (p *mrkdwnParser) block(block gateway.Block) {
+	switch block.Type {
+	case "header":
+		if block.Text != nil {
+			p.lineBreak()
+			p.add(gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD, unEscape(block.Text.Text))
+		}
+
+	case "section":
+		if block.Text != nil {
+			p.lineBreak()
+			p.text(*block.Text)
+		}
+		for _, field := range block.Fields {
+			p.lineBreak()
+			p.text(field)
+		}
+		if block.Accessory != nil {
+			p.lineBreak()
+			p.element(*block.Accessory, gateway.PRINTABLE_MESSAGE_PLAIN_TEXT)
+		}
+
+	case "context":
+		p.lineBreak()
+		for index, element := range block.Elements {
+			if index > 0 {
+				p.add(gateway.PRINTABLE_MESSAGE_BLOCK_CONTEXT, "  ")
+			}
+			p.element(element, gateway.PRINTABLE_MESSAGE_BLOCK_CONTEXT)
+		}
+
+	case "divider":
+		p.lineBreak()
+		p.add(gateway.PRINTABLE_MESSAGE_BLOCK_DIVIDER, "")
+
+	case "image":
+		p.lineBreak()
+		p.element(gateway.BlockElement{Type: "image", Text: block.Title, ImageUrl: block.ImageUrl, AltText: block.AltText}, gateway.PRINTABLE_MESSAGE_PLAIN_TEXT)
+
+	case "actions":
+		p.lineBreak()
+		for index, element := range block.Elements {
+			if index > 0 {
+				p.add(gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, " ")
+			}
+			p.element(element, gateway.PRINTABLE_MESSAGE_PLAIN_TEXT)
+		}
+	}
+}
+
+// Add a text element, which is either mrkdwn or plain text.
+func (p *mrkdwnParser) text(element gateway.BlockElement) {
+	if element.Type == "mrkdwn" {
+		p.parse(element.Text)
+	} else {
+		p.add(gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, unEscape(element.Text))
+	}
+}
+
+// Add an element inside of a block. Text that isn't otherwise formatted is given the type
+// `partType`, so that the text in a context block can be drawn smaller than the rest.
+func (p *mrkdwnParser) element(element gateway.BlockElement, partType gateway.PrintableMessagePartType) {
+	switch {
+	case element.Type == "mrkdwn":
+		p.inline(strings.Replace(element.Text, "\n", " ", -1), partType)
+	case element.Type == "plain_text":
+		p.add(partType, unEscape(element.Text))
+
+	// Images are drawn as a link to the image.
+	case element.Type == "image":
+		label := element.Text
+		if len(label) == 0 {
+			label = element.AltText
+		}
+		if len(label) == 0 {
+			label = "image"
+		}
+		p.parts = append(p.parts, gateway.PrintableMessagePart{
+			Type:     gateway.PRINTABLE_MESSAGE_LINK,
+			Content:  unEscape(label),
+			Metadata: map[string]interface{}{"Href": element.ImageUrl},
+		})
+
+	case element.Type == "button":
+		p.add(gateway.PRINTABLE_MESSAGE_BLOCK_ELEMENT, "[ "+unEscape(element.Text)+" ]")
+	case element.Type == "overflow":
+		p.add(gateway.PRINTABLE_MESSAGE_BLOCK_ELEMENT, "[ ... ]")
+	case strings.HasSuffix(element.Type, "_select") || element.Type == "datepicker":
+		label := element.Placeholder
+		if len(label) == 0 {
+			label = "Choose"
+		}
+		p.add(gateway.PRINTABLE_MESSAGE_BLOCK_ELEMENT, "[ "+unEscape(label)+" ▾ ]")
+	}
+}
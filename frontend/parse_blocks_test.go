@@ -0,0 +1,96 @@
+package frontend_test
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+)
+
+func TestParseBlocks(t *testing.T) {
+	var parsedMessage gateway.PrintableMessage
+
+	blocks := []gateway.Block{
+		{Type: "header", Text: &gateway.BlockElement{Type: "plain_text", Text: "Deploy finished"}},
+		{
+			Type:      "section",
+			Text:      &gateway.BlockElement{Type: "mrkdwn", Text: "*api* is live for <@U024BE7LR>"},
+			Fields:    []gateway.BlockElement{{Type: "mrkdwn", Text: "_Env:_ prod"}},
+			Accessory: &gateway.BlockElement{Type: "button", Text: "Logs"},
+		},
+		{Type: "context", Elements: []gateway.BlockElement{
+			{Type: "image", ImageUrl: "https://example.com/me.png", AltText: "me"},
+			{Type: "mrkdwn", Text: "by *me*"},
+		}},
+		{Type: "divider"},
+		{Type: "image", Title: "Graph", ImageUrl: "https://example.com/graph.png"},
+		{Type: "actions", Elements: []gateway.BlockElement{
+			{Type: "button", Text: "Approve"},
+			{Type: "static_select", Placeholder: "Environment"},
+		}},
+	}
+
+	err := frontend.ParseBlocks(blocks, &parsedMessage, func(id string) (*gateway.User, error) {
+		return &gateway.User{Name: "user-looked-up-by-id"}, nil
+	})
+	if err != nil {
+		t.Errorf("Error parsing blocks: %s", err)
+	}
+
+	newline := gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_NEWLINE}
+	part := func(partType gateway.PrintableMessagePartType, text string) gateway.PrintableMessagePart {
+		return gateway.PrintableMessagePart{Type: partType, Content: text}
+	}
+	link := func(text string, href string) gateway.PrintableMessagePart {
+		return gateway.PrintableMessagePart{
+			Type:     gateway.PRINTABLE_MESSAGE_LINK,
+			Content:  text,
+			Metadata: map[string]interface{}{"Href": href},
+		}
+	}
+
+	// Each block starts on a new line, and images are drawn as links.
+	expected := []gateway.PrintableMessagePart{
+		part(gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD, "Deploy finished"),
+		newline,
+		part(gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD, "api"),
+		part(gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, " is live for "),
+		part(gateway.PRINTABLE_MESSAGE_AT_MENTION_USER, "@user-looked-up-by-id"),
+		newline,
+		part(gateway.PRINTABLE_MESSAGE_FORMATTING_ITALIC, "Env:"),
+		part(gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, " prod"),
+		newline,
+		part(gateway.PRINTABLE_MESSAGE_BLOCK_ELEMENT, "[ Logs ]"),
+		newline,
+		link("me", "https://example.com/me.png"),
+		part(gateway.PRINTABLE_MESSAGE_BLOCK_CONTEXT, "  "),
+		part(gateway.PRINTABLE_MESSAGE_BLOCK_CONTEXT, "by "),
+		part(gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD, "me"),
+		newline,
+		part(gateway.PRINTABLE_MESSAGE_BLOCK_DIVIDER, ""),
+		newline,
+		link("Graph", "https://example.com/graph.png"),
+		newline,
+		part(gateway.PRINTABLE_MESSAGE_BLOCK_ELEMENT, "[ Approve ]"),
+		part(gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, " "),
+		part(gateway.PRINTABLE_MESSAGE_BLOCK_ELEMENT, "[ Environment ▾ ]"),
+	}
+
+	if diff := pretty.Compare(parsedMessage.Parts(), expected); diff != "" {
+		t.Errorf("Blocks were parsed wrong: \n%s", diff)
+	}
+}
+
+func TestParseMessageWithoutBlocks(t *testing.T) {
+	var parsedMessage gateway.PrintableMessage
+	frontend.ParseMessage(gateway.Message{Text: "*hi*"}, &parsedMessage, userById)
+
+	expected := []gateway.PrintableMessagePart{
+		gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD, Content: "hi"},
+	}
+	if diff := pretty.Compare(parsedMessage.Parts(), expected); diff != "" {
+		t.Errorf("Message without blocks wasn't parsed from its text: \n%s", diff)
+	}
+}
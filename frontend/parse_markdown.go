@@ -1,138 +1,229 @@
 package frontend
 
 import (
-	"github.com/kyokomi/emoji" // convert :smile: to unicode
+	"regexp"
 	"strings"
-	// "fmt"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/1egoman/slick/gateway" // The thing to interface with slack
 )
 
+// Parse text formatted with slack's flavor of markdown (mrkdwn) into a printable message. Slack's
+// `<...>` tags (mentions, channels, and links) aren't parsed, see `ParseSlackMessage` for that.
 func ParseMarkdown(text string) gateway.PrintableMessage {
-	text = emoji.Sprintf(text) // Emojis
-	text = strings.Replace(text, "&amp;", "&", -1)
-	text = strings.Replace(text, "&gt;", ">", -1)
-	text = strings.Replace(text, "&lt;", "<", -1)
-
-	var parts []gateway.PrintableMessagePart
-
-	// Iterate through each character in the message.
-	// Look for bits of markdown like *this* or _this_.
-	var tagType gateway.PrintableMessagePartType
-	startIndex := 0 // Start at the beginning of the message text
-	lastTagEndIndex := 0
-	for index, char := range text {
-		if char == '\n' {
-			// Enforce newlines.
-
-			// Add any text before the newline to the printable message slice.
-			if lastTagEndIndex < index {
-				parts = append(parts, gateway.PrintableMessagePart{
-					Type:    gateway.PRINTABLE_MESSAGE_PLAIN_TEXT,
-					Content: text[lastTagEndIndex:index],
-				})
-			}
-			startIndex = index + 1
-			lastTagEndIndex = index+1
-
-			// Add the newline.
-			parts = append(parts, gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_NEWLINE})
-
-		// BOLD
-		} else if char == '*' && tagType == gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD {
-			// Add any text before the newline to the printable message slice.
-			parts = append(parts, gateway.PrintableMessagePart{
-				Type:    gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD,
-				Content: text[startIndex:index],
-			})
-			lastTagEndIndex = index+1
-		} else if char == '*' {
-			// Since we just discovered the boundary of the next bit of interest, then add the
-			// previous plain text bit (before this tag) to the parts slice.
-			if index - lastTagEndIndex > 0 {
-				parts = append(parts, gateway.PrintableMessagePart{
-					Type:    gateway.PRINTABLE_MESSAGE_PLAIN_TEXT,
-					Content: text[lastTagEndIndex:index],
-				})
+	parser := mrkdwnParser{}
+	parser.parse(text)
+	return gateway.NewPrintableMessage(parser.parts)
+}
+
+// A list item starts with a bullet or a number, then a space (like `- foo`, `• foo`, or `1. foo`).
+var mrkdwnListItem = regexp.MustCompile(`^(\s*)([-*•◦▪]|[0-9]+[.)]) +`)
+
+type mrkdwnParser struct {
+	parts []gateway.PrintableMessagePart
+
+	// When set, slack's `<...>` tags are parsed too, and users are looked up with it.
+	userById func(string) (*gateway.User, error)
+}
+
+func (p *mrkdwnParser) add(partType gateway.PrintableMessagePartType, content string) {
+	p.parts = append(p.parts, gateway.PrintableMessagePart{Type: partType, Content: content})
+}
+
+// Parse a message line by line. Things that take up whole lines (quotes, list items, and code
+// blocks) are handled here, and the rest of each line is parsed by `inline`.
+func (p *mrkdwnParser) parse(text string) {
+	lines := strings.Split(text, "\n")
+	quoteRestOfMessage := false // Set by `>>>`, which quotes everything after it.
+	afterCodeBlock := false
+
+	for index := 0; index < len(lines); index++ {
+		line := lines[index]
+
+		// Enforce newlines. (A code block is always followed by a new line, so it doesn't need one)
+		if index > 0 && !afterCodeBlock {
+			p.add(gateway.PRINTABLE_MESSAGE_NEWLINE, "")
+		}
+		afterCodeBlock = false
+
+		// QUOTES
+		if rest, ok := trimQuoteMarker(line, "&gt;&gt;&gt;", ">>>"); ok && !quoteRestOfMessage {
+			quoteRestOfMessage = true
+			line = rest
+		} else if rest, ok := trimQuoteMarker(line, "&gt;", ">"); ok && !quoteRestOfMessage {
+			p.add(gateway.PRINTABLE_MESSAGE_BLOCKQUOTE, "| ")
+			line = rest
+		}
+		if quoteRestOfMessage {
+			p.add(gateway.PRINTABLE_MESSAGE_BLOCKQUOTE, "| ")
+		}
+
+		// LISTS
+		if match := mrkdwnListItem.FindStringSubmatch(line); match != nil {
+			bullet := match[2]
+			if bullet == "-" || bullet == "*" {
+				bullet = "•"
 			}
+			indent := strings.Replace(match[1], "\t", "    ", -1)
+			p.add(gateway.PRINTABLE_MESSAGE_LIST_ITEM, indent+bullet+" ")
+			line = line[len(match[0]):]
+		}
 
-			tagType = gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD
-			startIndex = index+1
-
-		// ITALICS
-		} else if char == '_' && tagType == gateway.PRINTABLE_MESSAGE_FORMATTING_ITALIC {
-			// Add any text before the newline to the printable message slice.
-			parts = append(parts, gateway.PrintableMessagePart{
-				Type:    gateway.PRINTABLE_MESSAGE_FORMATTING_ITALIC,
-				Content: text[startIndex:index],
-			})
-			lastTagEndIndex = index+1
-		} else if char == '_' {
-			// Since we just discovered the boundary of the next bit of interest, then add the
-			// previous plain text bit (before this tag) to the parts slice.
-			if index - lastTagEndIndex > 0 {
-				parts = append(parts, gateway.PrintableMessagePart{
-					Type:    gateway.PRINTABLE_MESSAGE_PLAIN_TEXT,
-					Content: text[lastTagEndIndex:index],
-				})
+		// CODE BLOCKS
+		// A code block starts with ``` and ends with ``` on a later line. (When both are on the same
+		// line, it's preformatted text inside the line instead, see `inline`)
+		if start := strings.Index(line, "```"); start >= 0 && !strings.Contains(line[start+3:], "```") {
+			for end := index + 1; end < len(lines); end++ {
+				closing := strings.Index(lines[end], "```")
+				if closing == -1 {
+					continue
+				}
+
+				// Anything before the code block is drawn before the box.
+				p.inline(line[:start], gateway.PRINTABLE_MESSAGE_PLAIN_TEXT)
+
+				// A language can follow the opening fence on its own (like ```go), to highlight the code.
+				firstLine, language := line[start+3:], ""
+				if tag := strings.TrimSpace(firstLine); len(tag) > 0 && !strings.ContainsAny(tag, " \t") && codeLexer(tag) != nil {
+					firstLine, language = "", tag
+				}
+
+				code := strings.Join(append(append([]string{firstLine}, lines[index+1:end]...), lines[end][:closing]), "\n")
+				code = strings.TrimPrefix(strings.TrimSuffix(code, "\n"), "\n")
+				code = strings.Replace(unEscapeEntities(code), "\t", "    ", -1)
+				if len(language) > 0 {
+					p.parts = append(p.parts, gateway.PrintableMessagePart{
+						Type:     gateway.PRINTABLE_MESSAGE_CODE_BLOCK,
+						Content:  code,
+						Metadata: map[string]interface{}{"Language": language},
+					})
+				} else {
+					p.add(gateway.PRINTABLE_MESSAGE_CODE_BLOCK, code)
+				}
+
+				// Anything after the code block is drawn after the box, as if it were its own line.
+				lines[end] = lines[end][closing+3:]
+				if len(lines[end]) == 0 {
+					end += 1
+				}
+				index = end - 1
+				afterCodeBlock = true
+				break
 			}
+			if afterCodeBlock {
+				continue
+			}
+		}
 
-			tagType = gateway.PRINTABLE_MESSAGE_FORMATTING_ITALIC
-			startIndex = index+1
+		p.inline(line, gateway.PRINTABLE_MESSAGE_PLAIN_TEXT)
+	}
+}
 
-		// PREFORMATTED
-		} else if index < len(text) - 2 && char == '`' && text[index + 1] == '`' && text[index + 2] == '`' && tagType == gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED {
-			// Add any text before the newline to the printable message slice.
-			parts = append(parts, gateway.PrintableMessagePart{
-				Type:    gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED,
-				Content: text[startIndex:index],
-			})
-			lastTagEndIndex = index+3
-		} else if index < len(text) - 2 && char == '`' && text[index + 1] == '`' && text[index + 2] == '`' {
-			// Since we just discovered the boundary of the next bit of interest, then add the
-			// previous plain text bit (before this tag) to the parts slice.
-			if index - lastTagEndIndex > 0 {
-				parts = append(parts, gateway.PrintableMessagePart{
-					Type:    gateway.PRINTABLE_MESSAGE_PLAIN_TEXT,
-					Content: text[lastTagEndIndex:index],
-				})
+// Remove a quote marker (and the space after it) from the start of a line. Slack escapes `>` in
+// the messages it sends, but markdown typed by hand doesn't.
+func trimQuoteMarker(line string, markers ...string) (string, bool) {
+	for _, marker := range markers {
+		if strings.HasPrefix(line, marker) {
+			return strings.TrimPrefix(line[len(marker):], " "), true
+		}
+	}
+	return line, false
+}
+
+// The part type that surrounding text with a character formats it as.
+var mrkdwnFormatting = map[byte]gateway.PrintableMessagePartType{
+	'*': gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD,
+	'_': gateway.PRINTABLE_MESSAGE_FORMATTING_ITALIC,
+	'~': gateway.PRINTABLE_MESSAGE_FORMATTING_STRIKETHROUGH,
+}
+
+// Parse formatting within a line, like *this*, _this_, ~this~, `this`, and ```this```. Plain text
+// is given the type `partType`, so that formatting can be nested (ie, *bold <#channel>*).
+func (p *mrkdwnParser) inline(text string, partType gateway.PrintableMessagePartType) {
+	// Add the plain text before the bit of interest that was just discovered.
+	plainTextStart := 0
+	addPlainText := func(end int) {
+		if end > plainTextStart {
+			p.add(partType, unEscape(text[plainTextStart:end]))
+		}
+	}
+
+	for index := 0; index < len(text); index++ {
+		char := text[index]
+
+		// TAGS
+		if char == '<' && p.userById != nil {
+			if end := strings.IndexByte(text[index:], '>'); end > 0 {
+				addPlainText(index)
+				p.parts = append(p.parts, parseSlackTag(text[index+1:index+end], p.userById))
+				index += end
+				plainTextStart = index + 1
 			}
 
-			tagType = gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED
-			startIndex = index+3
+		// PREFORMATTED
+		} else if strings.HasPrefix(text[index:], "```") {
+			if end := strings.Index(text[index+3:], "```"); end >= 0 {
+				addPlainText(index)
+				p.add(gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED, unEscapeEntities(text[index+3:index+3+end]))
+				index += end + 5
+				plainTextStart = index + 1
+			}
 
 		// CODE
-		} else if char == '`' && tagType == gateway.PRINTABLE_MESSAGE_FORMATTING_CODE {
-			// Add any text before the newline to the printable message slice.
-			parts = append(parts, gateway.PrintableMessagePart{
-				Type:    gateway.PRINTABLE_MESSAGE_FORMATTING_CODE,
-				Content: text[startIndex:index],
-			})
-			lastTagEndIndex = index+1
-		} else if char == '`' && tagType != gateway.PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED {
-			// Since we just discovered the boundary of the next bit of interest, then add the
-			// previous plain text bit (before this tag) to the parts slice.
-			if index - lastTagEndIndex > 0 {
-				parts = append(parts, gateway.PrintableMessagePart{
-					Type:    gateway.PRINTABLE_MESSAGE_PLAIN_TEXT,
-					Content: text[lastTagEndIndex:index],
-				})
+		} else if char == '`' {
+			if end := strings.IndexByte(text[index+1:], '`'); end > 0 {
+				addPlainText(index)
+				p.add(gateway.PRINTABLE_MESSAGE_FORMATTING_CODE, unEscapeEntities(text[index+1:index+1+end]))
+				index += end + 1
+				plainTextStart = index + 1
 			}
 
-			tagType = gateway.PRINTABLE_MESSAGE_FORMATTING_CODE
-			startIndex = index+1
+		// BOLD, ITALICS, and STRIKETHROUGH
+		} else if formatting, ok := mrkdwnFormatting[char]; ok {
+			if end := closingFormattingMarker(text, index); end >= 0 {
+				addPlainText(index)
+				p.inline(text[index+1:end], formatting)
+				index = end
+				plainTextStart = index + 1
+			}
 		}
 	}
 
 	// Add the final plain text part to the message.
 	// bla bla #general foo bar
 	//                  ^^^^^^ = This bit
-	if lastTagEndIndex < len(text) {
-		parts = append(parts, gateway.PrintableMessagePart{
-			Type:    gateway.PRINTABLE_MESSAGE_PLAIN_TEXT,
-			Content: text[lastTagEndIndex:],
-		})
+	addPlainText(len(text))
+}
+
+// Given the index of a formatting character (like the first `*` in `*foo*`), return the index of
+// the character that closes it, or -1 if it isn't closed. Like slack, formatting has to start at
+// the start of a word and end at the end of one, so that `2*3*4` or `snake_case_name` aren't
+// formatted.
+func closingFormattingMarker(text string, start int) int {
+	marker := text[start]
+	if start > 0 && isWordCharacter(text[:start], true) {
+		return -1
+	}
+	if start+1 >= len(text) || text[start+1] == ' ' || text[start+1] == marker {
+		return -1
 	}
 
-	return gateway.NewPrintableMessage(parts)
+	for end := start + 2; end < len(text); end++ {
+		if text[end] == marker && text[end-1] != ' ' && (end+1 == len(text) || !isWordCharacter(text[end+1:], false)) {
+			return end
+		}
+	}
+	return -1
+}
+
+// Is the character at the end (or start) of the text a letter or number?
+func isWordCharacter(text string, atEnd bool) bool {
+	var char rune
+	if atEnd {
+		char, _ = utf8.DecodeLastRuneInString(text)
+	} else {
+		char, _ = utf8.DecodeRuneInString(text)
+	}
+	return unicode.IsLetter(char) || unicode.IsNumber(char)
 }
@@ -4,6 +4,7 @@ import (
 	// "fmt"
 	"testing"
 	"github.com/kyokomi/emoji"
+	"github.com/kylelemons/godebug/pretty"
 	"reflect"
 	. "github.com/1egoman/slick/frontend"
 	"github.com/1egoman/slick/gateway"
@@ -159,3 +160,107 @@ func TestParseMarkdown(t *testing.T) {
 		t.Errorf("The markdown 'hello *world* bar\\nfoo bar baz' wasn't parsed properly: %+v", markdownTen)
 	}
 }
+
+func TestParseMarkdownFormatting(t *testing.T) {
+	plain := func(text string) gateway.PrintableMessagePart {
+		return gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, Content: text}
+	}
+	part := func(partType gateway.PrintableMessagePartType, text string) gateway.PrintableMessagePart {
+		return gateway.PrintableMessagePart{Type: partType, Content: text}
+	}
+	newline := gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_NEWLINE}
+	quote := part(gateway.PRINTABLE_MESSAGE_BLOCKQUOTE, "| ")
+	bullet := part(gateway.PRINTABLE_MESSAGE_LIST_ITEM, "• ")
+
+	for _, test := range []struct {
+		Markdown string
+		Parts    []gateway.PrintableMessagePart
+	}{
+		// Strikethrough
+		{"a ~mistake~ here", []gateway.PrintableMessagePart{
+			plain("a "), part(gateway.PRINTABLE_MESSAGE_FORMATTING_STRIKETHROUGH, "mistake"), plain(" here"),
+		}},
+		// Formatting inside of formatting
+		{"*bold _and italic_*", []gateway.PrintableMessagePart{
+			part(gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD, "bold "),
+			part(gateway.PRINTABLE_MESSAGE_FORMATTING_ITALIC, "and italic"),
+		}},
+		// Formatting isn't parsed inside of code
+		{"run `rm *.go` now", []gateway.PrintableMessagePart{
+			plain("run "), part(gateway.PRINTABLE_MESSAGE_FORMATTING_CODE, "rm *.go"), plain(" now"),
+		}},
+		// Formatting has to start and end at a word boundary
+		{"snake_case_name and 2*3*4", []gateway.PrintableMessagePart{plain("snake_case_name and 2*3*4")}},
+		{"*not bold *", []gateway.PrintableMessagePart{plain("*not bold *")}},
+		{"a * not bold*", []gateway.PrintableMessagePart{plain("a * not bold*")}},
+		// Formatting that's never closed is left alone
+		{"a *b", []gateway.PrintableMessagePart{plain("a *b")}},
+		{"a ` b", []gateway.PrintableMessagePart{plain("a ` b")}},
+		// Formatting can't span lines
+		{"*foo\nbar*", []gateway.PrintableMessagePart{plain("*foo"), newline, plain("bar*")}},
+		// Escaped characters in code are unescaped
+		{"`a &lt; b`", []gateway.PrintableMessagePart{part(gateway.PRINTABLE_MESSAGE_FORMATTING_CODE, "a < b")}},
+
+		// Quotes, escaped like slack sends them or not
+		{"&gt; quoted\nnot quoted", []gateway.PrintableMessagePart{quote, plain("quoted"), newline, plain("not quoted")}},
+		{">quoted *bold*", []gateway.PrintableMessagePart{
+			quote, plain("quoted "), part(gateway.PRINTABLE_MESSAGE_FORMATTING_BOLD, "bold"),
+		}},
+		// A quote marker in the middle of a line isn't a quote
+		{"a &gt; b", []gateway.PrintableMessagePart{plain("a > b")}},
+		// Everything after >>> is quoted
+		{"before\n&gt;&gt;&gt; one\ntwo", []gateway.PrintableMessagePart{
+			plain("before"), newline, quote, plain("one"), newline, quote, plain("two"),
+		}},
+
+		// Lists
+		{"- one\n* two\n• three", []gateway.PrintableMessagePart{
+			bullet, plain("one"), newline, bullet, plain("two"), newline, bullet, plain("three"),
+		}},
+		{"1. one\n12) twelve", []gateway.PrintableMessagePart{
+			part(gateway.PRINTABLE_MESSAGE_LIST_ITEM, "1. "), plain("one"), newline,
+			part(gateway.PRINTABLE_MESSAGE_LIST_ITEM, "12) "), plain("twelve"),
+		}},
+		{"- one\n  - nested", []gateway.PrintableMessagePart{
+			bullet, plain("one"), newline, part(gateway.PRINTABLE_MESSAGE_LIST_ITEM, "  • "), plain("nested"),
+		}},
+		{"> - quoted list", []gateway.PrintableMessagePart{quote, bullet, plain("quoted list")}},
+		// A dash without a space after it isn't a bullet
+		{"-1 degrees", []gateway.PrintableMessagePart{plain("-1 degrees")}},
+
+		// Code blocks
+		{"```\nfunc main() {\n\tfmt.Println(\"&lt;hi&gt;\")\n}\n```", []gateway.PrintableMessagePart{
+			part(gateway.PRINTABLE_MESSAGE_CODE_BLOCK, "func main() {\n    fmt.Println(\"<hi>\")\n}"),
+		}},
+		{"look: ```one\ntwo``` after\nnext", []gateway.PrintableMessagePart{
+			plain("look: "),
+			part(gateway.PRINTABLE_MESSAGE_CODE_BLOCK, "one\ntwo"),
+			plain(" after"),
+			newline,
+			plain("next"),
+		}},
+		{"```\n*not bold*\n```\nafter", []gateway.PrintableMessagePart{
+			part(gateway.PRINTABLE_MESSAGE_CODE_BLOCK, "*not bold*"),
+			plain("after"),
+		}},
+		// A language after the opening fence is used to highlight the code block
+		{"```python\nprint(1)\n```", []gateway.PrintableMessagePart{
+			gateway.PrintableMessagePart{
+				Type:     gateway.PRINTABLE_MESSAGE_CODE_BLOCK,
+				Content:  "print(1)",
+				Metadata: map[string]interface{}{"Language": "python"},
+			},
+		}},
+		// ... but a word that isn't a language is part of the code
+		{"```hello\nworld\n```", []gateway.PrintableMessagePart{
+			part(gateway.PRINTABLE_MESSAGE_CODE_BLOCK, "hello\nworld"),
+		}},
+		// A code block that's never closed is left alone
+		{"```\nfoo", []gateway.PrintableMessagePart{plain("```"), newline, plain("foo")}},
+	} {
+		parsed := ParseMarkdown(test.Markdown)
+		if diff := pretty.Compare(parsed.Parts(), test.Parts); diff != "" {
+			t.Errorf("The markdown %q wasn't parsed properly:\n%s", test.Markdown, diff)
+		}
+	}
+}
@@ -121,3 +121,40 @@ func TestParseNewlines(t *testing.T) {
 		t.Errorf("Slack message was bad: \n%s", diff)
 	}
 }
+
+func TestParseFormattedSlackMessage(t *testing.T) {
+	var parsedMessage gateway.PrintableMessage
+
+	message := "&gt; *<https://example.com?a=1&amp;b=2|the docs>* say ~<@U024BE7LR>~ `<@U024BE7LR>`"
+
+	err := frontend.ParseSlackMessage(
+		message,
+		&parsedMessage,
+		func (id string) (*gateway.User, error) {
+			return &gateway.User{Name: "user-looked-up-by-id"}, nil
+		},
+	)
+
+	if err != nil {
+		t.Errorf("Error parsing slack message: %s", err)
+	}
+
+	// Links show their label, tags keep their type inside of formatting, and tags aren't parsed
+	// inside of code.
+	expected := []gateway.PrintableMessagePart{
+		gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_BLOCKQUOTE, Content: "| "},
+		gateway.PrintableMessagePart{
+			Type:     gateway.PRINTABLE_MESSAGE_LINK,
+			Content:  "the docs",
+			Metadata: map[string]interface{}{"Href": "https://example.com?a=1&b=2"},
+		},
+		gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, Content: " say "},
+		gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_AT_MENTION_USER, Content: "@user-looked-up-by-id"},
+		gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_PLAIN_TEXT, Content: " "},
+		gateway.PrintableMessagePart{Type: gateway.PRINTABLE_MESSAGE_FORMATTING_CODE, Content: "<@U024BE7LR>"},
+	}
+
+	if diff := pretty.Compare(parsedMessage.Parts(), expected); diff != "" {
+		t.Errorf("Slack message was bad: \n%s", diff)
+	}
+}
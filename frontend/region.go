@@ -0,0 +1,67 @@
+package frontend
+
+import (
+	"github.com/1egoman/slick/textwidth"
+	"github.com/gdamore/tcell"
+)
+
+// A rectangle within a screen. Everything drawn into it is offset by its position and clipped to
+// its size, so that the same drawing functions can draw into part of the screen (ie, the messages
+// next to the sidebar).
+type regionScreen struct {
+	tcell.Screen
+	x, y          int
+	width, height int
+}
+
+func (r regionScreen) contains(x int, y int) bool {
+	return x >= 0 && y >= 0 && x < r.width && y < r.height
+}
+
+func (r regionScreen) Size() (int, int) {
+	return r.width, r.height
+}
+
+func (r regionScreen) SetCell(x int, y int, style tcell.Style, ch ...rune) {
+	if r.contains(x, y) {
+		r.Screen.SetCell(r.x+x, r.y+y, style, ch...)
+	}
+}
+
+func (r regionScreen) SetContent(x int, y int, mainc rune, combc []rune, style tcell.Style) {
+	// A wide character in the last column would spill out of the region.
+	if x == r.width-1 && textwidth.Width(string(mainc)) == 2 {
+		mainc, combc = ' ', nil
+	}
+	if r.contains(x, y) {
+		r.Screen.SetContent(r.x+x, r.y+y, mainc, combc, style)
+	}
+}
+
+func (r regionScreen) GetContent(x int, y int) (rune, []rune, tcell.Style, int) {
+	if !r.contains(x, y) {
+		return ' ', nil, tcell.StyleDefault, 1
+	}
+	return r.Screen.GetContent(r.x+x, r.y+y)
+}
+
+func (r regionScreen) ShowCursor(x int, y int) {
+	r.Screen.ShowCursor(r.x+x, r.y+y)
+}
+
+// A display that draws into a rectangle within this one.
+func (term *TerminalDisplay) Region(x int, y int, width int, height int) *TerminalDisplay {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	return &TerminalDisplay{
+		screen:      regionScreen{Screen: term.screen, x: x, y: y, width: width, height: height},
+		parent:      term,
+		offsetX:     x,
+		offsetY:     y,
+		imageOutput: term.imageOutput,
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scores given to each character of the needle found in an item. Matches at the start of a word
+// and runs of consecutive matches are worth more, and every character skipped between two matches
+// costs a little. This means that `gnrl` matches `general`, but `gen` ranks `general` above
+// `design-engineering`.
+const (
+	fuzzyScoreMatch       = 16
+	fuzzyBonusFirstChar   = 10
+	fuzzyBonusBoundary    = 8
+	fuzzyBonusConsecutive = 6
+	fuzzyPenaltyGap       = 1
+)
+
+// Characters that separate words in an item.
+const fuzzyWordSeparators = " -_/.#:@()\t"
+
+// Does the character at the given index start a word?
+func fuzzyBonus(haystack []rune, index int) int {
+	if index == 0 {
+		return fuzzyBonusFirstChar
+	}
+	previous, current := haystack[index-1], haystack[index]
+	if strings.ContainsRune(fuzzyWordSeparators, previous) {
+		return fuzzyBonusBoundary
+	}
+	if unicode.IsLower(previous) && unicode.IsUpper(current) { // camelCase
+		return fuzzyBonusBoundary
+	}
+	return 0
+}
+
+// Find the characters in `needle` within `haystack`, in order, but not necessarily next to each
+// other. Returns a score (higher is a better match) and the index of each matched rune in the
+// haystack, or -1 if the haystack doesn't contain the needle. Matching ignores case unless the
+// needle has an upper case letter in it.
+func FuzzyMatch(needle string, haystack string) (int, []int) {
+	if len(needle) == 0 {
+		return 0, nil
+	}
+
+	needleRunes := []rune(needle)
+	haystackRunes := []rune(haystack)
+	matchRunes := haystackRunes
+	if strings.ToLower(needle) == needle {
+		needleRunes = []rune(strings.ToLower(needle))
+		matchRunes = []rune(strings.ToLower(haystack))
+		if len(matchRunes) != len(haystackRunes) { // Lowercasing changed the length, so compare as-is.
+			matchRunes = haystackRunes
+		}
+	}
+
+	n, m := len(needleRunes), len(matchRunes)
+	if n > m {
+		return -1, nil
+	}
+
+	// Quickly throw away items that don't contain every character, which is most of them.
+	index := 0
+	for _, char := range matchRunes {
+		if index < n && char == needleRunes[index] {
+			index += 1
+		}
+	}
+	if index < n {
+		return -1, nil
+	}
+
+	// scores[i][j] is the best score for matching the first i+1 characters of the needle, with
+	// the last one at haystack index j. previous[i][j] is where the character before it matched.
+	const noMatch = -1 << 30
+	scores := make([][]int, n)
+	previous := make([][]int, n)
+	for i := range scores {
+		scores[i] = make([]int, m)
+		previous[i] = make([]int, m)
+		for j := range scores[i] {
+			scores[i][j] = noMatch
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		// The best score of an earlier match, counting the gap penalty up to the current index.
+		bestEarlier, bestEarlierIndex := noMatch, -1
+		for j := i; j < m; j++ {
+			if i > 0 && j >= 2 && scores[i-1][j-2] != noMatch {
+				if gapped := scores[i-1][j-2] + (j-2)*fuzzyPenaltyGap; gapped > bestEarlier {
+					bestEarlier, bestEarlierIndex = gapped, j-2
+				}
+			}
+			if matchRunes[j] != needleRunes[i] {
+				continue
+			}
+
+			score := fuzzyScoreMatch + fuzzyBonus(haystackRunes, j)
+			if i == 0 {
+				scores[i][j] = score - j*fuzzyPenaltyGap/2 // Matches closer to the start are better
+				previous[i][j] = -1
+				continue
+			}
+
+			// Either continue a run of matches, or jump from an earlier match.
+			if scores[i-1][j-1] != noMatch {
+				scores[i][j] = scores[i-1][j-1] + score + fuzzyBonusConsecutive
+				previous[i][j] = j - 1
+			}
+			if bestEarlier != noMatch {
+				if gapped := bestEarlier - (j-1)*fuzzyPenaltyGap + score; gapped > scores[i][j] {
+					scores[i][j] = gapped
+					previous[i][j] = bestEarlierIndex
+				}
+			}
+		}
+	}
+
+	// Find the best place for the last character, then walk backwards to find the rest.
+	best, bestIndex := noMatch, -1
+	for j := n - 1; j < m; j++ {
+		if scores[n-1][j] > best {
+			best, bestIndex = scores[n-1][j], j
+		}
+	}
+	if bestIndex == -1 {
+		return -1, nil
+	}
+
+	positions := make([]int, n)
+	for i := n - 1; i >= 0; i-- {
+		positions[i] = bestIndex
+		bestIndex = previous[i][bestIndex]
+	}
+
+	// Shorter items are a slightly better match, but a match is never worth less than 1.
+	best -= m / 8
+	if best < 1 {
+		best = 1
+	}
+	return best, positions
+}
@@ -0,0 +1,159 @@
+package main_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/1egoman/slick"
+)
+
+func TestFuzzyMatchIsASubsequenceMatch(t *testing.T) {
+	if score, positions := FuzzyMatch("gnrl", "general"); score < 0 || !reflect.DeepEqual(positions, []int{0, 2, 4, 6}) {
+		t.Errorf("gnrl should match general at 0, 2, 4, and 6, got %d %v", score, positions)
+	}
+	if score, _ := FuzzyMatch("lrng", "general"); score >= 0 {
+		t.Errorf("lrng shouldn't match general, since the characters are out of order")
+	}
+	if score, _ := FuzzyMatch("GEN", "general"); score >= 0 {
+		t.Errorf("Upper case needles should match case sensitively")
+	}
+	if score, _ := FuzzyMatch("gen", "General"); score < 0 {
+		t.Errorf("Lower case needles should match case insensitively")
+	}
+	if score, positions := FuzzyMatch("", "general"); score != 0 || positions != nil {
+		t.Errorf("An empty needle should match everything equally")
+	}
+}
+
+func TestFuzzyMatchPrefersWordStartsAndRuns(t *testing.T) {
+	for _, test := range []struct {
+		Needle string
+		Better string
+		Worse  string
+	}{
+		{"gen", "general", "design-engineering"},
+		{"dev", "dev-ops", "random-devices"},
+		{"fe", "#frontend-eng team", "#coffee team"},
+		{"rnd", "random", "around-the-world"},
+	} {
+		better, _ := FuzzyMatch(test.Needle, test.Better)
+		worse, _ := FuzzyMatch(test.Needle, test.Worse)
+		if better <= worse {
+			t.Errorf("%s should rank %s (%d) above %s (%d)", test.Needle, test.Better, better, test.Worse, worse)
+		}
+	}
+}
+
+func TestFuzzyMatchFindsTheBestPositions(t *testing.T) {
+	// The greedy match would be the `e` and `n` in `design`, rather than the run in `engineering`.
+	_, positions := FuzzyMatch("eng", "design engineering")
+	if !reflect.DeepEqual(positions, []int{7, 8, 9}) {
+		t.Errorf("Expected the match at the start of `engineering`, got %v", positions)
+	}
+}
+
+func TestSelectionInputSortsByScore(t *testing.T) {
+	input := SelectionInput{
+		Items:       []interface{}{1, 2, 3, 4},
+		StringItems: []string{"#random team", "#design-engineering team", "#general team\t(archived)", "#off-topic team"},
+		Needle:      "gen",
+	}
+	input.Sort(nil)
+
+	if input.StringItems[0] != "#general team\t(archived)" || input.Items[0] != 3 {
+		t.Errorf("#general should be first, got %v", input.StringItems)
+	}
+	if score, _ := input.Match("#random team"); score >= 0 {
+		t.Errorf("#random shouldn't match gen")
+	}
+	if input.Rank("#off-topic team") >= 0 {
+		t.Errorf("#off-topic shouldn't match gen")
+	}
+}
+
+func TestSelectionInputBoostsFrecentItems(t *testing.T) {
+	frecency := NewFrecency("")
+	frecency.Record("#gaming-events-news-rally team")
+
+	input := SelectionInput{
+		Items:       []interface{}{1, 2},
+		StringItems: []string{"#general team", "#gaming-events-news-rally team"},
+	}
+	input.Sort(frecency)
+	if input.StringItems[0] != "#gaming-events-news-rally team" {
+		t.Errorf("The item picked most should be first, got %v", input.StringItems)
+	}
+
+	// A much better match still wins.
+	input.Needle = "general"
+	input.Sort(frecency)
+	if input.StringItems[0] != "#general team" {
+		t.Errorf("The better match should be first, got %v", input.StringItems)
+	}
+}
+
+func TestSelectionInputRanksHiddenItemsLower(t *testing.T) {
+	input := SelectionInput{
+		Items:       []interface{}{1, 2},
+		StringItems: []string{".notes", "notes"},
+		Needle:      "notes",
+	}
+	input.Sort(nil)
+	if input.StringItems[0] != "notes" || input.Rank(".notes") < 0 {
+		t.Errorf("The hidden item should be ranked lower but still shown, got %v", input.StringItems)
+	}
+}
+
+func TestSelectionInputSortsLargeListsQuickly(t *testing.T) {
+	input := SelectionInput{}
+	for i := 0; i < 50000; i++ {
+		input.Items = append(input.Items, i)
+		input.StringItems = append(input.StringItems, fmt.Sprintf("#channel-number-%d my-team", i))
+	}
+	input.Needle = "chn4242"
+
+	start := time.Now()
+	input.Sort(nil)
+	input.Sort(nil) // Sorting again without changes is free.
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Sorting 50000 items took %s", elapsed)
+	}
+	if input.StringItems[0] != "#channel-number-4242 my-team" {
+		t.Errorf("Expected #channel-number-4242 first, got %s", input.StringItems[0])
+	}
+}
+
+func TestFrecencyIsSavedAndLoaded(t *testing.T) {
+	directory, err := ioutil.TempDir("", "slick-frecency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "frecency")
+
+	frecency := NewFrecency(path)
+	frecency.Record("#general team")
+	frecency.Record("#general team")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Frecency shouldn't be written to disk until it's saved")
+	}
+	if err := frecency.Save(); err != nil {
+		t.Fatalf("Error saving frecency: %s", err)
+	}
+
+	loaded := LoadFrecency(path)
+	if score := loaded.Score("#general team", time.Now()); score != 8 {
+		t.Errorf("Two recent picks should score 8, got %d", score)
+	}
+	if score := loaded.Score("#general team", time.Now().Add(60*24*time.Hour)); score != 2 {
+		t.Errorf("Two old picks should score 2, got %d", score)
+	}
+	if score := loaded.Score("#random team", time.Now()); score != 0 {
+		t.Errorf("Items that were never picked shouldn't be boosted, got %d", score)
+	}
+}
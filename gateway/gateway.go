@@ -1,5 +1,11 @@
 package gateway
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 // A Connection is used to represent a message source.
 type Connection interface {
 	// Each connection has a name.
@@ -25,6 +31,9 @@ type Connection interface {
 	SendMessage(Message, *Channel) (*Message, error)
 	ParseMessage(map[string]interface{}, map[string]*User) (*Message, error)
 	ToggleMessageReaction(Message, string) error
+	// Press a button or pick an option in a menu in a message. The bot that posted the message is
+	// told, and it usually responds by updating the message.
+	SendMessageAction(Message, MessageAction) error
 
 	// Fetch a slice of all channels that are available on this connection
 	Channels() []Channel
@@ -35,6 +44,11 @@ type Connection interface {
 	JoinChannel(*Channel) (*Channel, error)
 	LeaveChannel(*Channel) (*Channel, error)
 
+	// Fetch a channel's topic, purpose, and members.
+	FetchChannelInfo(*Channel) (*Channel, error)
+	SetChannelTopic(*Channel, string) (*Channel, error)
+	SetChannelPurpose(*Channel, string) (*Channel, error)
+
 	// Fetch the team associated with this connection.
 	Team() *Team
 	SetTeam(Team)
@@ -43,12 +57,32 @@ type Connection interface {
 	Self() *User
 	SetSelf(User)
 
-	// Given a channel, fetch the message history for that channel. Optionally, provide a timestamp
-	// to fetch all messages after.
-	FetchChannelMessages(Channel, *string) ([]Message, error)
+	// Given a channel, fetch a page of message history for that channel. Optionally, provide a
+	// `latest` timestamp to fetch the page of messages before it, an `oldest` timestamp to fetch
+	// the page of messages after it, or both to fetch the newest messages between them.
+	FetchChannelMessages(channel Channel, latest *string, oldest *string) ([]Message, error)
+
+	// Are there older messages in the channel than the ones that have been fetched?
+	HasMoreMessageHistory(Channel) bool
+
+	// The timestamp of the newest message in a channel that the user has read.
+	LastRead(Channel) string
+	SetLastRead(Channel, string)
+
+	// Is the message history a window in the past, with newer messages that haven't been fetched?
+	HasNewerMessageHistory() bool
+	SetHasNewerMessageHistory(bool)
 
 	UserById(string) (*User, error)
 
+	// Fetch every user on the team, or return the users that were last fetched.
+	FetchUsers() ([]User, error)
+	Users() []User
+
+	// Open a direct message with one user, or a group direct message with more than one, creating
+	// it if it doesn't exist yet.
+	OpenConversation(users []*User) (*Channel, error)
+
 	UserOnline(user *User) bool
 	SetUserOnline(user *User, status bool)
 
@@ -58,8 +92,18 @@ type Connection interface {
 	// Upload a file into a given channel
 	PostBinary(title string, filename string, content []byte) error
 
+	// Upload a file, streaming its content as it's sent.
+	PostFile(upload FileUpload) error
+
+	// Download the contents of a file that was posted in a message. Also returns the size of the
+	// file in bytes, or -1 if it isn't known.
+	FetchFile(file *File) (io.ReadCloser, int64, error)
+
 	// Manage which users are typing.
 	TypingUsers() *TypingUsers
+
+	// If the connection is being rate limited, when will it be able to make requests again?
+	ThrottledUntil() time.Time
 }
 
 // Events are emitted when data comes in from a connection
@@ -95,6 +139,15 @@ type User struct {
 	Email    string `json:"email"`
 	Skype    string `json:"skype"`
 	Phone    string `json:"phone"`
+
+	Title       string `json:"title"`
+	StatusEmoji string `json:"status_emoji"`
+
+	// The user's timezone, ie "America/New_York" and "Eastern Daylight Time", and how many seconds
+	// it is ahead of UTC.
+	Timezone       string `json:"timezone"`
+	TimezoneLabel  string `json:"timezone_label"`
+	TimezoneOffset int    `json:"timezone_offset"`
 }
 
 // A Team is a collection of channels.
@@ -120,7 +173,35 @@ type Channel struct {
 	Created    int         `json:"created"`
 	IsMember   bool        `json:"is_member"`
 	IsArchived bool        `json:"is_archived"`
+	IsPrivate  bool        `json:"is_private"`
+	IsShared   bool        `json:"is_shared"` // Shared with another team
 	SubType    ChannelType `json:"subtype"`
+
+	Topic       string `json:"topic"`
+	Purpose     string `json:"purpose"`
+	MemberCount int    `json:"member_count"`
+	// The ids of every user in the channel. Only set once the channel's info has been fetched.
+	Members []string `json:"members"`
+
+	// How a direct message or group direct message is shown, ie "alice" or "alice, bob". `Name`
+	// stays the same, so it can still be used to find the channel.
+	DisplayName string `json:"display_name"`
+	// In a direct message, the id of the other user.
+	DirectMessageUser string `json:"direct_message_user"`
+}
+
+// How the channel is shown to the user: `#general` for a channel, `@alice` for a direct message,
+// and `alice, bob` for a group direct message.
+func (c Channel) Label() string {
+	if len(c.DisplayName) > 0 {
+		switch c.SubType {
+		case TYPE_DIRECT_MESSAGE:
+			return "@" + c.DisplayName
+		case TYPE_GROUP_DIRECT_MESSAGE:
+			return c.DisplayName
+		}
+	}
+	return "#" + c.Name
 }
 
 // A Reaction is an optional subcollection of a message.
@@ -134,9 +215,32 @@ type File struct {
 	Id         string `json:"id"`
 	Name       string `json:"name"`
 	Filetype   string `json:"type"`
+	Mimetype   string `json:"mimetype"`
+	Size       int    `json:"size"` // In bytes
 	User       *User  `json:"user"`
 	PrivateUrl string `json:"url_private"`
 	Permalink  string `json:"permalink"`
+
+	// The text in a snippet (ie, text posted with `PostText`). Empty for other files.
+	Content string `json:"content"`
+}
+
+// A file to upload with `PostFile`.
+type FileUpload struct {
+	Filename       string
+	Filetype       string // Optional, ie `go` or `python` to highlight a snippet
+	Title          string
+	InitialComment string   // A message to post along with the file
+	Channel        *Channel // Which channel to upload into. Defaults to the selected channel.
+
+	Content io.Reader
+	Size    int64 // In bytes, or -1 if the size isn't known
+
+	// Called as the content is sent, with the number of bytes sent so far.
+	Progress func(sent int64)
+
+	// Cancel the upload by cancelling the context.
+	Context context.Context
 }
 
 // A Message is a blob of text or media sent by a User within a Channel.
@@ -148,6 +252,10 @@ type Message struct {
 	Timestamp   int           `json:"timestamp"` // This value is in seconds!
 	File        *File         `json:"file,omitempty"`
 	Attachments *[]Attachment `json:"attachments,omitempty"`
+	// Messages built with Block Kit (ie, by bots) are drawn using their blocks instead of their text.
+	Blocks      []Block       `json:"blocks,omitempty"`
+	// The bot that posted the message, if a bot posted it. Its buttons and menus are sent to it.
+	BotId       string        `json:"bot_id,omitempty"`
 	// Has a message been confirmed as existing from the server, or is it preemptive?
 	Confirmed   bool          `json:"confirmed"`
 	// Cache message tokens on the message.
@@ -160,6 +268,19 @@ type Attachment struct {
 	Body string
 	Color     string
 	Fields    []AttachmentField
+
+	// Buttons and menus in the attachment, and the id sent to the bot when one is used.
+	CallbackId string
+	Actions    []AttachmentAction
+}
+
+type AttachmentAction struct {
+	Name    string
+	Text    string
+	Type    string // "button" or "select"
+	Value   string
+	Url     string // Buttons can open a link
+	Options []BlockOption
 }
 
 type AttachmentField struct {
@@ -168,6 +289,45 @@ type AttachmentField struct {
 	Short bool
 }
 
+// A block in a message built with Block Kit (see https://api.slack.com/block-kit).
+type Block struct {
+	Type string // "section", "context", "divider", "header", "image", or "actions"
+	Id   string
+
+	Text      *BlockElement  // The text of a section or header
+	Fields    []BlockElement // The fields of a section, under its text
+	Accessory *BlockElement  // The element beside a section's text (ie, a button or image)
+	Elements  []BlockElement // The elements of a context or actions block
+
+	// Image blocks
+	Title    string
+	ImageUrl string
+	AltText  string
+}
+
+// A piece of a block: either text, an image, or an interactive element like a button or menu.
+type BlockElement struct {
+	Type string // ie, "mrkdwn", "plain_text", "image", "button", or "static_select"
+	Text string // The text of a text element, or the label of a button
+
+	// Images
+	ImageUrl string
+	AltText  string
+
+	// Interactive elements
+	ActionId    string
+	Value       string
+	Url         string // Buttons can open a link
+	Placeholder string // Shown in a menu before anything is picked
+	Options     []BlockOption
+}
+
+// One of the options in a menu.
+type BlockOption struct {
+	Text  string
+	Value string
+}
+
 type ConnectionStatus int
 
 const (
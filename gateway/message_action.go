@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"strings"
+)
+
+// Something that can be done with an interactive element in a message: pressing a button, or
+// picking an option in a menu.
+type MessageAction struct {
+	Label string // What the action is shown as, ie `Approve` or `Environment: prod`
+
+	Type     string // The type of element, ie "button" or "static_select"
+	ActionId string // For attachments, the action's name
+	Value    string // The button's value, or the value of the option picked
+	Text     string // The button's label, or the label of the option picked
+	Url      string // Buttons can open a link
+
+	// Elements in a Block Kit block are identified by the block they're in.
+	BlockId string
+
+	// Actions in a legacy attachment are identified by the attachment (starting at 1) and its
+	// callback id.
+	AttachmentIndex int
+	CallbackId      string
+}
+
+// Every interactive element in a message, with an action for each option in a menu.
+func (m Message) Actions() []MessageAction {
+	var actions []MessageAction
+
+	for _, block := range m.Blocks {
+		elements := block.Elements
+		if block.Type == "section" {
+			elements = nil
+			if block.Accessory != nil {
+				elements = []BlockElement{*block.Accessory}
+			}
+		} else if block.Type != "actions" {
+			continue
+		}
+
+		for _, element := range elements {
+			action := MessageAction{Type: element.Type, ActionId: element.ActionId, BlockId: block.Id, Url: element.Url}
+			if element.Type == "button" {
+				action.Label, action.Text, action.Value = element.Text, element.Text, element.Value
+				actions = append(actions, action)
+			} else if strings.HasSuffix(element.Type, "_select") || element.Type == "overflow" {
+				actions = append(actions, optionActions(action, element.Placeholder, element.Options)...)
+			}
+		}
+	}
+
+	if m.Attachments != nil {
+		for index, attachment := range *m.Attachments {
+			for _, attachmentAction := range attachment.Actions {
+				action := MessageAction{
+					Type:            attachmentAction.Type,
+					ActionId:        attachmentAction.Name,
+					Url:             attachmentAction.Url,
+					AttachmentIndex: index + 1,
+					CallbackId:      attachment.CallbackId,
+				}
+				if attachmentAction.Type == "select" {
+					actions = append(actions, optionActions(action, attachmentAction.Text, attachmentAction.Options)...)
+				} else {
+					action.Label, action.Text, action.Value = attachmentAction.Text, attachmentAction.Text, attachmentAction.Value
+					actions = append(actions, action)
+				}
+			}
+		}
+	}
+
+	return actions
+}
+
+// A menu is listed as one action per option, like `Environment: prod`.
+func optionActions(menu MessageAction, placeholder string, options []BlockOption) []MessageAction {
+	var actions []MessageAction
+	for _, option := range options {
+		action := menu
+		action.Text, action.Value = option.Text, option.Value
+		if len(placeholder) > 0 {
+			action.Label = placeholder + ": " + option.Text
+		} else {
+			action.Label = option.Text
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
@@ -0,0 +1,38 @@
+package gateway_test
+
+import (
+	. "github.com/1egoman/slick/gateway"
+	"reflect"
+	"testing"
+)
+
+func TestMessageActions(t *testing.T) {
+	message := Message{
+		Blocks: []Block{
+			{Type: "section", Id: "b1", Accessory: &BlockElement{Type: "button", ActionId: "logs", Text: "Logs", Url: "https://example.com/logs"}},
+			{Type: "context", Id: "b2", Elements: []BlockElement{{Type: "mrkdwn", Text: "by @me"}}},
+			{Type: "actions", Id: "b3", Elements: []BlockElement{
+				{Type: "button", ActionId: "approve", Text: "Approve", Value: "yes"},
+				{Type: "static_select", ActionId: "env", Placeholder: "Environment", Options: []BlockOption{
+					{Text: "Staging", Value: "staging"},
+					{Text: "Production", Value: "prod"},
+				}},
+			}},
+		},
+		Attachments: &[]Attachment{
+			{Title: "No actions"},
+			{CallbackId: "deploy-42", Actions: []AttachmentAction{{Name: "cancel", Type: "button", Text: "Cancel", Value: "no"}}},
+		},
+	}
+
+	expected := []MessageAction{
+		{Label: "Logs", Type: "button", ActionId: "logs", Text: "Logs", Url: "https://example.com/logs", BlockId: "b1"},
+		{Label: "Approve", Type: "button", ActionId: "approve", Text: "Approve", Value: "yes", BlockId: "b3"},
+		{Label: "Environment: Staging", Type: "static_select", ActionId: "env", Text: "Staging", Value: "staging", BlockId: "b3"},
+		{Label: "Environment: Production", Type: "static_select", ActionId: "env", Text: "Production", Value: "prod", BlockId: "b3"},
+		{Label: "Cancel", Type: "button", ActionId: "cancel", Text: "Cancel", Value: "no", AttachmentIndex: 2, CallbackId: "deploy-42"},
+	}
+	if actions := message.Actions(); !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Wrong actions:\n%+v\n!=\n%+v", actions, expected)
+	}
+}
@@ -3,6 +3,8 @@ package gateway
 import (
 	"fmt"
 	"strings"
+
+	"github.com/1egoman/slick/textwidth"
 )
 
 type PrintableMessagePartType int
@@ -19,6 +21,14 @@ const (
 	PRINTABLE_MESSAGE_NEWLINE
 	PRINTABLE_MESSAGE_FORMATTING_CODE
 	PRINTABLE_MESSAGE_FORMATTING_PREFORMATTED
+	PRINTABLE_MESSAGE_FORMATTING_STRIKETHROUGH
+	PRINTABLE_MESSAGE_BLOCKQUOTE        // The gutter at the start of each line of a quote (like > foo)
+	PRINTABLE_MESSAGE_LIST_ITEM         // The bullet or number at the start of a list item (like - foo)
+	PRINTABLE_MESSAGE_CODE_BLOCK        // A multi-line block of code (like ```foo\nbar```)
+	PRINTABLE_MESSAGE_CODE_BLOCK_BORDER // The box drawn around a code block
+	PRINTABLE_MESSAGE_BLOCK_CONTEXT     // The small print in a Block Kit context block
+	PRINTABLE_MESSAGE_BLOCK_DIVIDER     // A line across the message, between Block Kit blocks
+	PRINTABLE_MESSAGE_BLOCK_ELEMENT     // An interactive element in a Block Kit block (like a button)
 )
 
 type PrintableMessagePart struct {
@@ -27,6 +37,13 @@ type PrintableMessagePart struct {
 	Metadata map[string]interface{}
 }
 
+// A run of a part that's drawn in its own style, like a keyword in a highlighted code block. A code
+// block's spans are stored in its part's metadata, under "Spans".
+type PrintableMessageSpan struct {
+	Length int    // In bytes
+	Style  string // A serialized color, like "red::B"
+}
+
 type PrintableMessage struct {
 	parts []PrintableMessagePart
 
@@ -47,11 +64,12 @@ func (p *PrintableMessage) SetParts(parts []PrintableMessagePart) {
 	p.parts = parts
 }
 
-// Return the length of the printable message
+// Return the length of the printable message, in cells on the screen. Wide characters (like emoji
+// and CJK text) take up two cells, and combining characters (like accents) don't take up any.
 func (p *PrintableMessage) Length() int {
 	length := 0
 	for _, part := range p.parts {
-		length += len(part.Content)
+		length += textwidth.Width(part.Content)
 	}
 	return length
 }
@@ -65,11 +83,19 @@ func (p *PrintableMessage) Plain() string {
 	return total
 }
 
+// Wrap the printable message into lines that are at most `width` cells wide.
 func (p *PrintableMessage) Lines(width int) [][]PrintableMessagePart {
 	var lines [][]PrintableMessagePart
 	
 	var lineBeingAssembled []PrintableMessagePart
 	lineWidth := 0
+
+	// The blockquote gutters and list bullets at the start of the current line. When the line wraps,
+	// they're continued on the next line so that the wrapped text lines up.
+	var linePrefix []PrintableMessagePart
+	prefixWidth := 0
+	onlyPrefixInLine := true
+
 	for _, part := range p.parts {
 		// Handle newlines. If we come across a newline, add the "working" line to the lines array and
 		// create a new working line.
@@ -78,21 +104,78 @@ func (p *PrintableMessage) Lines(width int) [][]PrintableMessagePart {
 		  // Reset the current line.
 		  lineBeingAssembled = make([]PrintableMessagePart, 0)
 		  lineWidth = 0
+		  linePrefix = nil
+		  prefixWidth = 0
+		  onlyPrefixInLine = true
+		}
+
+		// Code blocks are drawn in a box, on lines of their own.
+		if part.Type == PRINTABLE_MESSAGE_CODE_BLOCK {
+			box := codeBlockLines(part, width)
+			if lineWidth > 0 {
+				lines = append(lines, lineBeingAssembled)
+			} else {
+				box[0] = append(lineBeingAssembled, box[0]...)
+			}
+			lines = append(lines, box[:len(box)-1]...)
+
+			// Anything after the code block goes on the line after the box.
+			lineBeingAssembled = box[len(box)-1]
+			lineWidth = width
+			linePrefix = nil
+			prefixWidth = 0
+			onlyPrefixInLine = false
+			continue
+		}
+
+		// Dividers are drawn as a line of their own, as wide as the message.
+		if part.Type == PRINTABLE_MESSAGE_BLOCK_DIVIDER {
+			if lineWidth > 0 {
+				lines = append(lines, lineBeingAssembled)
+			}
+			lineBeingAssembled = []PrintableMessagePart{
+				PrintableMessagePart{Type: PRINTABLE_MESSAGE_BLOCK_DIVIDER, Content: strings.Repeat("-", width)},
+			}
+			lineWidth = width
+			linePrefix = nil
+			prefixWidth = 0
+			onlyPrefixInLine = false
+			continue
+		}
+
+		// Keep track of the gutters and bullets that start the line.
+		if onlyPrefixInLine && (part.Type == PRINTABLE_MESSAGE_BLOCKQUOTE || part.Type == PRINTABLE_MESSAGE_LIST_ITEM) {
+			linePrefix = append(linePrefix, part)
+			prefixWidth += textwidth.Width(part.Content)
+		} else if part.Type != PRINTABLE_MESSAGE_NEWLINE {
+			onlyPrefixInLine = false
 		}
 
 		// Is the current part have to wrap to fit on the current width
-		if lineWidth + len(part.Content) > width {
+		if lineWidth + textwidth.Width(part.Content) > width {
 			widthRemainingInLine := width - lineWidth
+			lineIsEmpty := lineWidth <= prefixWidth
 
 			content := part.Content
-			for len(content) > widthRemainingInLine {
+			for len(content) > 0 && textwidth.Width(content) > widthRemainingInLine {
 				// The goal is to split the part in two - the first bit goes on the current line, the second bit is saved for the next iteration.
 				
 				// Attempt to split the part at a space, if possible. If not, just split in the middle of a word.
 				// (Look for the last space in the "first bit" of the string, and split at that marker)
-				amountOfLineUsed := strings.LastIndex(content[:widthRemainingInLine], " ")+1
+				// (Measure in cells, so that a wide character or an accent is never split in half)
+				fitsInLine := textwidth.Truncate(content, widthRemainingInLine)
+				amountOfLineUsed := strings.LastIndex(fitsInLine, " ")+1
 				if amountOfLineUsed <= 0 {
-					amountOfLineUsed = widthRemainingInLine
+					amountOfLineUsed = len(fitsInLine)
+				}
+
+				// If not even one character fits on an empty line (ie, a wide character on a one cell
+				// wide line), put it on the line anyway so that wrapping always makes progress.
+				if amountOfLineUsed == 0 && lineIsEmpty {
+					amountOfLineUsed = textwidth.Clusters(content)[0].Bytes
+					if amountOfLineUsed == len(content) {
+						break
+					}
 				}
 				
 				// Append the first bit to the current line
@@ -103,7 +186,8 @@ func (p *PrintableMessage) Lines(width int) [][]PrintableMessagePart {
 			
 				// Append the current line to the lines collection.
 				lines = append(lines, lineBeingAssembled)
-				lineBeingAssembled = make([]PrintableMessagePart, 0)
+				lineBeingAssembled = continueLinePrefix(linePrefix)
+				lineIsEmpty = true
 				
 				// Remove the chunk already used from the message content.
 				content = content[amountOfLineUsed:]
@@ -114,15 +198,17 @@ func (p *PrintableMessage) Lines(width int) [][]PrintableMessagePart {
 				// |                  | <= Full width
 				// rown fox jumps over 
 				// the lazy dog
-				widthRemainingInLine = width
+				// (Other than the space taken up by a blockquote gutter or list bullet.)
+				widthRemainingInLine = width - prefixWidth
 			}
 			
 			// Finally, append the final bit that was left unhandled.
 			finalBit := PrintableMessagePart{Type: part.Type, Content: content, Metadata: part.Metadata}
 			lineBeingAssembled = append(lineBeingAssembled, finalBit)
+			lineWidth = width - widthRemainingInLine + textwidth.Width(content)
 		} else {
 			// Add the part to the end of the line if it fits on the line.
-			lineWidth += len(part.Content)
+			lineWidth += textwidth.Width(part.Content)
 			lineBeingAssembled = append(lineBeingAssembled, part)
 		}
 	}
@@ -133,6 +219,130 @@ func (p *PrintableMessage) Lines(width int) [][]PrintableMessagePart {
 	return lines
 }
 
+// The start of a line that a line starting with `prefix` wrapped onto. Blockquote gutters are drawn
+// again, and list bullets are replaced with space so that the wrapped text lines up with the text
+// after the bullet.
+func continueLinePrefix(prefix []PrintableMessagePart) []PrintableMessagePart {
+	continued := make([]PrintableMessagePart, 0, len(prefix))
+	for _, part := range prefix {
+		if part.Type == PRINTABLE_MESSAGE_LIST_ITEM {
+			part = PrintableMessagePart{
+				Type:    PRINTABLE_MESSAGE_PLAIN_TEXT,
+				Content: strings.Repeat(" ", textwidth.Width(part.Content)),
+			}
+		}
+		continued = append(continued, part)
+	}
+	return continued
+}
+
+// Draw a code block as a box, as wide as its longest line (or `width`, if that's narrower):
+// +-----------------+
+// | func main() {   |
+// |   fmt.Println() |
+// | }               |
+// +-----------------+
+// Lines that don't fit are wrapped wherever they hit the edge of the box, since spacing matters in
+// code.
+func codeBlockLines(part PrintableMessagePart, width int) [][]PrintableMessagePart {
+	codeLines := strings.Split(part.Content, "\n")
+
+	innerWidth := 0
+	for _, line := range codeLines {
+		if lineWidth := textwidth.Width(line); lineWidth > innerWidth {
+			innerWidth = lineWidth
+		}
+	}
+	if innerWidth > width-4 {
+		innerWidth = width - 4
+	}
+	if innerWidth < 1 {
+		innerWidth = 1
+	}
+
+	border := func(content string) PrintableMessagePart {
+		return PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK_BORDER, Content: content}
+	}
+	edge := border("+" + strings.Repeat("-", innerWidth+2) + "+")
+
+	spans, _ := part.Metadata["Spans"].([]PrintableMessageSpan)
+
+	lines := [][]PrintableMessagePart{{edge}}
+	offset := 0 // How far into the code block the current chunk starts, in bytes.
+	for _, line := range codeLines {
+		for {
+			chunk := textwidth.Truncate(line, innerWidth)
+			if len(chunk) == 0 && len(line) > 0 {
+				chunk = line[:textwidth.Clusters(line)[0].Bytes]
+			}
+			line = line[len(chunk):]
+
+			padding := ""
+			if chunkWidth := textwidth.Width(chunk); chunkWidth < innerWidth {
+				padding = strings.Repeat(" ", innerWidth-chunkWidth)
+			}
+
+			codeLine := []PrintableMessagePart{border("| ")}
+			if spans == nil {
+				codeLine = append(codeLine, PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK, Content: chunk + padding, Metadata: part.Metadata})
+			} else {
+				// Highlighted code is split up so each span can be drawn in its own style.
+				codeLine = append(codeLine, splitBySpans(chunk, offset, spans)...)
+				if len(padding) > 0 {
+					codeLine = append(codeLine, PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK, Content: padding})
+				}
+			}
+			lines = append(lines, append(codeLine, border(" |")))
+
+			offset += len(chunk)
+			if len(line) == 0 {
+				break
+			}
+		}
+		offset += 1 // The newline at the end of the line
+	}
+	return append(lines, []PrintableMessagePart{edge})
+}
+
+// Split a chunk of a code block that starts `offset` bytes into the block into a part for each span
+// it overlaps. Each part's style is stored in its metadata, under "Style".
+func splitBySpans(chunk string, offset int, spans []PrintableMessageSpan) []PrintableMessagePart {
+	var parts []PrintableMessagePart
+	spanStart := 0
+	for _, span := range spans {
+		spanEnd := spanStart + span.Length
+		start, end := spanStart-offset, spanEnd-offset
+		spanStart = spanEnd
+		if end <= 0 {
+			continue
+		} else if start >= len(chunk) {
+			break
+		}
+
+		if start < 0 {
+			start = 0
+		}
+		if end > len(chunk) {
+			end = len(chunk)
+		}
+		parts = append(parts, PrintableMessagePart{
+			Type:     PRINTABLE_MESSAGE_CODE_BLOCK,
+			Content:  chunk[start:end],
+			Metadata: map[string]interface{}{"Style": span.Style},
+		})
+	}
+
+	// Anything after the last span isn't highlighted.
+	if spanStart-offset < len(chunk) {
+		start := spanStart - offset
+		if start < 0 {
+			start = 0
+		}
+		parts = append(parts, PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK, Content: chunk[start:]})
+	}
+	return parts
+}
+
 func SprintLines(width int, lines [][]PrintableMessagePart) string {
 	total := ""
 
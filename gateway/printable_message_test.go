@@ -14,6 +14,17 @@ func channel(text string) PrintableMessagePart {
 	return PrintableMessagePart{Type: PRINTABLE_MESSAGE_AT_MENTION_GROUP, Content: text}
 }
 var newline PrintableMessagePart = PrintableMessagePart{Type: PRINTABLE_MESSAGE_NEWLINE}
+var quote PrintableMessagePart = PrintableMessagePart{Type: PRINTABLE_MESSAGE_BLOCKQUOTE, Content: "| "}
+var bullet PrintableMessagePart = PrintableMessagePart{Type: PRINTABLE_MESSAGE_LIST_ITEM, Content: "• "}
+func code(text string) PrintableMessagePart {
+	return PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK, Content: text}
+}
+func styledCode(text string, style string) PrintableMessagePart {
+	return PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK, Content: text, Metadata: map[string]interface{}{"Style": style}}
+}
+func codeBorder(text string) PrintableMessagePart {
+	return PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK_BORDER, Content: text}
+}
 
 func TestPrintableMessageLines(t *testing.T) {
 	for _, test := range []struct {
@@ -94,6 +105,122 @@ func TestPrintableMessageLines(t *testing.T) {
 				[]PrintableMessagePart{newline, channel("quux hello world")},
 			},
 		},
+		// Wide characters take up two cells, so fewer of them fit on a line.
+		{
+			MessageParts: []PrintableMessagePart{plainText("日本語のテキスト")},
+			Width:        7,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{plainText("日本語")},
+				[]PrintableMessagePart{plainText("のテキ")},
+				[]PrintableMessagePart{plainText("スト")},
+			},
+		},
+		// Emoji and accents should never be split in the middle.
+		{
+			MessageParts: []PrintableMessagePart{plainText("café 😀😀")},
+			Width:        6,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{plainText("café ")},
+				[]PrintableMessagePart{plainText("😀😀")},
+			},
+		},
+		// A part after a wrapped part should be measured from where the wrapped part ended.
+		{
+			MessageParts: []PrintableMessagePart{plainText("foo bar"), plainText("日本")},
+			Width:        5,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{plainText("foo ")},
+				[]PrintableMessagePart{plainText("bar"), plainText("日")},
+				[]PrintableMessagePart{plainText("本")},
+			},
+		},
+		// A wide character that can't fit on a line at all still gets a line to itself.
+		{
+			MessageParts: []PrintableMessagePart{plainText("日本")},
+			Width:        1,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{plainText("日")},
+				[]PrintableMessagePart{plainText("本")},
+			},
+		},
+		// A quote's gutter is drawn again on each line it wraps onto.
+		{
+			MessageParts: []PrintableMessagePart{quote, plainText("foo bar baz")},
+			Width:        10,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{quote, plainText("foo bar ")},
+				[]PrintableMessagePart{quote, plainText("baz")},
+			},
+		},
+		// Text in a list item that wraps lines up with the text after the bullet.
+		{
+			MessageParts: []PrintableMessagePart{plainText("list:"), newline, bullet, plainText("foo bar baz")},
+			Width:        10,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{plainText("list:")},
+				[]PrintableMessagePart{newline, bullet, plainText("foo bar ")},
+				[]PrintableMessagePart{plainText("  "), plainText("baz")},
+			},
+		},
+		// Code blocks are drawn in a box on their own lines, and long lines of code are wrapped at the
+		// edge of the box.
+		{
+			MessageParts: []PrintableMessagePart{
+				plainText("code:"),
+				PrintableMessagePart{Type: PRINTABLE_MESSAGE_CODE_BLOCK, Content: "if x {\n  return verylongname\n}"},
+				plainText("done"),
+			},
+			Width: 14,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{plainText("code:")},
+				[]PrintableMessagePart{codeBorder("+------------+")},
+				[]PrintableMessagePart{codeBorder("| "), code("if x {    "), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("| "), code("  return v"), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("| "), code("erylongnam"), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("| "), code("e         "), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("| "), code("}         "), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("+------------+")},
+				[]PrintableMessagePart{plainText("done")},
+			},
+		},
+		// Dividers are a line of their own, as wide as the message.
+		{
+			MessageParts: []PrintableMessagePart{
+				plainText("above"),
+				PrintableMessagePart{Type: PRINTABLE_MESSAGE_BLOCK_DIVIDER},
+				newline,
+				plainText("below"),
+			},
+			Width: 8,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{plainText("above")},
+				[]PrintableMessagePart{PrintableMessagePart{Type: PRINTABLE_MESSAGE_BLOCK_DIVIDER, Content: "--------"}},
+				[]PrintableMessagePart{newline, plainText("below")},
+			},
+		},
+		// Highlighted code blocks are split up by span, including spans that are wrapped or that
+		// cross a newline.
+		{
+			MessageParts: []PrintableMessagePart{
+				PrintableMessagePart{
+					Type:    PRINTABLE_MESSAGE_CODE_BLOCK,
+					Content: "x = 'abcdefgh'\ny",
+					Metadata: map[string]interface{}{"Spans": []PrintableMessageSpan{
+						{Length: 4, Style: "white::"},
+						{Length: 10, Style: "red::"},
+						{Length: 2, Style: "white::"},
+					}},
+				},
+			},
+			Width: 14,
+			WrappedResult: [][]PrintableMessagePart{
+				[]PrintableMessagePart{codeBorder("+------------+")},
+				[]PrintableMessagePart{codeBorder("| "), styledCode("x = ", "white::"), styledCode("'abcde", "red::"), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("| "), styledCode("fgh'", "red::"), code("      "), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("| "), styledCode("y", "white::"), code("         "), codeBorder(" |")},
+				[]PrintableMessagePart{codeBorder("+------------+")},
+			},
+		},
 	} {
 		fmt.Println("")
 		pm := NewPrintableMessage(test.MessageParts)
@@ -104,3 +231,10 @@ func TestPrintableMessageLines(t *testing.T) {
 		}
 	}
 }
+
+func TestPrintableMessageLength(t *testing.T) {
+	pm := NewPrintableMessage([]PrintableMessagePart{plainText("José "), channel("日本"), plainText(" 😀")})
+	if length := pm.Length(); length != 12 {
+		t.Errorf("Expected length to be 12 cells, got %d", length)
+	}
+}
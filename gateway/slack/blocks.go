@@ -0,0 +1,120 @@
+package gatewaySlack
+
+import (
+	"encoding/json"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// The blocks that can be drawn. Other blocks (like the `rich_text` block slack adds to every message
+// typed by a person) are dropped, so that those messages are drawn using their text instead.
+var supportedBlockTypes = map[string]bool{
+	"section": true,
+	"context": true,
+	"divider": true,
+	"header":  true,
+	"image":   true,
+	"actions": true,
+}
+
+// Text in a block is sometimes a string (ie, in a `mrkdwn` element) and sometimes a text object
+// (ie, the label of a button, `{"type": "plain_text", "text": "Approve"}`). Either way, keep the text.
+type rawBlockText string
+
+func (t *rawBlockText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = rawBlockText(text)
+		return nil
+	}
+
+	// Anything else (ie, in a block that's going to be dropped) is ignored, so that one odd block
+	// doesn't keep the whole message from being parsed.
+	var object struct {
+		Text string `json:"text"`
+	}
+	json.Unmarshal(data, &object)
+	*t = rawBlockText(object.Text)
+	return nil
+}
+
+type rawBlockElement struct {
+	Type        string       `json:"type"`
+	Text        rawBlockText `json:"text"`
+	ImageUrl    string       `json:"image_url"`
+	AltText     string       `json:"alt_text"`
+	ActionId    string       `json:"action_id"`
+	Value       string       `json:"value"`
+	Url         string       `json:"url"`
+	Placeholder rawBlockText `json:"placeholder"`
+	Options     []struct {
+		Text  rawBlockText `json:"text"`
+		Value string       `json:"value"`
+	} `json:"options"`
+}
+
+type rawBlock struct {
+	Type      string            `json:"type"`
+	BlockId   string            `json:"block_id"`
+	Text      *rawBlockElement  `json:"text"`
+	Fields    []rawBlockElement `json:"fields"`
+	Accessory *rawBlockElement  `json:"accessory"`
+	Elements  []rawBlockElement `json:"elements"`
+	Title     rawBlockText      `json:"title"`
+	ImageUrl  string            `json:"image_url"`
+	AltText   string            `json:"alt_text"`
+}
+
+func parseBlockElement(raw rawBlockElement) gateway.BlockElement {
+	var options []gateway.BlockOption
+	for _, option := range raw.Options {
+		options = append(options, gateway.BlockOption{Text: string(option.Text), Value: option.Value})
+	}
+
+	return gateway.BlockElement{
+		Type:        raw.Type,
+		Text:        string(raw.Text),
+		ImageUrl:    raw.ImageUrl,
+		AltText:     raw.AltText,
+		ActionId:    raw.ActionId,
+		Value:       raw.Value,
+		Url:         raw.Url,
+		Placeholder: string(raw.Placeholder),
+		Options:     options,
+	}
+}
+
+// Convert the blocks in a message from slack into blocks that can be drawn.
+func parseBlocks(rawBlocks []rawBlock) []gateway.Block {
+	var blocks []gateway.Block
+	for _, raw := range rawBlocks {
+		if !supportedBlockTypes[raw.Type] {
+			continue
+		}
+
+		block := gateway.Block{
+			Type:     raw.Type,
+			Id:       raw.BlockId,
+			Title:    string(raw.Title),
+			ImageUrl: raw.ImageUrl,
+			AltText:  raw.AltText,
+		}
+		if raw.Text != nil {
+			text := parseBlockElement(*raw.Text)
+			block.Text = &text
+		}
+		if raw.Accessory != nil {
+			accessory := parseBlockElement(*raw.Accessory)
+			block.Accessory = &accessory
+		}
+		for _, field := range raw.Fields {
+			block.Fields = append(block.Fields, parseBlockElement(field))
+		}
+		for _, element := range raw.Elements {
+			block.Elements = append(block.Elements, parseBlockElement(element))
+		}
+
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
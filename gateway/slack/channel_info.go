@@ -0,0 +1,132 @@
+package gatewaySlack
+
+import (
+	"errors"
+	"log"
+	"net/url"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// Fetch the topic, purpose, and members of a channel.
+func (c *SlackConnection) FetchChannelInfo(inChannel *gateway.Channel) (*gateway.Channel, error) {
+	if inChannel == nil {
+		return nil, errors.New("Cannot fetch info for nil channel!")
+	}
+
+	log.Printf("Fetching info for channel %s", inChannel.Name)
+
+	var infoBuffer struct {
+		Channel struct {
+			NumMembers int `json:"num_members"`
+			Topic      struct {
+				Value string `json:"value"`
+			} `json:"topic"`
+			Purpose struct {
+				Value string `json:"value"`
+			} `json:"purpose"`
+		} `json:"channel"`
+	}
+	infoUrl := "https://slack.com/api/conversations.info?token=" + c.token
+	infoUrl += "&channel=" + inChannel.Id
+	infoUrl += "&include_num_members=true"
+	if err := c.get(infoUrl, &infoBuffer); err != nil {
+		return nil, err
+	}
+
+	// Members are returned a page at a time.
+	var members []string
+	cursor := ""
+	for {
+		membersUrl := "https://slack.com/api/conversations.members?token=" + c.token
+		membersUrl += "&channel=" + inChannel.Id
+		membersUrl += "&limit=" + pageSize
+		if len(cursor) > 0 {
+			membersUrl += "&cursor=" + cursor
+		}
+
+		var membersBuffer struct {
+			Members          []string `json:"members"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := c.get(membersUrl, &membersBuffer); err != nil {
+			return nil, err
+		}
+		members = append(members, membersBuffer.Members...)
+
+		cursor = membersBuffer.ResponseMetadata.NextCursor
+		if len(cursor) == 0 {
+			break
+		}
+	}
+
+	channel := *inChannel
+	channel.Topic = infoBuffer.Channel.Topic.Value
+	channel.Purpose = infoBuffer.Channel.Purpose.Value
+	channel.MemberCount = infoBuffer.Channel.NumMembers
+	if channel.MemberCount < len(members) {
+		channel.MemberCount = len(members)
+	}
+	channel.Members = members
+
+	c.updateChannel(channel)
+	return &channel, nil
+}
+
+// Set the topic of a channel.
+func (c *SlackConnection) SetChannelTopic(inChannel *gateway.Channel, topic string) (*gateway.Channel, error) {
+	if inChannel == nil {
+		return nil, errors.New("Cannot set the topic of nil channel!")
+	}
+
+	log.Printf("Setting topic of channel %s", inChannel.Name)
+
+	requestUrl := "https://slack.com/api/conversations.setTopic?token=" + c.token
+	requestUrl += "&channel=" + inChannel.Id
+	requestUrl += "&topic=" + url.QueryEscape(topic)
+	if err := c.get(requestUrl, nil); err != nil {
+		return nil, err
+	}
+
+	channel := *inChannel
+	channel.Topic = topic
+	c.updateChannel(channel)
+	return &channel, nil
+}
+
+// Set the purpose of a channel.
+func (c *SlackConnection) SetChannelPurpose(inChannel *gateway.Channel, purpose string) (*gateway.Channel, error) {
+	if inChannel == nil {
+		return nil, errors.New("Cannot set the purpose of nil channel!")
+	}
+
+	log.Printf("Setting purpose of channel %s", inChannel.Name)
+
+	requestUrl := "https://slack.com/api/conversations.setPurpose?token=" + c.token
+	requestUrl += "&channel=" + inChannel.Id
+	requestUrl += "&purpose=" + url.QueryEscape(purpose)
+	if err := c.get(requestUrl, nil); err != nil {
+		return nil, err
+	}
+
+	channel := *inChannel
+	channel.Purpose = purpose
+	c.updateChannel(channel)
+	return &channel, nil
+}
+
+// Replace a channel in the locally stored channels collection (and the selected channel) with a
+// newer copy of it.
+func (c *SlackConnection) updateChannel(channel gateway.Channel) {
+	if c.selectedChannel != nil && c.selectedChannel.Id == channel.Id {
+		selected := channel
+		c.selectedChannel = &selected
+	}
+	for index, ch := range c.channels {
+		if ch.Id == channel.Id {
+			c.channels[index] = channel
+		}
+	}
+}
@@ -2,8 +2,6 @@ package gatewaySlack
 
 import (
 	"encoding/json"
-	"errors"
-	"io/ioutil"
 	"log"
 	"time"
 
@@ -180,16 +178,13 @@ func (c *SlackConnection) Connect() error {
 	return nil
 }
 
+type rtmLastRead struct {
+	Id       string `json:"id"`
+	LastRead string `json:"last_read"`
+}
+
 func (c *SlackConnection) requestConnectionUrl() error {
 	// Make request to slack's api to get websocket credentials
-	resp, err := httpClient.Get("https://slack.com/api/rtm.start?token=" + c.token)
-
-	if err != nil {
-		return err
-	}
-
-	// Decode json body.
-	body, _ := ioutil.ReadAll(resp.Body)
 	var connectionBuffer struct {
 		Ok    bool         `json:"ok"`
 		Url   string       `json:"url"`
@@ -199,11 +194,15 @@ func (c *SlackConnection) requestConnectionUrl() error {
 			Id       string `json:"id"`
 			Presence string `json:"presence"`
 		} `json:"users"`
+
+		// Each type of channel includes the timestamp of the last message the user read in it.
+		Channels []rtmLastRead `json:"channels"`
+		Groups   []rtmLastRead `json:"groups"`
+		Ims      []rtmLastRead `json:"ims"`
 	}
-	err = json.Unmarshal(body, &connectionBuffer)
+	err := c.get("https://slack.com/api/rtm.start?token="+c.token, &connectionBuffer)
 	if err != nil {
-		log.Println("Slack response: " + string(body))
-		return errors.New("Slack connection error: " + string(body))
+		return err
 	}
 
 	// Add response data to struct
@@ -211,6 +210,15 @@ func (c *SlackConnection) requestConnectionUrl() error {
 	c.self = connectionBuffer.Self
 	c.team = connectionBuffer.Team
 
+	// Add where the user last read up to in each channel
+	for _, list := range [][]rtmLastRead{connectionBuffer.Channels, connectionBuffer.Groups, connectionBuffer.Ims} {
+		for _, channel := range list {
+			if len(channel.LastRead) > 0 {
+				c.SetLastRead(gateway.Channel{Id: channel.Id}, channel.LastRead)
+			}
+		}
+	}
+
 	// Add online statuses for each user
 	for _, user := range connectionBuffer.Users {
 		if user.Presence == "away" {
@@ -0,0 +1,98 @@
+package gatewaySlack
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// The name given to a direct message with another user.
+func (c *SlackConnection) directMessageName(other *gateway.User) string {
+	return fmt.Sprintf("im-%s-%s", c.Self().Name, other.Name)
+}
+
+var groupDirectMessageNameRegex = regexp.MustCompile(`^mpdm-(.*)-\d+$`)
+
+// Slack names group direct messages like `mpdm-me--alice--bob-1`. Show them as a list of everyone
+// else in the conversation, like `alice, bob`.
+func (c *SlackConnection) groupDirectMessageDisplayName(name string) string {
+	match := groupDirectMessageNameRegex.FindStringSubmatch(name)
+	if match == nil {
+		return name
+	}
+
+	var others []string
+	for _, member := range strings.Split(match[1], "--") {
+		if member != c.Self().Name {
+			others = append(others, member)
+		}
+	}
+	if len(others) == 0 {
+		return name
+	}
+	return strings.Join(others, ", ")
+}
+
+// Open a conversation with one or more users, creating it if it doesn't exist yet. A conversation
+// with one user is a direct message, and with more than one user it's a group direct message. If
+// the conversation is new, it's added to the channels in the connection.
+func (c *SlackConnection) OpenConversation(users []*gateway.User) (*gateway.Channel, error) {
+	if len(users) == 0 {
+		return nil, errors.New("Cannot open a conversation without any users!")
+	}
+
+	var ids, names []string
+	for _, user := range users {
+		ids = append(ids, user.Id)
+		names = append(names, user.Name)
+	}
+	log.Printf("Opening conversation with %s", strings.Join(names, ", "))
+
+	var conversationBuffer struct {
+		Channel struct {
+			Id      string `json:"id"`
+			Name    string `json:"name"`
+			Created int    `json:"created"`
+		} `json:"channel"`
+	}
+	url := "https://slack.com/api/conversations.open?token=" + c.token
+	url += "&users=" + strings.Join(ids, ",")
+	url += "&return_im=true"
+	if err := c.get(url, &conversationBuffer); err != nil {
+		return nil, err
+	}
+
+	for _, channel := range c.channels {
+		if channel.Id == conversationBuffer.Channel.Id {
+			return &channel, nil
+		}
+	}
+
+	channel := gateway.Channel{
+		Id:        conversationBuffer.Channel.Id,
+		SubType:   gateway.TYPE_DIRECT_MESSAGE,
+		Name:      c.directMessageName(users[0]),
+		Creator:   c.Self(),
+		Created:   conversationBuffer.Channel.Created,
+		IsMember:  true,
+		IsPrivate: true,
+
+		DisplayName:       users[0].Name,
+		DirectMessageUser: users[0].Id,
+	}
+	if len(users) > 1 {
+		channel.SubType = gateway.TYPE_GROUP_DIRECT_MESSAGE
+		channel.Name = conversationBuffer.Channel.Name
+		if len(channel.Name) == 0 {
+			channel.Name = "mpdm-" + c.Self().Name + "--" + strings.Join(names, "--") + "-1"
+		}
+		channel.DisplayName = strings.Join(names, ", ")
+		channel.DirectMessageUser = ""
+	}
+	c.channels = append(c.channels, channel)
+	return &channel, nil
+}
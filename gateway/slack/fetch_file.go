@@ -0,0 +1,37 @@
+package gatewaySlack
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"net/http"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// Files posted to slack are only visible to members of the team, so they have to be downloaded with
+// the connection's token. The caller is responsible for closing the returned body.
+func (c *SlackConnection) FetchFile(file *gateway.File) (io.ReadCloser, int64, error) {
+	if file == nil || len(file.PrivateUrl) == 0 {
+		return nil, 0, fmt.Errorf("File doesn't have a url to download it from.")
+	}
+	log.Printf("* Fetching file %s", file.PrivateUrl)
+
+	req, err := http.NewRequest("GET", file.PrivateUrl, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("Couldn't download %s: slack responded with %s", file.Name, resp.Status)
+	}
+
+	return resp.Body, resp.ContentLength, nil
+}
@@ -0,0 +1,93 @@
+package gatewaySlack
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"net/http"
+	"net/url"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// Press a button or pick an option in a menu. These are sent the same way slack's own clients send
+// them: Block Kit elements to `blocks.actions`, and actions in legacy attachments to
+// `chat.attachmentAction`. Slack passes the action on to the bot that posted the message, which
+// usually responds by updating the message (see the `message_changed` event).
+func (c *SlackConnection) SendMessageAction(message gateway.Message, action gateway.MessageAction) error {
+	if c.selectedChannel == nil {
+		return errors.New("No channel selected!")
+	}
+	log.Printf("* Sending action %s (%s) to message %s", action.ActionId, action.Label, message.Hash)
+
+	form := url.Values{}
+	form.Set("service_id", message.BotId)
+
+	var method string
+	if action.AttachmentIndex > 0 {
+		method = "chat.attachmentAction"
+
+		attachmentAction := map[string]interface{}{"name": action.ActionId, "type": action.Type}
+		if action.Type == "select" {
+			attachmentAction["selected_options"] = []map[string]string{{"value": action.Value}}
+		} else {
+			attachmentAction["value"] = action.Value
+		}
+		payload, err := json.Marshal(map[string]interface{}{
+			"actions":       []interface{}{attachmentAction},
+			"attachment_id": strconv.Itoa(action.AttachmentIndex),
+			"callback_id":   action.CallbackId,
+			"channel_id":    c.selectedChannel.Id,
+			"message_ts":    message.Hash,
+		})
+		if err != nil {
+			return err
+		}
+		form.Set("payload", string(payload))
+	} else {
+		method = "blocks.actions"
+
+		text := map[string]string{"type": "plain_text", "text": action.Text}
+		blockAction := map[string]interface{}{
+			"action_id": action.ActionId,
+			"block_id":  action.BlockId,
+			"type":      action.Type,
+		}
+		if action.Type == "button" {
+			blockAction["text"] = text
+			blockAction["value"] = action.Value
+		} else {
+			blockAction["selected_option"] = map[string]interface{}{"text": text, "value": action.Value}
+		}
+		actions, err := json.Marshal([]interface{}{blockAction})
+		if err != nil {
+			return err
+		}
+		container, err := json.Marshal(map[string]interface{}{
+			"type":         "message",
+			"message_ts":   message.Hash,
+			"channel_id":   c.selectedChannel.Id,
+			"is_ephemeral": false,
+		})
+		if err != nil {
+			return err
+		}
+		form.Set("actions", string(actions))
+		form.Set("container", string(container))
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		"https://slack.com/api/"+method+"?token="+c.token,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req, nil)
+}
@@ -7,10 +7,10 @@ import (
 	"log"
 	"mime/multipart"
 
-	"encoding/json"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+
+	"github.com/1egoman/slick/gateway"
 )
 
 func (c *SlackConnection) PostText(title string, content string) error {
@@ -27,18 +27,8 @@ func (c *SlackConnection) PostText(title string, content string) error {
 		queryString += "&title=" + title
 	}
 
-	resp, err := http.Get("https://slack.com/api/files.upload" + queryString)
-	if err != nil {
-		return err
-	}
-
-	var body []byte
-	body, err = ioutil.ReadAll(resp.Body)
-
 	var fileBuffer struct {
-		Ok    bool   `json:"ok"`
-		Error string `json:"error"`
-		File  struct {
+		File struct {
 			Id       string `json:"id"`
 			Mimetype string `json:"mimetype"`
 			Mode     string `json:"snippet"`
@@ -47,52 +37,93 @@ func (c *SlackConnection) PostText(title string, content string) error {
 		} `json:"file"`
 	}
 
-	json.Unmarshal(body, &fileBuffer)
-
-	if len(fileBuffer.Error) != 0 {
-		log.Println("Error posting to channel", fileBuffer.Error)
-		return errors.New(fileBuffer.Error)
+	err := c.get("https://slack.com/api/files.upload"+queryString, &fileBuffer)
+	if err != nil {
+		log.Println("Error posting to channel", err)
+		return err
 	}
 	return nil
 }
 
 func (c *SlackConnection) PostBinary(title string, filename string, content []byte) error {
-	log.Printf("* Posting binary to active channel: '%s'", filename)
-	title = url.QueryEscape(title)
+	return c.PostFile(gateway.FileUpload{
+		Filename: filename,
+		Title:    title,
+		Content:  bytes.NewReader(content),
+		Size:     int64(len(content)),
+	})
+}
 
-	// Assemble the query string
-	url := "https://slack.com/api/files.upload"
-	url += "?token=" + c.token
-	url += "&file=file"
-	url += "&channels=" + c.selectedChannel.Id
-	if len(title) > 0 {
-		url += "&title=" + title
+// Upload a file to slack. The file's content is streamed into the body of the request as it's
+// sent, so large files never have to be read into memory.
+func (c *SlackConnection) PostFile(upload gateway.FileUpload) error {
+	channel := upload.Channel
+	if channel == nil {
+		channel = c.selectedChannel
+	}
+	if channel == nil {
+		return errors.New("No channel to upload the file into.")
 	}
+	log.Printf("* Posting file to %s: '%s'", channel.Name, upload.Filename)
 
-	// Prepare a form that you will submit to that URL.
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
-	// Add your image file
-	fw, err := w.CreateFormFile("file", filename)
-	if err != nil {
-		return err
+	// Assemble the query string
+	uploadUrl := "https://slack.com/api/files.upload"
+	uploadUrl += "?token=" + c.token
+	uploadUrl += "&channels=" + channel.Id
+	if len(upload.Title) > 0 {
+		uploadUrl += "&title=" + url.QueryEscape(upload.Title)
 	}
-	if _, err = io.Copy(fw, bytes.NewBuffer(content)); err != nil {
-		return err
+	if len(upload.Filetype) > 0 {
+		uploadUrl += "&filetype=" + url.QueryEscape(upload.Filetype)
+	}
+	if len(upload.InitialComment) > 0 {
+		uploadUrl += "&initial_comment=" + url.QueryEscape(upload.InitialComment)
 	}
-	// Don't forget to close the multipart writer.
-	// If you don't close it, your request will be missing the terminating boundary.
-	w.Close()
 
-	req, err := http.NewRequest("POST", url, &b)
-	// Don't forget to set the content type, this will contain the boundary.
-	req.Header.Set("Content-Type", w.FormDataContentType())
+	// The multipart body is the form's headers, then the file, then the closing boundary. Write the
+	// parts around the file ahead of time so that the file can be streamed between them.
+	var header bytes.Buffer
+	form := multipart.NewWriter(&header)
+	if _, err := form.CreateFormFile("file", upload.Filename); err != nil {
+		return err
+	}
+	var footer bytes.Buffer
+	footerForm := multipart.NewWriter(&footer)
+	footerForm.SetBoundary(form.Boundary())
+	footerForm.Close()
 
-	client := &http.Client{}
-	_, err = client.Do(req)
+	content := &uploadProgressReader{reader: upload.Content, progress: upload.Progress}
+	req, err := http.NewRequest("POST", uploadUrl, io.MultiReader(&header, content, &footer))
 	if err != nil {
 		return err
 	}
+	if upload.Size >= 0 {
+		req.ContentLength = int64(header.Len()) + upload.Size + int64(footer.Len())
+	}
+	if upload.Context != nil {
+		req = req.WithContext(upload.Context)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
 
+	if err = c.do(req, nil); err != nil {
+		log.Println("Error posting file to channel", err)
+		return err
+	}
 	return nil
 }
+
+// Counts the bytes of a file that have been sent.
+type uploadProgressReader struct {
+	reader   io.Reader
+	progress func(sent int64)
+	sent     int64
+}
+
+func (r *uploadProgressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.sent += int64(n)
+	if r.progress != nil && n > 0 {
+		r.progress(r.sent)
+	}
+	return n, err
+}
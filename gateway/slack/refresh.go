@@ -33,10 +33,11 @@ func (c *SlackConnection) Refresh(force bool) error {
 			c.Team().Name,
 			c.SelectedChannel().Name,
 		)
-		c.messageHistory, err = c.FetchChannelMessages(*c.selectedChannel, nil)
+		c.messageHistory, err = c.FetchChannelMessages(*c.selectedChannel, nil, nil)
 		if err != nil {
 			return err
 		}
+		c.hasNewerHistory = false
 	}
 
 	// If no channel is selected, select a default.
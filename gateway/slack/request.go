@@ -0,0 +1,299 @@
+package gatewaySlack
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// Slack rate limits each web api method according to a "tier". All requests made to slack are
+// queued per tier so that slick stays under each limit, and when slack responds with a 429 anyway
+// the `Retry-After` header is honoured before trying again.
+// More info: https://api.slack.com/docs/rate-limits
+type rateLimitTier int
+
+const (
+	rateLimitTier1       rateLimitTier = iota // 1+ per minute
+	rateLimitTier2                            // 20+ per minute
+	rateLimitTier3                            // 50+ per minute
+	rateLimitTier4                            // 100+ per minute
+	rateLimitPostMessage                      // about 1 per second
+)
+
+// How many requests are allowed per minute in each tier, and how many of those can be sent in a
+// burst before slick starts spacing them out.
+var rateLimitTierLimits = map[rateLimitTier]struct {
+	PerMinute int
+	Burst     int
+}{
+	rateLimitTier1:       {PerMinute: 1, Burst: 5},
+	rateLimitTier2:       {PerMinute: 20, Burst: 20},
+	rateLimitTier3:       {PerMinute: 50, Burst: 50},
+	rateLimitTier4:       {PerMinute: 100, Burst: 100},
+	rateLimitPostMessage: {PerMinute: 60, Burst: 5},
+}
+
+// Which tier each method belongs to. Any method not in this list is treated as tier 3, which is
+// what slack documents most methods as.
+var rateLimitMethodTiers = map[string]rateLimitTier{
+	"rtm.start": rateLimitTier1,
+
+	"conversations.list": rateLimitTier2,
+	"users.list":         rateLimitTier2,
+	"files.upload":       rateLimitTier2,
+	"channels.join":      rateLimitTier2,
+
+	"conversations.setTopic":   rateLimitTier2,
+	"conversations.setPurpose": rateLimitTier2,
+
+	"users.info":            rateLimitTier4,
+	"conversations.members": rateLimitTier4,
+
+	"chat.postMessage": rateLimitPostMessage,
+}
+
+// How many times a request will be retried before giving up, and how long to wait before the
+// first retry when slack didn't say how long to wait.
+const maxRequestRetries = 2
+const requestRetryBackoff = 500 * time.Millisecond
+
+// If a request has to wait at least this long to be sent, the connection is considered throttled.
+const throttledThreshold = time.Second
+
+func tierForMethod(method string) rateLimitTier {
+	if tier, ok := rateLimitMethodTiers[method]; ok {
+		return tier
+	} else {
+		return rateLimitTier3
+	}
+}
+
+// Methods that only read data can safely be retried if they fail part way through. Retrying a
+// write could cause it to happen twice (ie, a message being sent twice.)
+func isIdempotentMethod(method string) bool {
+	if method == "rtm.start" {
+		return true
+	}
+	for _, suffix := range []string{".list", ".history", ".info", ".replies", ".members", ".get"} {
+		if strings.HasSuffix(method, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// An error returned by slack in the `error` field of a response.
+type SlackError struct {
+	Method string
+	Code   string // ie, `channel_not_found`, `not_in_channel`, `ratelimited`
+}
+
+func (e *SlackError) Error() string {
+	return e.Code
+}
+
+// Is the passed error a slack error with the given code?
+func IsSlackError(err error, code string) bool {
+	slackError, ok := err.(*SlackError)
+	return ok && slackError.Code == code
+}
+
+// Keeps track of when the next request in each tier is allowed to be sent.
+type requestScheduler struct {
+	mutex sync.Mutex
+
+	// The earliest time that a request in each tier would be sent if no burst was allowed.
+	nextSlot map[rateLimitTier]time.Time
+
+	// When slack asks us to back off, no requests in the tier are sent until this time.
+	retryAfter map[rateLimitTier]time.Time
+
+	// The latest time that any tier is waiting for. Used to show throttling in the status bar.
+	throttledUntil time.Time
+}
+
+func newRequestScheduler() *requestScheduler {
+	return &requestScheduler{
+		nextSlot:   make(map[rateLimitTier]time.Time),
+		retryAfter: make(map[rateLimitTier]time.Time),
+	}
+}
+
+// Reserve a spot in the tier's queue, returning how long the caller must wait before sending its
+// request. Reservations are handed out in order, so requests are sent in the order they were made.
+func (s *requestScheduler) reserve(tier rateLimitTier) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	limits := rateLimitTierLimits[tier]
+	interval := time.Minute / time.Duration(limits.PerMinute)
+	burst := interval * time.Duration(limits.Burst-1)
+
+	now := time.Now()
+	slot := s.nextSlot[tier]
+	if slot.Before(now) {
+		slot = now
+	}
+
+	sendAt := slot.Add(-burst)
+	if sendAt.Before(now) {
+		sendAt = now
+	}
+	if s.retryAfter[tier].After(sendAt) {
+		sendAt = s.retryAfter[tier]
+	}
+	s.nextSlot[tier] = slot.Add(interval)
+
+	if wait := sendAt.Sub(now); wait >= throttledThreshold && sendAt.After(s.throttledUntil) {
+		s.throttledUntil = sendAt
+	}
+	return sendAt.Sub(now)
+}
+
+// Stop sending requests in the given tier for a while.
+func (s *requestScheduler) block(tier rateLimitTier, duration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	until := time.Now().Add(duration)
+	if until.After(s.retryAfter[tier]) {
+		s.retryAfter[tier] = until
+	}
+	if until.After(s.throttledUntil) {
+		s.throttledUntil = until
+	}
+}
+
+func (s *requestScheduler) ThrottledUntil() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.throttledUntil
+}
+
+// Slack sends the number of seconds to wait in the `Retry-After` header. If it's missing, wait a
+// little bit anyway.
+func parseRetryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	} else {
+		return requestRetryBackoff
+	}
+}
+
+func (c *SlackConnection) scheduler() *requestScheduler {
+	if c.requests == nil {
+		c.requests = newRequestScheduler()
+	}
+	return c.requests
+}
+
+// If slack is rate limiting this connection, when will requests be able to be made again?
+func (c *SlackConnection) ThrottledUntil() time.Time {
+	return c.scheduler().ThrottledUntil()
+}
+
+// Make a GET request to the slack api, decoding the response into `response` (which can be nil).
+func (c *SlackConnection) get(url string, response interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, response)
+}
+
+// Make a request to the slack api. The request waits in its method's rate limit tier before being
+// sent, is retried if slack asks us to slow down (or if it's a read that failed), and if slack
+// responds with `ok: false` or an `error` a *SlackError is returned.
+func (c *SlackConnection) do(req *http.Request, response interface{}) error {
+	method := path.Base(req.URL.Path)
+	tier := tierForMethod(method)
+	// Requests with a body can only be resent if the body can be rewound.
+	resendable := req.Body == nil || req.GetBody != nil
+
+	var body []byte
+	for attempt := 0; ; attempt++ {
+		if wait := c.scheduler().reserve(tier); wait > 0 {
+			log.Printf("Waiting %s before calling %s to stay under slack's rate limit", wait, method)
+			time.Sleep(wait)
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			req.Body, _ = req.GetBody()
+		}
+
+		// Only reads are retried when the network or slack fails, since a write might have
+		// already happened.
+		canRetry := attempt < maxRequestRetries && resendable
+		backoff := requestRetryBackoff * time.Duration(1<<uint(attempt))
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			if canRetry && isIdempotentMethod(method) {
+				log.Printf("Error calling %s, retrying in %s: %s", method, backoff, err)
+				time.Sleep(backoff)
+				continue
+			}
+			return err
+		}
+
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			if canRetry && isIdempotentMethod(method) {
+				time.Sleep(backoff)
+				continue
+			}
+			return err
+		}
+
+		// Slack rejects rate limited requests before doing anything, so they can always be resent.
+		if resp.StatusCode == http.StatusTooManyRequests {
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+			log.Printf("Slack rate limited %s, retrying in %s", method, retryAfter)
+			c.scheduler().block(tier, retryAfter)
+			if canRetry {
+				continue
+			}
+			return &SlackError{Method: method, Code: "ratelimited"}
+		}
+
+		if resp.StatusCode >= 500 && canRetry && isIdempotentMethod(method) {
+			log.Printf("Slack returned %d for %s, retrying in %s", resp.StatusCode, method, backoff)
+			time.Sleep(backoff)
+			continue
+		}
+
+		break
+	}
+
+	// Every slack response has an `ok` field, and an `error` field when something went wrong.
+	var envelope struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		log.Printf("Slack response to %s: %s", method, body)
+		return fmt.Errorf("Couldn't parse response from slack method %s: %s", method, err)
+	}
+	if !envelope.Ok || len(envelope.Error) > 0 {
+		log.Printf("Slack error calling %s: %s", method, envelope.Error)
+		code := envelope.Error
+		if len(code) == 0 {
+			code = "unknown_error"
+		}
+		return &SlackError{Method: method, Code: code}
+	}
+
+	if response != nil {
+		return json.Unmarshal(body, response)
+	}
+	return nil
+}
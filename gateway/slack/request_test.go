@@ -0,0 +1,120 @@
+package gatewaySlack_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/jarcoal/httpmock"
+)
+
+// Respond with each response in order, repeating the last one once they run out.
+func sequenceResponder(calls *int, responses ...*http.Response) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		response := responses[len(responses)-1]
+		if *calls < len(responses) {
+			response = responses[*calls]
+		}
+		*calls += 1
+		return response, nil
+	}
+}
+
+func rateLimitedResponse(retryAfter string) *http.Response {
+	response := httpmock.NewStringResponse(429, ``)
+	response.Header.Set("Retry-After", retryAfter)
+	return response
+}
+
+func TestRequestRetriesAfterRateLimit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		sequenceResponder(&calls,
+			rateLimitedResponse("1"),
+			httpmock.NewStringResponse(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`),
+		),
+	)
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	startedAt := time.Now()
+	user, err := conn.UserById("user-id")
+
+	if err != nil {
+		t.Errorf("Error fetching user: %s", err)
+	} else if user.Name != "my-user" {
+		t.Errorf("Wrong user fetched: %+v", user)
+	}
+	if calls != 2 {
+		t.Errorf("Expected two requests to be made, made %d", calls)
+	}
+	if time.Since(startedAt) < time.Second {
+		t.Errorf("Request was retried before Retry-After elapsed")
+	}
+	if conn.ThrottledUntil().IsZero() {
+		t.Errorf("Connection wasn't marked as throttled")
+	}
+}
+
+func TestRequestRetriesFailedReads(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		sequenceResponder(&calls,
+			httpmock.NewStringResponse(503, `Service Unavailable`),
+			httpmock.NewStringResponse(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`),
+		),
+	)
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	if _, err := conn.UserById("user-id"); err != nil {
+		t.Errorf("Error fetching user: %s", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected two requests to be made, made %d", calls)
+	}
+}
+
+func TestRequestDoesntRetryFailedWrites(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder("GET", "https://slack.com/api/chat.postMessage?token=token&channel=channel-id&text=Hello&link_names=true&unfurl_links=true&as_user=true",
+		sequenceResponder(&calls, httpmock.NewStringResponse(503, `Service Unavailable`)),
+	)
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	_, err := conn.SendMessage(gateway.Message{Text: "Hello"}, &gateway.Channel{Id: "channel-id"})
+
+	if err == nil {
+		t.Errorf("Sending a message that failed didn't return an error")
+	}
+	if calls != 1 {
+		t.Errorf("Expected one request to be made, made %d", calls)
+	}
+}
+
+func TestRequestReturnsSlackErrors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/channels.leave?token=token&channel=channel-id",
+		httpmock.NewStringResponder(200, `{"ok": false, "error": "channel_not_found"}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	_, err := conn.LeaveChannel(&gateway.Channel{Id: "channel-id", Name: "random"})
+
+	if !gatewaySlack.IsSlackError(err, "channel_not_found") {
+		t.Errorf("Expected a channel_not_found slack error, got %#v", err)
+	}
+	if slackError, ok := err.(*gatewaySlack.SlackError); !ok || slackError.Method != "channels.leave" {
+		t.Errorf("Slack error didn't record the method that failed: %#v", err)
+	}
+}
@@ -1,10 +1,7 @@
 package gatewaySlack
 
 import (
-	"encoding/json"
-	"io/ioutil"
 	"log"
-	"net/http"
 	"net/url"
 
 	"strings"
@@ -18,16 +15,10 @@ func sendSlashCommand(c *SlackConnection, message gateway.Message, channel *gate
 	// If the message starts with a slash, it's a slash command.
 	command := strings.Split(message.Text, " ")
 	text := url.QueryEscape(strings.Join(command[1:], " "))
-	resp, err := http.Get("https://slack.com/api/chat.command?token=" + c.token + "&channel=" + channel.Id + "&command=" + url.QueryEscape(command[0]) + "&text=" + text)
-	if err != nil {
-		return nil, err
-	}
-
-	body, _ := ioutil.ReadAll(resp.Body)
 	var commandResponse struct {
 		Response string `json:"response"`
 	}
-	err = json.Unmarshal(body, &commandResponse)
+	err := c.get("https://slack.com/api/chat.command?token="+c.token+"&channel="+channel.Id+"&command="+url.QueryEscape(command[0])+"&text="+text, &commandResponse)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +44,7 @@ func (c *SlackConnection) SendMessage(message gateway.Message, channel *gateway.
 		log.Printf("Sending message to team %s on channel %s", c.Team().Name, channel.Name)
 
 		// Otherwise just a plain message
-		_, err := http.Get("https://slack.com/api/chat.postMessage?token=" + c.token + "&channel=" + channel.Id + "&text=" + url.QueryEscape(message.Text) + "&link_names=true&parse=full&unfurl_links=true&as_user=true")
+		err := c.get("https://slack.com/api/chat.postMessage?token="+c.token+"&channel="+channel.Id+"&text="+url.QueryEscape(message.Text)+"&link_names=true&unfurl_links=true&as_user=true", nil)
 		return nil, err
 	}
 }
@@ -5,9 +5,10 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"sync"
+	"time"
 
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
 
 	"github.com/1egoman/slick/gateway"
@@ -24,6 +25,11 @@ func New(token string) *SlackConnection {
 	return &SlackConnection{
 		token:    token,
 		nickname: nil,
+		requests: newRequestScheduler(),
+
+		hasMoreHistory: make(map[string]bool),
+		historyCursors: make(map[string]string),
+		lastRead:       make(map[string]string),
 	}
 }
 func NewWithName(nickname string, token string) *SlackConnection {
@@ -38,6 +44,12 @@ func NewWithName(nickname string, token string) *SlackConnection {
 
 		// Which users are online?
 		userPresence: make(map[string]bool),
+
+		requests: newRequestScheduler(),
+
+		hasMoreHistory: make(map[string]bool),
+		historyCursors: make(map[string]string),
+		lastRead:       make(map[string]string),
 	}
 }
 
@@ -58,6 +70,9 @@ type SlackConnection struct {
 
 	userCache map[string]gateway.User
 
+	// Every user on the team, once they've been fetched
+	users []gateway.User
+
 	// Internal state to store all channels and a pointer to the active one.
 	channels        []gateway.Channel
 	selectedChannel *gateway.Channel
@@ -65,11 +80,27 @@ type SlackConnection struct {
 	// Internal state to store message history of the active channel
 	messageHistory []gateway.Message
 
+	// For each channel, are there older messages to scroll back to? And, given the timestamp of
+	// the oldest message fetched, the cursor that slack returned for the page before it.
+	historyMutex   sync.Mutex
+	hasMoreHistory map[string]bool
+	historyCursors map[string]string
+
+	// Is the message history of the active channel showing a window in the past, with newer
+	// messages after it that haven't been fetched?
+	hasNewerHistory bool
+
+	// For each channel, the timestamp of the newest message that the user has seen.
+	lastRead map[string]string
+
 	// Managethe users that are currently typing.
 	typingUsers *gateway.TypingUsers
 
 	// A list of users mapping to whether they are online of offline.
 	userPresence map[string]bool
+
+	// Queues requests to slack's api so that rate limits are respected.
+	requests *requestScheduler
 }
 
 // Return the name of the team.
@@ -83,126 +114,120 @@ func (c *SlackConnection) Name() string {
 	}
 }
 
+// How many items to ask slack for in each page of a paginated request.
+const pageSize = "200"
+
+// How many messages to fetch at a time when scrolling through a channel.
+const messageHistoryPageSize = "100"
+
+// How many times to resize the window of time being searched for messages after a timestamp.
+const maxMessageWindowAttempts = 8
+
 // Fetch all channels for the given team
 func (c *SlackConnection) FetchChannels() ([]gateway.Channel, error) {
 	var channelBuffer []gateway.Channel
 
-	// FETCH CHANNELs
-	log.Printf("Fetching list of channels for team %s", c.Team().Name)
-	resp, err := httpClient.Get("https://slack.com/api/channels.list?token=" + c.token)
-	if err != nil {
-		return nil, err
-	}
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	var slackChannelBuffer struct {
-		Channels []struct {
-			Id         string `json:"id"`
-			Name       string `json:"name"`
-			CreatorId  string `json:"creator"`
-			Created    int    `json:"created"`
-			IsMember   bool   `json:"is_member"`
-			IsArchived bool   `json:"is_archived"`
-		} `json:"channels"`
-	}
-	json.Unmarshal(body, &slackChannelBuffer)
-
-	// Convert to more generic message format
-	var creator *gateway.User
-	for _, channel := range slackChannelBuffer.Channels {
-		creator, err = c.UserById(channel.CreatorId)
-		if err != nil {
-			return nil, err
+	log.Printf("Fetching list of conversations for team %s", c.Team().Name)
+
+	// Slack returns conversations a page at a time. Keep fetching pages until there's no cursor
+	// pointing to the next one.
+	cursor := ""
+	for {
+		url := "https://slack.com/api/conversations.list?token=" + c.token
+		url += "&types=public_channel,private_channel,mpim,im"
+		url += "&limit=" + pageSize
+		if len(cursor) > 0 {
+			url += "&cursor=" + cursor
 		}
-		channelBuffer = append(channelBuffer, gateway.Channel{
-			Id:         channel.Id,
-			SubType:    gateway.TYPE_CHANNEL,
-			Name:       channel.Name,
-			Creator:    creator,
-			Created:    channel.Created,
-			IsMember:   channel.IsMember,
-			IsArchived: channel.IsArchived,
-		})
-	}
-
-	// FETCH IMs
-	log.Printf("Fetching list of ims for team %s", c.Team().Name)
-	resp, err = httpClient.Get("https://slack.com/api/im.list?token=" + c.token)
-	if err != nil {
-		return nil, err
-	}
-
-	body, _ = ioutil.ReadAll(resp.Body)
-	var slackImBuffer struct {
-		Ims []struct {
-			Id      string `json:"id"`
-			User    string `json:"user"`
-			Created int    `json:"created"`
-		} `json:"ims"`
-	}
-	json.Unmarshal(body, &slackImBuffer)
 
-	var otherUser *gateway.User
-	for _, im := range slackImBuffer.Ims {
-		otherUser, err = c.UserById(im.User)
-		if err != nil {
+		var slackChannelBuffer struct {
+			Channels []struct {
+				Id          string `json:"id"`
+				Name        string `json:"name"`
+				CreatorId   string `json:"creator"`
+				Created     int    `json:"created"`
+				IsMember    bool   `json:"is_member"`
+				IsArchived  bool   `json:"is_archived"`
+				IsPrivate   bool   `json:"is_private"`
+				IsShared    bool   `json:"is_shared"`
+				IsExtShared bool   `json:"is_ext_shared"`
+				IsIm        bool   `json:"is_im"`
+				IsMpim      bool   `json:"is_mpim"`
+				User        string `json:"user"` // The other user in an im
+				NumMembers  int    `json:"num_members"`
+				Topic       struct {
+					Value string `json:"value"`
+				} `json:"topic"`
+				Purpose struct {
+					Value string `json:"value"`
+				} `json:"purpose"`
+			} `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := c.get(url, &slackChannelBuffer); err != nil {
 			return nil, err
 		}
-		channelBuffer = append(channelBuffer, gateway.Channel{
-			Id:         im.Id,
-			SubType:    gateway.TYPE_DIRECT_MESSAGE,
-			Name:       fmt.Sprintf("im-%s-%s", c.Self().Name, otherUser.Name),
-			Creator:    c.Self(),
-			Created:    im.Created,
-			IsMember:   true,
-			IsArchived: false,
-		})
-	}
-
-	// FETCH GROUPS
-	log.Printf("Fetching list of mp ims for team %s", c.Team().Name)
-	resp, err = httpClient.Get("https://slack.com/api/groups.list?token=" + c.token)
-	if err != nil {
-		return nil, err
-	}
 
-	body, _ = ioutil.ReadAll(resp.Body)
-	var slackMpimBuffer struct {
-		Groups []struct {
-			Id         string `json:"id"`
-			Name       string `json:"name"`
-			CreatorId  string `json:"creator"`
-			Created    int    `json:"created"`
-			Members    []string   `json:"members"`
-			IsArchived bool   `json:"is_archived"`
-		} `json:"groups"`
-	}
-	json.Unmarshal(body, &slackMpimBuffer)
+		// Convert to more generic channel format
+		for _, channel := range slackChannelBuffer.Channels {
+			if channel.IsIm {
+				otherUser, err := c.UserById(channel.User)
+				if err != nil {
+					return nil, err
+				}
+				channelBuffer = append(channelBuffer, gateway.Channel{
+					Id:         channel.Id,
+					SubType:    gateway.TYPE_DIRECT_MESSAGE,
+					Name:       c.directMessageName(otherUser),
+					Creator:    c.Self(),
+					Created:    channel.Created,
+					IsMember:   true,
+					IsArchived: false,
+					IsPrivate:  true,
+
+					DisplayName:       otherUser.Name,
+					DirectMessageUser: otherUser.Id,
+				})
+				continue
+			}
 
-	for _, mpim := range slackMpimBuffer.Groups {
-		creator, err = c.UserById(mpim.CreatorId)
-		if err != nil {
-			return nil, err
-		}
+			creator, err := c.UserById(channel.CreatorId)
+			if err != nil {
+				return nil, err
+			}
 
-		// Is the surrent user a member of the group?
-		isMember := false
-		for _, member := range mpim.Members {
-			if self := c.Self(); self != nil && member == self.Id {
-				isMember = true
-				break
+			subType := gateway.TYPE_CHANNEL
+			var displayName string
+			if channel.IsMpim {
+				subType = gateway.TYPE_GROUP_DIRECT_MESSAGE
+				displayName = c.groupDirectMessageDisplayName(channel.Name)
 			}
+
+			channelBuffer = append(channelBuffer, gateway.Channel{
+				Id:      channel.Id,
+				SubType: subType,
+				Name:    channel.Name,
+				Creator: creator,
+				Created: channel.Created,
+				// Slack only lists private conversations that the user is a member of.
+				IsMember:   channel.IsMember || channel.IsPrivate || channel.IsMpim,
+				IsArchived: channel.IsArchived,
+				IsPrivate:  channel.IsPrivate || channel.IsMpim,
+				IsShared:   channel.IsShared || channel.IsExtShared,
+
+				Topic:       channel.Topic.Value,
+				Purpose:     channel.Purpose.Value,
+				MemberCount: channel.NumMembers,
+				DisplayName: displayName,
+			})
 		}
 
-		channelBuffer = append(channelBuffer, gateway.Channel{
-			Id:         mpim.Id,
-			SubType:    gateway.TYPE_GROUP_DIRECT_MESSAGE,
-			Name:       mpim.Name,
-			Creator:    creator,
-			Created:    mpim.Created,
-			IsMember:   isMember,
-			IsArchived: mpim.IsArchived,
-		})
+		cursor = slackChannelBuffer.ResponseMetadata.NextCursor
+		if len(cursor) == 0 {
+			break
+		}
 	}
 
 	// Set the internal state of the component.
@@ -212,44 +237,142 @@ func (c *SlackConnection) FetchChannels() ([]gateway.Channel, error) {
 	return channelBuffer, nil
 }
 
-// Given a channel, return all messages within that channel.
-func (c *SlackConnection) FetchChannelMessages(channel gateway.Channel, startTs *string) ([]gateway.Message, error) {
-	log.Printf("Fetching channel messages for team %s starting at %s", c.Team().Name, startTs)
+// Given a channel, return a page of messages within that channel. If `latest` is passed, the page
+// contains the messages directly before that timestamp, and if `oldest` is passed, the page
+// contains the messages directly after that timestamp. If both are passed, the page contains the
+// newest messages between the two.
+func (c *SlackConnection) FetchChannelMessages(channel gateway.Channel, latest *string, oldest *string) ([]gateway.Message, error) {
+	log.Printf("Fetching channel messages for team %s between %v and %v", c.Team().Name, oldest, latest)
 
-	// Contruct the request url
-	var url string
-	if channel.SubType == gateway.TYPE_CHANNEL {
-		url = "https://slack.com/api/channels.history?token=" + c.token
-	} else if channel.SubType == gateway.TYPE_DIRECT_MESSAGE {
-		url = "https://slack.com/api/im.history?token=" + c.token
-	} else if channel.SubType == gateway.TYPE_GROUP_DIRECT_MESSAGE {
-		url = "https://slack.com/api/groups.history?token=" + c.token
+	if oldest != nil && latest != nil {
+		messages, _, _, err := c.fetchMessagePage(channel, "&latest="+*latest+"&oldest="+*oldest)
+		return messages, err
+	} else if oldest != nil {
+		return c.fetchMessagesAfter(channel, *oldest)
 	}
-	url += "&channel=" + channel.Id
-	url += "&count=100"
 
-	// If a starting timestamp was passed, get all messages after that timestamp.
-	if startTs != nil {
-		url += "&latest=" + *startTs
+	// If a starting timestamp was passed, get the page of messages before that timestamp. If the
+	// page before it was fetched by this connection, use the cursor slack gave for it.
+	var params string
+	if latest != nil {
+		c.historyMutex.Lock()
+		cursor, ok := c.historyCursors[channel.Id+"/"+*latest]
+		c.historyMutex.Unlock()
+
+		if ok {
+			params = "&cursor=" + cursor
+		} else {
+			params = "&latest=" + *latest
+		}
 	}
 
-	log.Println("Fetching history from slack", url)
-	resp, err := httpClient.Get(url)
+	messages, hasMore, cursor, err := c.fetchMessagePage(channel, params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse slack messages
-	body, err := ioutil.ReadAll(resp.Body)
+	// Remember if there are older messages, and how to get to them.
+	c.historyMutex.Lock()
+	defer c.historyMutex.Unlock()
+	c.hasMoreHistory[channel.Id] = hasMore && len(messages) > 0
+	if len(cursor) > 0 && len(messages) > 0 {
+		c.historyCursors[channel.Id+"/"+messages[0].Hash] = cursor
+	}
+
+	return messages, nil
+}
+
+// Slack always returns the newest messages in a range first, so to get the page of messages
+// directly after a timestamp, look for a window after it that is small enough to fit in one page.
+// The window is narrowed between the biggest window known to be empty and the smallest window known
+// to have more than a page of messages.
+func (c *SlackConnection) fetchMessagesAfter(channel gateway.Channel, oldest string) ([]gateway.Message, error) {
+	oldestSeconds, err := strconv.ParseFloat(oldest, 64)
 	if err != nil {
 		return nil, err
 	}
+
+	now := float64(time.Now().Unix())
+	emptyWindow := float64(0)       // The biggest window that has no messages.
+	overflowWindow := float64(0)    // The smallest window with too many messages, 0 if none yet.
+	window := float64(24 * 60 * 60) // Start by looking a day ahead.
+	for attempt := 0; attempt < maxMessageWindowAttempts; attempt++ {
+		latest := oldestSeconds + window
+		messages, hasMore, _, err := c.fetchMessagePage(
+			channel,
+			fmt.Sprintf("&latest=%.6f&oldest=%s", latest, oldest),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasMore {
+			// More messages than fit in a page, so look at a smaller window.
+			overflowWindow = window
+		} else if len(messages) == 0 && latest < now {
+			// No messages yet, so look further ahead.
+			emptyWindow = window
+		} else {
+			return messages, nil
+		}
+
+		if overflowWindow == 0 {
+			window *= 4
+		} else if emptyWindow == 0 {
+			window /= 4
+		} else {
+			window = (emptyWindow + overflowWindow) / 2
+		}
+
+		// Once the window can't get much smaller, stop looking.
+		if overflowWindow > 0 && overflowWindow-emptyWindow <= 60 {
+			break
+		}
+	}
+
+	if overflowWindow == 0 {
+		return nil, nil
+	}
+
+	// No window fits in one page, so page through the smallest window that has too many messages
+	// until reaching the oldest page, which starts right after `oldest`.
+	params := fmt.Sprintf("&latest=%.6f&oldest=%s", oldestSeconds+overflowWindow, oldest)
+	cursor := ""
+	for {
+		pageParams := params
+		if len(cursor) > 0 {
+			pageParams += "&cursor=" + cursor
+		}
+		messages, hasMore, nextCursor, err := c.fetchMessagePage(channel, pageParams)
+		if err != nil {
+			return nil, err
+		}
+		if !hasMore || len(nextCursor) == 0 {
+			return messages, nil
+		}
+		cursor = nextCursor
+	}
+}
+
+// Fetch a single page of messages from slack, oldest message first. Also returns whether slack has
+// older messages in the range, and the cursor pointing to them.
+func (c *SlackConnection) fetchMessagePage(channel gateway.Channel, params string) ([]gateway.Message, bool, string, error) {
+	url := "https://slack.com/api/conversations.history?token=" + c.token
+	url += "&channel=" + channel.Id
+	url += "&limit=" + messageHistoryPageSize
+	url += params
+
+	log.Println("Fetching history from slack", url)
 	var slackMessageBuffer struct {
-		Messages []map[string]interface{} `json:"messages"`
-		hasMore  bool
+		Messages         []map[string]interface{} `json:"messages"`
+		HasMore          bool                     `json:"has_more"`
+		ResponseMetadata struct {
+			NextCursor string `json:"next_cursor"`
+		} `json:"response_metadata"`
 	}
-	if err = json.Unmarshal(body, &slackMessageBuffer); err != nil {
-		return nil, err
+	err := c.get(url, &slackMessageBuffer)
+	if err != nil {
+		return nil, false, "", err
 	}
 
 	// Convert to more generic message format
@@ -261,40 +384,53 @@ func (c *SlackConnection) FetchChannelMessages(channel gateway.Channel, startTs
 		if err == nil {
 			messageBuffer = append(messageBuffer, *message)
 		} else {
-			return nil, err
+			return nil, false, "", err
 		}
 	}
 
-	return messageBuffer, nil
+	return messageBuffer, slackMessageBuffer.HasMore, slackMessageBuffer.ResponseMetadata.NextCursor, nil
+}
+
+// Are there older messages in the given channel than the ones that have been fetched? Until a
+// channel's history is fetched, assume there are.
+func (c *SlackConnection) HasMoreMessageHistory(channel gateway.Channel) bool {
+	c.historyMutex.Lock()
+	defer c.historyMutex.Unlock()
+
+	if hasMore, ok := c.hasMoreHistory[channel.Id]; ok {
+		return hasMore
+	} else {
+		return true
+	}
 }
 
 func (c *SlackConnection) UserById(id string) (*gateway.User, error) {
 	if user, ok := c.userCache[id]; ok {
 		return &user, nil
 	} else {
-		resp, err := httpClient.Get("https://slack.com/api/users.info?token=" + c.token + "&user=" + id)
-		if err != nil {
-			return nil, err
-		}
-
 		// Parse slack user buffer
-		body, _ := ioutil.ReadAll(resp.Body)
 		var slackUserBuffer struct {
 			User struct {
-				Id      string `json:"id"`
-				Name    string `json:"name"`
-				Color   string `json:"color"`
-				Profile struct {
-					Status   string `json:"color"`
-					RealName string `json:"real_name"`
-					Email    string `json:"email"`
-					Phone    string `json:"phone"`
-					Skype    string `json:"skype"`
-					Image    string `json:"image_24"`
+				Id             string `json:"id"`
+				Name           string `json:"name"`
+				Color          string `json:"color"`
+				Timezone       string `json:"tz"`
+				TimezoneLabel  string `json:"tz_label"`
+				TimezoneOffset int    `json:"tz_offset"`
+				Profile        struct {
+					Status      string `json:"status_text"`
+					StatusEmoji string `json:"status_emoji"`
+					Title       string `json:"title"`
+					RealName    string `json:"real_name"`
+					Email       string `json:"email"`
+					Phone       string `json:"phone"`
+					Skype       string `json:"skype"`
+					Image       string `json:"image_24"`
 				} `json:"profile"`
 			} `json:"user"`
 		}
-		if err = json.Unmarshal(body, &slackUserBuffer); err != nil {
+		err := c.get("https://slack.com/api/users.info?token="+c.token+"&user="+id, &slackUserBuffer)
+		if err != nil {
 			return nil, err
 		}
 
@@ -309,6 +445,12 @@ func (c *SlackConnection) UserById(id string) (*gateway.User, error) {
 			Email:    slackUserBuffer.User.Profile.Email,
 			Skype:    slackUserBuffer.User.Profile.Skype,
 			Phone:    slackUserBuffer.User.Profile.Phone,
+
+			Title:          slackUserBuffer.User.Profile.Title,
+			StatusEmoji:    slackUserBuffer.User.Profile.StatusEmoji,
+			Timezone:       slackUserBuffer.User.Timezone,
+			TimezoneLabel:  slackUserBuffer.User.TimezoneLabel,
+			TimezoneOffset: slackUserBuffer.User.TimezoneOffset,
 		}
 
 		// Store in cache
@@ -317,6 +459,16 @@ func (c *SlackConnection) UserById(id string) (*gateway.User, error) {
 	}
 }
 
+// Like UserById, but users that slack can't find are replaced with a placeholder. This happens in
+// shared channels, where users on the other team can't always be looked up.
+func (c *SlackConnection) userByIdOrPlaceholder(id string) (*gateway.User, error) {
+	user, err := c.UserById(id)
+	if IsSlackError(err, "user_not_found") {
+		return &gateway.User{Id: id, Name: id}, nil
+	}
+	return user, err
+}
+
 func (c *SlackConnection) UserOnline(user *gateway.User) bool {
 	return c.userPresence[user.Id]
 }
@@ -347,9 +499,33 @@ func (c *SlackConnection) SelectedChannel() *gateway.Channel {
 	return c.selectedChannel
 }
 func (c *SlackConnection) SetSelectedChannel(channel *gateway.Channel) {
+	// When leaving a channel, the user has read every message in it.
+	if c.selectedChannel != nil && len(c.messageHistory) > 0 && !c.hasNewerHistory {
+		c.SetLastRead(*c.selectedChannel, c.messageHistory[len(c.messageHistory)-1].Hash)
+	}
+
 	c.selectedChannel = channel
 	// When setting a new channel, clear out the message history so that messages will be refetched.
 	c.messageHistory = []gateway.Message{}
+	c.hasNewerHistory = false
+}
+
+func (c *SlackConnection) LastRead(channel gateway.Channel) string {
+	c.historyMutex.Lock()
+	defer c.historyMutex.Unlock()
+	return c.lastRead[channel.Id]
+}
+func (c *SlackConnection) SetLastRead(channel gateway.Channel, timestamp string) {
+	c.historyMutex.Lock()
+	defer c.historyMutex.Unlock()
+	c.lastRead[channel.Id] = timestamp
+}
+
+func (c *SlackConnection) HasNewerMessageHistory() bool {
+	return c.hasNewerHistory
+}
+func (c *SlackConnection) SetHasNewerMessageHistory(hasNewer bool) {
+	c.hasNewerHistory = hasNewer
 }
 
 func (c *SlackConnection) Incoming() chan gateway.Event {
@@ -380,6 +556,8 @@ func (c *SlackConnection) SetSelf(u gateway.User) {
 type RawSlackMessage struct {
 	Ts        string `json:"ts"`
 	UserId    string `json:"user"`
+	Username  string `json:"username"` // Only sent for messages posted by bots
+	BotId     string `json:"bot_id"`
 	Text      string `json:"text"`
 	Reactions []struct {
 		Name  string   `json:"name"`
@@ -389,10 +567,13 @@ type RawSlackMessage struct {
 		Id         string `json:"id"`
 		Name       string `json:"name"`
 		Filetype   string `json:"pretty_type"`
+		Mimetype   string `json:"mimetype"`
+		Size       int    `json:"size"`
 		User       string `json:"user"`
 		PrivateUrl string `json:"url_private"`
 		Permalink  string `json:"permalink"`
 		Text       string `json:"plain_text"`
+		Preview    string `json:"preview"`
 		Reactions  []struct {
 			Name  string   `json:"name"`
 			Users []string `json:"users"`
@@ -408,7 +589,20 @@ type RawSlackMessage struct {
 			Value string `json:"value"`
 			Short bool   `json:"short"`
 		} `json:"fields"`
+		CallbackId string `json:"callback_id"`
+		Actions    []struct {
+			Name    string `json:"name"`
+			Text    string `json:"text"`
+			Type    string `json:"type"`
+			Value   string `json:"value"`
+			Url     string `json:"url"`
+			Options []struct {
+				Text  string `json:"text"`
+				Value string `json:"value"`
+			} `json:"options"`
+		} `json:"actions"`
 	} `json:"attachments"`
+	Blocks []rawBlock `json:"blocks"`
 }
 
 func (c *SlackConnection) ParseMessage(
@@ -433,10 +627,13 @@ func (c *SlackConnection) ParseMessage(
 	// Get the sender of the message
 	// Since we're likely to have a lot of the same users, cache them.
 	var sender *gateway.User
-	if cachedUsers[slackMessageBuffer.UserId] != nil {
+	if len(slackMessageBuffer.UserId) == 0 {
+		// Messages posted by bots and integrations don't have a user.
+		sender = &gateway.User{Name: slackMessageBuffer.Username}
+	} else if cachedUsers[slackMessageBuffer.UserId] != nil {
 		sender = cachedUsers[slackMessageBuffer.UserId]
 	} else {
-		sender, err = c.UserById(slackMessageBuffer.UserId)
+		sender, err = c.userByIdOrPlaceholder(slackMessageBuffer.UserId)
 		if err != nil {
 			return nil, err
 		}
@@ -457,7 +654,7 @@ func (c *SlackConnection) ParseMessage(
 				reactionUsers = append(reactionUsers, cachedUsers[reactionUserId])
 			} else {
 				var reactionUser *gateway.User
-				reactionUser, err = c.UserById(reactionUserId)
+				reactionUser, err = c.userByIdOrPlaceholder(reactionUserId)
 				if err != nil {
 					return nil, err
 				}
@@ -476,20 +673,29 @@ func (c *SlackConnection) ParseMessage(
 		if cachedUsers[slackMessageBuffer.File.User] != nil {
 			fileUser = cachedUsers[slackMessageBuffer.File.User]
 		} else {
-			fileUser, err = c.UserById(slackMessageBuffer.File.User)
+			fileUser, err = c.userByIdOrPlaceholder(slackMessageBuffer.File.User)
 			if err != nil {
 				return nil, err
 			}
 		}
 
+		// Snippets include their text. When it's long, slack only sends the start of it.
+		content := slackMessageBuffer.File.Text
+		if len(content) == 0 {
+			content = slackMessageBuffer.File.Preview
+		}
+
 		// Create the file struct representation.
 		file = &gateway.File{
 			Id:         slackMessageBuffer.File.Id,
 			Name:       slackMessageBuffer.File.Name,
 			Filetype:   slackMessageBuffer.File.Filetype,
+			Mimetype:   slackMessageBuffer.File.Mimetype,
+			Size:       slackMessageBuffer.File.Size,
 			User:       fileUser,
 			PrivateUrl: slackMessageBuffer.File.PrivateUrl,
 			Permalink:  slackMessageBuffer.File.Permalink,
+			Content:    content,
 		}
 	} else {
 		file = nil
@@ -504,6 +710,7 @@ func (c *SlackConnection) ParseMessage(
 				TitleLink: attach.TitleLink,
 				Color:     attach.Color,
 				Body:     attach.Text,
+				CallbackId: attach.CallbackId,
 			}
 
 			// Add fields to attachment
@@ -515,6 +722,21 @@ func (c *SlackConnection) ParseMessage(
 				})
 			}
 
+			// Add buttons and menus to attachment
+			for _, action := range attach.Actions {
+				attachmentAction := gateway.AttachmentAction{
+					Name:  action.Name,
+					Text:  action.Text,
+					Type:  action.Type,
+					Value: action.Value,
+					Url:   action.Url,
+				}
+				for _, option := range action.Options {
+					attachmentAction.Options = append(attachmentAction.Options, gateway.BlockOption{Text: option.Text, Value: option.Value})
+				}
+				a.Actions = append(a.Actions, attachmentAction)
+			}
+
 			// Add attachment to list.
 			attachments = append(attachments, a)
 		}
@@ -539,6 +761,8 @@ func (c *SlackConnection) ParseMessage(
 		Hash:        slackMessageBuffer.Ts,
 		File:        file,
 		Attachments: &attachments,
+		Blocks:      parseBlocks(slackMessageBuffer.Blocks),
+		BotId:       slackMessageBuffer.BotId,
 		Confirmed: true,
 	}, nil
 }
@@ -578,29 +802,8 @@ Outer:
 		reactionUrl += "&file=" + message.File.Id
 	}
 
-	// Make the request
-	resp, err := httpClient.Get(reactionUrl)
-	if err != nil {
-		return err
-	}
-
-	// Fetch body
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	// Verify the response
-	var response struct {
-		Ok    bool   `json:"ok"`
-		Error string `json:"error"`
-	}
-	json.Unmarshal(body, &response)
-	if response.Ok {
-		return nil
-	} else {
-		return errors.New(fmt.Sprintf("Slack error: %s", response.Error))
-	}
+	// Make the request. Slack errors (ie, `already_reacted`) are returned as a SlackError.
+	return c.get(reactionUrl, nil)
 }
 
 func (c *SlackConnection) Status() gateway.ConnectionStatus {
@@ -623,12 +826,6 @@ func (c *SlackConnection) JoinChannel(inChannel *gateway.Channel) (*gateway.Chan
 	url += "&name=" + inChannel.Name
 	url += "&validate=true"
 
-	resp, err := httpClient.Get(url)
-	if err != nil {
-		return nil, err
-	}
-
-	body, _ := ioutil.ReadAll(resp.Body)
 	var joinChannelBuffer struct {
 		Channel struct {
 			Id         string `json:"id"`
@@ -639,7 +836,9 @@ func (c *SlackConnection) JoinChannel(inChannel *gateway.Channel) (*gateway.Chan
 			IsArchived bool   `json:"is_archived"`
 		} `json:"channel"`
 	}
-	json.Unmarshal(body, &joinChannelBuffer)
+	if err := c.get(url, &joinChannelBuffer); err != nil {
+		return nil, err
+	}
 
 	// Convert to more generic message format
 	channel := gateway.Channel{
@@ -650,6 +849,13 @@ func (c *SlackConnection) JoinChannel(inChannel *gateway.Channel) (*gateway.Chan
 		Created:    joinChannelBuffer.Channel.Created,
 		IsMember:   joinChannelBuffer.Channel.IsMember,
 		IsArchived: joinChannelBuffer.Channel.IsArchived,
+
+		Topic:       inChannel.Topic,
+		Purpose:     inChannel.Purpose,
+		MemberCount: inChannel.MemberCount,
+	}
+	if !inChannel.IsMember && channel.IsMember {
+		channel.MemberCount += 1
 	}
 
 	// Update the channel in the locally stored channels collection
@@ -680,16 +886,12 @@ func (c *SlackConnection) LeaveChannel(channel *gateway.Channel) (*gateway.Chann
 	url := "https://slack.com/api/channels.leave?token=" + c.token
 	url += "&channel=" + channel.Id
 
-	resp, err := httpClient.Get(url)
-	if err != nil {
-		return nil, err
-	}
-
-	body, _ := ioutil.ReadAll(resp.Body)
 	var leaveChannelBuffer struct {
 		NotInChannel bool `json:"not_in_channel"`
 	}
-	json.Unmarshal(body, &leaveChannelBuffer)
+	if err := c.get(url, &leaveChannelBuffer); err != nil {
+		return nil, err
+	}
 
 	// If the user wasn't in the channel originally, then error.
 	if leaveChannelBuffer.NotInChannel {
@@ -697,6 +899,9 @@ func (c *SlackConnection) LeaveChannel(channel *gateway.Channel) (*gateway.Chann
 	}
 
 	// Update the channel
+	if channel.IsMember && channel.MemberCount > 0 {
+		channel.MemberCount -= 1
+	}
 	channel.IsMember = false
 
 	// Update the channel in the locally stored channels collection
@@ -0,0 +1,547 @@
+package gatewaySlack_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestFetchChannelsPaginates(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		httpmock.NewStringResponder(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.list?token=token&types=public_channel,private_channel,mpim,im&limit=200",
+		httpmock.NewStringResponder(200, `{"ok": true, "channels": [
+			{"id": "C1", "name": "general", "creator": "user-id", "is_member": true, "num_members": 42,
+			 "topic": {"value": "Lunch at noon"}, "purpose": {"value": "Company-wide chatter"}},
+			{"id": "G1", "name": "secret", "creator": "user-id", "is_private": true}
+		], "response_metadata": {"next_cursor": "page-two"}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.list?token=token&types=public_channel,private_channel,mpim,im&limit=200&cursor=page-two",
+		httpmock.NewStringResponder(200, `{"ok": true, "channels": [
+			{"id": "D1", "is_im": true, "user": "user-id"},
+			{"id": "G2", "name": "mpdm-a--b-1", "creator": "user-id", "is_mpim": true, "is_private": true}
+		], "response_metadata": {"next_cursor": ""}}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelf(gateway.User{Id: "self-id", Name: "me"})
+	channels, err := conn.FetchChannels()
+	if err != nil {
+		t.Fatalf("Error fetching channels: %s", err)
+	}
+
+	if len(channels) != 4 {
+		t.Fatalf("Expected channels from both pages, got %+v", channels)
+	}
+	if channels[0].MemberCount != 42 || channels[0].Topic != "Lunch at noon" || channels[0].Purpose != "Company-wide chatter" {
+		t.Errorf("Channel's member count, topic, and purpose weren't parsed: %+v", channels[0])
+	}
+	if channels[1].SubType != gateway.TYPE_CHANNEL || !channels[1].IsPrivate || !channels[1].IsMember {
+		t.Errorf("Private channel wasn't a private channel that the user is a member of: %+v", channels[1])
+	}
+	if channels[2].SubType != gateway.TYPE_DIRECT_MESSAGE || channels[2].Name != "im-me-my-user" {
+		t.Errorf("Im wasn't converted to a direct message: %+v", channels[2])
+	}
+	if channels[2].Label() != "@my-user" || channels[2].DirectMessageUser != "user-id" {
+		t.Errorf("Direct message should be shown as the other user, got %s", channels[2].Label())
+	}
+	if channels[3].SubType != gateway.TYPE_GROUP_DIRECT_MESSAGE {
+		t.Errorf("Mpim wasn't converted to a group direct message: %+v", channels[3])
+	}
+	if channels[3].Label() != "a, b" || channels[3].Name != "mpdm-a--b-1" {
+		t.Errorf("Group direct message should be shown as its members, got %s (%s)", channels[3].Label(), channels[3].Name)
+	}
+}
+
+func TestFetchUsersPaginatesAndSkipsDeletedUsers(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.list?token=token&limit=200",
+		httpmock.NewStringResponder(200, `{"ok": true, "members": [
+			{"id": "U1", "name": "alice", "profile": {"real_name": "Alice Smith"}},
+			{"id": "U2", "name": "gone", "deleted": true}
+		], "response_metadata": {"next_cursor": "page-two"}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.list?token=token&limit=200&cursor=page-two",
+		httpmock.NewStringResponder(200, `{"ok": true, "members": [
+			{"id": "U3", "name": "bob"}
+		], "response_metadata": {"next_cursor": ""}}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	users, err := conn.FetchUsers()
+	if err != nil {
+		t.Fatalf("Error fetching users: %s", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "alice" || users[0].RealName != "Alice Smith" || users[1].Name != "bob" {
+		t.Errorf("Expected alice and bob, got %+v", users)
+	}
+	if len(conn.Users()) != 2 {
+		t.Errorf("Fetched users weren't remembered: %+v", conn.Users())
+	}
+
+	// Fetched users are cached, so looking them up again doesn't make a request.
+	if user, err := conn.UserById("U3"); err != nil || user.Name != "bob" {
+		t.Errorf("Fetched user wasn't cached: %+v %s", user, err)
+	}
+}
+
+func TestFetchChannelMessagesKnowsWhenHistoryEnds(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		httpmock.NewStringResponder(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.history?token=token&channel=C1&limit=100",
+		httpmock.NewStringResponder(200, `{"ok": true, "messages": [
+			{"ts": "1500000002.000000", "user": "user-id", "text": "newer"},
+			{"ts": "1500000001.000000", "user": "user-id", "text": "older"}
+		], "has_more": true, "response_metadata": {"next_cursor": "page-two"}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.history?token=token&channel=C1&limit=100&cursor=page-two",
+		httpmock.NewStringResponder(200, `{"ok": true, "messages": [
+			{"ts": "1500000000.000000", "user": "user-id", "text": "oldest"}
+		], "has_more": false}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	channel := gateway.Channel{Id: "C1", Name: "general"}
+
+	if !conn.HasMoreMessageHistory(channel) {
+		t.Errorf("A channel that hasn't been fetched should be assumed to have more history")
+	}
+
+	messages, err := conn.FetchChannelMessages(channel, nil, nil)
+	if err != nil {
+		t.Fatalf("Error fetching first page: %s", err)
+	}
+	if len(messages) != 2 || messages[0].Text != "older" {
+		t.Errorf("First page wasn't fetched oldest first: %+v", messages)
+	}
+	if !conn.HasMoreMessageHistory(channel) {
+		t.Errorf("Channel should have more history after the first page")
+	}
+
+	// Scrolling back from the oldest message uses the cursor slack returned.
+	messages, err = conn.FetchChannelMessages(channel, &messages[0].Hash, nil)
+	if err != nil {
+		t.Fatalf("Error fetching second page: %s", err)
+	}
+	if len(messages) != 1 || messages[0].Text != "oldest" {
+		t.Errorf("Second page wasn't fetched: %+v", messages)
+	}
+	if conn.HasMoreMessageHistory(channel) {
+		t.Errorf("Channel shouldn't have more history after the last page")
+	}
+}
+
+func TestFetchChannelMessagesAfterTimestamp(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		httpmock.NewStringResponder(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`))
+
+	// The day after the timestamp has too many messages to fit in a page, so a smaller window
+	// should be looked at.
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.history?token=token&channel=C1&limit=100&latest=1500086400.000000&oldest=1500000000.000000",
+		httpmock.NewStringResponder(200, `{"ok": true, "messages": [
+			{"ts": "1500080000.000000", "user": "user-id", "text": "much later"}
+		], "has_more": true}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.history?token=token&channel=C1&limit=100&latest=1500021600.000000&oldest=1500000000.000000",
+		httpmock.NewStringResponder(200, `{"ok": true, "messages": [
+			{"ts": "1500000002.000000", "user": "user-id", "text": "second"},
+			{"ts": "1500000001.000000", "user": "user-id", "text": "first"}
+		], "has_more": false}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	oldest := "1500000000.000000"
+	messages, err := conn.FetchChannelMessages(gateway.Channel{Id: "C1"}, nil, &oldest)
+	if err != nil {
+		t.Fatalf("Error fetching messages: %s", err)
+	}
+	if len(messages) != 2 || messages[0].Text != "first" || messages[1].Text != "second" {
+		t.Errorf("Messages directly after the timestamp weren't fetched: %+v", messages)
+	}
+}
+
+func TestFetchChannelMessagesAfterTimestampNarrowsWindow(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		httpmock.NewStringResponder(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`))
+
+	// The day after the timestamp has too many messages, but the six hours after it have none, so
+	// the messages must be somewhere in between.
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.history?token=token&channel=C1&limit=100&latest=1500086400.000000&oldest=1500000000.000000",
+		httpmock.NewStringResponder(200, `{"ok": true, "messages": [
+			{"ts": "1500080000.000000", "user": "user-id", "text": "much later"}
+		], "has_more": true}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.history?token=token&channel=C1&limit=100&latest=1500021600.000000&oldest=1500000000.000000",
+		httpmock.NewStringResponder(200, `{"ok": true, "messages": [], "has_more": false}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.history?token=token&channel=C1&limit=100&latest=1500054000.000000&oldest=1500000000.000000",
+		httpmock.NewStringResponder(200, `{"ok": true, "messages": [
+			{"ts": "1500030000.000000", "user": "user-id", "text": "first"}
+		], "has_more": false}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	oldest := "1500000000.000000"
+	messages, err := conn.FetchChannelMessages(gateway.Channel{Id: "C1"}, nil, &oldest)
+	if err != nil {
+		t.Fatalf("Error fetching messages: %s", err)
+	}
+	if len(messages) != 1 || messages[0].Text != "first" {
+		t.Errorf("Messages directly after the timestamp weren't fetched: %+v", messages)
+	}
+}
+
+func TestFetchChannelMessagesAfterTimestampPagesThroughBusyWindow(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.info?token=token&user=user-id",
+		httpmock.NewStringResponder(200, `{"ok": true, "user": {"id": "user-id", "name": "my-user"}}`))
+
+	// Every window has more than a page of messages, so once the window can't get any smaller, the
+	// smallest one should be paged through until its oldest page.
+	var smallestWindow string
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		query := req.URL.Query()
+		if query.Get("cursor") == "older" {
+			if query.Get("latest") != smallestWindow {
+				t.Errorf("Paged through a window other than the smallest one: %s", req.URL)
+			}
+			return httpmock.NewStringResponse(200, `{"ok": true, "messages": [
+				{"ts": "1500000001.000000", "user": "user-id", "text": "first"}
+			], "has_more": false}`), nil
+		}
+
+		smallestWindow = query.Get("latest")
+		return httpmock.NewStringResponse(200, `{"ok": true, "messages": [
+			{"ts": "1500000002.000000", "user": "user-id", "text": "second"}
+		], "has_more": true, "response_metadata": {"next_cursor": "older"}}`), nil
+	})
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	oldest := "1500000000.000000"
+	messages, err := conn.FetchChannelMessages(gateway.Channel{Id: "C1"}, nil, &oldest)
+	if err != nil {
+		t.Fatalf("Error fetching messages: %s", err)
+	}
+	if len(messages) != 1 || messages[0].Text != "first" {
+		t.Errorf("Oldest page of messages wasn't fetched: %+v", messages)
+	}
+}
+
+func TestFetchFileIsAuthenticated(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://files.slack.com/files-pri/T1-F1/image.png",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer token" {
+				return httpmock.NewStringResponse(403, `Forbidden`), nil
+			}
+			return httpmock.NewStringResponse(200, `image data`), nil
+		})
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	body, _, err := conn.FetchFile(&gateway.File{Name: "image.png", PrivateUrl: "https://files.slack.com/files-pri/T1-F1/image.png"})
+	if err != nil {
+		t.Fatalf("Error fetching file: %s", err)
+	}
+	defer body.Close()
+
+	data, _ := ioutil.ReadAll(body)
+	if string(data) != "image data" {
+		t.Errorf("Wrong file contents: %s", data)
+	}
+}
+
+func TestPostFileStreamsMultipartBody(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	var contentLength int64
+	httpmock.RegisterResponder("POST", "https://slack.com/api/files.upload?token=token&channels=C2&title=My+Notes&initial_comment=Take+a+look",
+		func(req *http.Request) (*http.Response, error) {
+			data, _ := ioutil.ReadAll(req.Body)
+			body = string(data)
+			contentLength = req.ContentLength
+			return httpmock.NewStringResponse(200, `{"ok": true}`), nil
+		})
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	var sent int64
+	err := conn.PostFile(gateway.FileUpload{
+		Filename:       "notes.txt",
+		Title:          "My Notes",
+		InitialComment: "Take a look",
+		Channel:        &gateway.Channel{Id: "C2", Name: "random"},
+		Content:        strings.NewReader("Hello World"),
+		Size:           11,
+		Progress:       func(bytes int64) { sent = bytes },
+	})
+	if err != nil {
+		t.Fatalf("Error posting file: %s", err)
+	}
+
+	if !strings.Contains(body, `filename="notes.txt"`) || !strings.Contains(body, "Hello World") {
+		t.Errorf("File wasn't sent in the multipart body: %s", body)
+	}
+	if contentLength != int64(len(body)) {
+		t.Errorf("Content length was %d, but %d bytes were sent", contentLength, len(body))
+	}
+	if sent != 11 {
+		t.Errorf("Progress wasn't reported, %d bytes reported as sent", sent)
+	}
+}
+
+func TestPostFileReturnsSlackErrors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://slack.com/api/files.upload?token=token&channels=C1",
+		httpmock.NewStringResponder(200, `{"ok": false, "error": "not_in_channel"}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	err := conn.PostFile(gateway.FileUpload{
+		Filename: "notes.txt",
+		Channel:  &gateway.Channel{Id: "C1", Name: "general"},
+		Content:  strings.NewReader("Hello World"),
+		Size:     -1,
+	})
+	if !gatewaySlack.IsSlackError(err, "not_in_channel") {
+		t.Errorf("Expected a not_in_channel slack error, got %#v", err)
+	}
+}
+
+func TestFetchChannelInfoFetchesTopicPurposeAndMembers(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.info?token=token&channel=C1&include_num_members=true",
+		httpmock.NewStringResponder(200, `{"ok": true, "channel": {
+			"id": "C1", "num_members": 3, "topic": {"value": "Lunch"}, "purpose": {"value": "Food"}
+		}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.members?token=token&channel=C1&limit=200",
+		httpmock.NewStringResponder(200, `{"ok": true, "members": ["U1", "U2"], "response_metadata": {"next_cursor": "page-two"}}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.members?token=token&channel=C1&limit=200&cursor=page-two",
+		httpmock.NewStringResponder(200, `{"ok": true, "members": ["U3"], "response_metadata": {"next_cursor": ""}}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetChannels([]gateway.Channel{{Id: "C1", Name: "general"}})
+	conn.SetSelectedChannel(&conn.Channels()[0])
+
+	channel, err := conn.FetchChannelInfo(&conn.Channels()[0])
+	if err != nil {
+		t.Fatalf("Error fetching channel info: %s", err)
+	}
+	if channel.Topic != "Lunch" || channel.Purpose != "Food" || channel.MemberCount != 3 || len(channel.Members) != 3 {
+		t.Errorf("Channel info wasn't parsed: %+v", channel)
+	}
+	if selected := conn.SelectedChannel(); selected.Topic != "Lunch" || conn.Channels()[0].Topic != "Lunch" {
+		t.Errorf("Channel wasn't updated in the connection: %+v", selected)
+	}
+}
+
+func TestSetChannelTopic(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.setTopic?token=token&channel=C1&topic=Lunch+%26+games",
+		httpmock.NewStringResponder(200, `{"ok": true}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetChannels([]gateway.Channel{{Id: "C1", Name: "general"}})
+
+	channel, err := conn.SetChannelTopic(&conn.Channels()[0], "Lunch & games")
+	if err != nil {
+		t.Fatalf("Error setting topic: %s", err)
+	}
+	if channel.Topic != "Lunch & games" || conn.Channels()[0].Topic != "Lunch & games" {
+		t.Errorf("Topic wasn't updated: %+v", channel)
+	}
+}
+
+func TestOpenConversationWithSeveralUsersIsAGroupDirectMessage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.open?token=token&users=U1,U2&return_im=true",
+		httpmock.NewStringResponder(200, `{"ok": true, "channel": {"id": "G1", "name": "mpdm-me--alice--bob-1"}}`))
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelf(gateway.User{Id: "U0", Name: "me"})
+	channel, err := conn.OpenConversation([]*gateway.User{{Id: "U1", Name: "alice"}, {Id: "U2", Name: "bob"}})
+	if err != nil {
+		t.Fatalf("Error opening conversation: %s", err)
+	}
+
+	if channel.SubType != gateway.TYPE_GROUP_DIRECT_MESSAGE || channel.Name != "mpdm-me--alice--bob-1" || !channel.IsMember {
+		t.Errorf("Expected a group direct message, got %+v", channel)
+	}
+	if len(conn.Channels()) != 1 || conn.Channels()[0].Id != "G1" {
+		t.Errorf("The conversation wasn't added to the channels: %+v", conn.Channels())
+	}
+
+	// Opening it again doesn't add it twice.
+	if _, err := conn.OpenConversation([]*gateway.User{{Id: "U1", Name: "alice"}, {Id: "U2", Name: "bob"}}); err != nil || len(conn.Channels()) != 1 {
+		t.Errorf("Expected one conversation, got %+v (%v)", conn.Channels(), err)
+	}
+}
+
+func TestParseMessageWithBlocks(t *testing.T) {
+	var raw map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"ts": "1500000000.000000",
+		"username": "deploy-bot",
+		"text": "Deploy finished",
+		"blocks": [
+			{"type": "header", "block_id": "b1", "text": {"type": "plain_text", "text": "Deploy finished"}},
+			{"type": "section", "block_id": "b2", "text": {"type": "mrkdwn", "text": "*api* is live"},
+			 "fields": [{"type": "mrkdwn", "text": "*Env:* prod"}],
+			 "accessory": {"type": "button", "action_id": "logs", "text": {"type": "plain_text", "text": "Logs"}, "url": "https://example.com/logs"}},
+			{"type": "rich_text", "block_id": "b3", "elements": [{"type": "rich_text_section", "elements": [{"type": "text", "text": "dropped"}]}]},
+			{"type": "context", "block_id": "b4", "elements": [{"type": "mrkdwn", "text": "by @me"}, {"type": "image", "image_url": "https://example.com/me.png", "alt_text": "me"}]},
+			{"type": "divider", "block_id": "b5"},
+			{"type": "actions", "block_id": "b6", "elements": [
+				{"type": "button", "action_id": "approve", "value": "yes", "text": {"type": "plain_text", "text": "Approve"}},
+				{"type": "static_select", "action_id": "env", "placeholder": {"type": "plain_text", "text": "Environment"}}
+			]}
+		]
+	}`), &raw)
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	message, err := conn.ParseMessage(raw, map[string]*gateway.User{})
+	if err != nil {
+		t.Fatalf("Error parsing message: %s", err)
+	}
+
+	// The `rich_text` block can't be drawn, so it's dropped.
+	expected := []gateway.Block{
+		{Type: "header", Id: "b1", Text: &gateway.BlockElement{Type: "plain_text", Text: "Deploy finished"}},
+		{
+			Type:      "section",
+			Id:        "b2",
+			Text:      &gateway.BlockElement{Type: "mrkdwn", Text: "*api* is live"},
+			Fields:    []gateway.BlockElement{{Type: "mrkdwn", Text: "*Env:* prod"}},
+			Accessory: &gateway.BlockElement{Type: "button", ActionId: "logs", Text: "Logs", Url: "https://example.com/logs"},
+		},
+		{Type: "context", Id: "b4", Elements: []gateway.BlockElement{
+			{Type: "mrkdwn", Text: "by @me"},
+			{Type: "image", ImageUrl: "https://example.com/me.png", AltText: "me"},
+		}},
+		{Type: "divider", Id: "b5"},
+		{Type: "actions", Id: "b6", Elements: []gateway.BlockElement{
+			{Type: "button", ActionId: "approve", Value: "yes", Text: "Approve"},
+			{Type: "static_select", ActionId: "env", Placeholder: "Environment"},
+		}},
+	}
+	if !reflect.DeepEqual(message.Blocks, expected) {
+		t.Errorf("Blocks weren't parsed properly:\n%+v\n!=\n%+v", message.Blocks, expected)
+	}
+}
+
+func TestSendMessageActionPressesBlockKitButton(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form url.Values
+	httpmock.RegisterResponder("POST", "https://slack.com/api/blocks.actions?token=token",
+		func(req *http.Request) (*http.Response, error) {
+			req.ParseForm()
+			form = req.PostForm
+			return httpmock.NewStringResponse(200, `{"ok": true}`), nil
+		})
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelectedChannel(&gateway.Channel{Id: "C1", Name: "deploys"})
+	err := conn.SendMessageAction(
+		gateway.Message{Hash: "1500000000.000000", BotId: "B1"},
+		gateway.MessageAction{Type: "static_select", ActionId: "env", BlockId: "b6", Text: "Production", Value: "prod"},
+	)
+	if err != nil {
+		t.Fatalf("Error sending action: %s", err)
+	}
+
+	var actions []map[string]interface{}
+	var container map[string]interface{}
+	json.Unmarshal([]byte(form.Get("actions")), &actions)
+	json.Unmarshal([]byte(form.Get("container")), &container)
+
+	expectedActions := []map[string]interface{}{{
+		"action_id":       "env",
+		"block_id":        "b6",
+		"type":            "static_select",
+		"selected_option": map[string]interface{}{"text": map[string]interface{}{"type": "plain_text", "text": "Production"}, "value": "prod"},
+	}}
+	if !reflect.DeepEqual(actions, expectedActions) {
+		t.Errorf("Wrong actions sent: %+v", actions)
+	}
+	if container["message_ts"] != "1500000000.000000" || container["channel_id"] != "C1" {
+		t.Errorf("Wrong container sent: %+v", container)
+	}
+	if form.Get("service_id") != "B1" {
+		t.Errorf("Wrong bot sent, %s", form.Get("service_id"))
+	}
+}
+
+func TestSendMessageActionPressesAttachmentButton(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var payload map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.attachmentAction?token=token",
+		func(req *http.Request) (*http.Response, error) {
+			req.ParseForm()
+			json.Unmarshal([]byte(req.PostForm.Get("payload")), &payload)
+			return httpmock.NewStringResponse(200, `{"ok": true}`), nil
+		})
+
+	var raw map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"ts": "1500000000.000000",
+		"bot_id": "B1",
+		"username": "approve-bot",
+		"text": "Can I deploy?",
+		"attachments": [{"callback_id": "deploy-42", "fallback": "Approve?", "actions": [
+			{"name": "approve", "type": "button", "text": "Approve", "value": "yes"}
+		]}]
+	}`), &raw)
+
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelectedChannel(&gateway.Channel{Id: "C1", Name: "deploys"})
+	message, err := conn.ParseMessage(raw, map[string]*gateway.User{})
+	if err != nil {
+		t.Fatalf("Error parsing message: %s", err)
+	}
+
+	actions := message.Actions()
+	if len(actions) != 1 || actions[0].Label != "Approve" {
+		t.Fatalf("Expected the attachment's button, got %+v", actions)
+	}
+	if err := conn.SendMessageAction(*message, actions[0]); err != nil {
+		t.Fatalf("Error sending action: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"actions":       []interface{}{map[string]interface{}{"name": "approve", "type": "button", "value": "yes"}},
+		"attachment_id": "1",
+		"callback_id":   "deploy-42",
+		"channel_id":    "C1",
+		"message_ts":    "1500000000.000000",
+	}
+	if !reflect.DeepEqual(payload, expected) {
+		t.Errorf("Wrong payload sent:\n%+v\n!=\n%+v", payload, expected)
+	}
+}
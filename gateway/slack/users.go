@@ -0,0 +1,91 @@
+package gatewaySlack
+
+import (
+	"log"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// Fetch every user on the team. Users are also cached, so that they don't have to be looked up
+// one at a time with UserById.
+func (c *SlackConnection) FetchUsers() ([]gateway.User, error) {
+	var users []gateway.User
+
+	log.Printf("Fetching list of users for team %s", c.Team().Name)
+
+	cursor := ""
+	for {
+		url := "https://slack.com/api/users.list?token=" + c.token
+		url += "&limit=" + pageSize
+		if len(cursor) > 0 {
+			url += "&cursor=" + cursor
+		}
+
+		var slackUserBuffer struct {
+			Members []struct {
+				Id             string `json:"id"`
+				Name           string `json:"name"`
+				Color          string `json:"color"`
+				Deleted        bool   `json:"deleted"`
+				Timezone       string `json:"tz"`
+				TimezoneLabel  string `json:"tz_label"`
+				TimezoneOffset int    `json:"tz_offset"`
+				Profile        struct {
+					Status      string `json:"status_text"`
+					StatusEmoji string `json:"status_emoji"`
+					Title       string `json:"title"`
+					RealName    string `json:"real_name"`
+					Email       string `json:"email"`
+					Phone       string `json:"phone"`
+					Skype       string `json:"skype"`
+					Image       string `json:"image_24"`
+				} `json:"profile"`
+			} `json:"members"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := c.get(url, &slackUserBuffer); err != nil {
+			return nil, err
+		}
+
+		for _, member := range slackUserBuffer.Members {
+			if member.Deleted {
+				continue
+			}
+
+			user := gateway.User{
+				Id:       member.Id,
+				Name:     member.Name,
+				Color:    member.Color,
+				Avatar:   member.Profile.Image,
+				Status:   member.Profile.Status,
+				RealName: member.Profile.RealName,
+				Email:    member.Profile.Email,
+				Skype:    member.Profile.Skype,
+				Phone:    member.Profile.Phone,
+
+				Title:          member.Profile.Title,
+				StatusEmoji:    member.Profile.StatusEmoji,
+				Timezone:       member.Timezone,
+				TimezoneLabel:  member.TimezoneLabel,
+				TimezoneOffset: member.TimezoneOffset,
+			}
+			users = append(users, user)
+			c.userCache[user.Id] = user
+		}
+
+		cursor = slackUserBuffer.ResponseMetadata.NextCursor
+		if len(cursor) == 0 {
+			break
+		}
+	}
+
+	c.users = users
+	return users, nil
+}
+
+// Every user on the team, as of the last call to FetchUsers.
+func (c *SlackConnection) Users() []gateway.User {
+	return c.users
+}
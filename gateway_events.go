@@ -68,6 +68,9 @@ func gatewayEvents(state *State, term *frontend.TerminalDisplay) {
 			// When a message is received for the selected channel, add to the message history
 			// "message" events come in when the gateway receives a message sent by someone else.
 			case "message":
+				// Channels shown in other panes are kept up to date too.
+				state.Panes.AddMessage(conn, event.Data, cachedUsers)
+
 				if channel := conn.SelectedChannel(); event.Data["channel"] == channel.Id {
 					if event.Data["subtype"] == "message_deleted" {
 						// If a message was deleted, then delete the message from the message history
@@ -76,6 +79,13 @@ func gatewayEvents(state *State, term *frontend.TerminalDisplay) {
 								conn.DeleteMessageHistory(index)
 							}
 						}
+					} else if event.Data["subtype"] == "message_changed" {
+						// If a message was changed (ie, by the bot that posted it, after one of its
+						// buttons was pressed), then replace it in the message history
+						history := conn.MessageHistory()
+						if ReplaceChangedMessage(history, conn, event.Data, cachedUsers) {
+							conn.SetMessageHistory(history)
+						}
 					} else {
 						// JUST A NORMAL MESSAGE!
 
@@ -131,7 +141,7 @@ func gatewayEvents(state *State, term *frontend.TerminalDisplay) {
 								ShouldMessageNotifyUser(message.Text, messageChannel, self) {
 								text := strings.Replace(message.Text, "<", "", -1)
 								text = strings.Replace(text, ">", "", -1)
-								Notification(messageChannel.Name, text)
+								Notification(messageChannel.Label(), text)
 							}
 
 							// If an unconfirmed message was found that is thought to be the same
@@ -146,8 +156,11 @@ func gatewayEvents(state *State, term *frontend.TerminalDisplay) {
 							}
 
 							// Add message to history, if message was posted to the active channel.
+							// If the history is showing the past, the message will be fetched
+							// when the user scrolls forward to it.
 							if selectedChannel := conn.SelectedChannel(); selectedChannel != nil &&
-								messageChannel.Id == selectedChannel.Id {
+								messageChannel.Id == selectedChannel.Id &&
+								!conn.HasNewerMessageHistory() {
 								conn.AppendMessageHistory(*message)
 							}
 
@@ -158,7 +171,11 @@ func gatewayEvents(state *State, term *frontend.TerminalDisplay) {
 						}
 					}
 				} else {
-					log.Println("Channel value", channel)
+					// A message in another channel. If it isn't shown in another pane, remember that
+					// it hasn't been read yet.
+					if channelId, ok := event.Data["channel"].(string); !ok || !state.Panes.Showing(conn, channelId) {
+						MarkMessageUnread(state, conn, event.Data)
+					}
 				}
 
 				// case "reaction_added":
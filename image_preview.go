@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	// Register the image formats that can be previewed.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+)
+
+// Never download images larger than this to preview them.
+const maxImagePreviewBytes = 10 * 1024 * 1024
+
+// Once decoded, images are shrunk so neither side is larger than this. Previews are never larger
+// than the terminal, so this keeps memory use down and rendering quick.
+const maxImagePreviewPixels = 400
+
+var imagePreviewMimetypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// Previews of images posted in messages. Images are downloaded in the background the first time
+// they are rendered, cached on disk, and kept in memory once they've been decoded.
+type ImagePreviews struct {
+	mutex  sync.Mutex
+	images map[string]image.Image
+
+	// Images that are being loaded, or failed to load. Either way, they shouldn't be loaded again.
+	loading map[string]bool
+}
+
+func NewImagePreviews() *ImagePreviews {
+	return &ImagePreviews{
+		images:  make(map[string]image.Image),
+		loading: make(map[string]bool),
+	}
+}
+
+func PathToImageCache() string {
+	return PathToCache() + "images/"
+}
+
+// Can the given file be previewed?
+func IsPreviewableImage(file *gateway.File) bool {
+	if file == nil || len(file.Id) == 0 || file.Size > maxImagePreviewBytes {
+		return false
+	}
+	if len(file.Mimetype) > 0 {
+		return imagePreviewMimetypes[file.Mimetype]
+	}
+
+	switch strings.ToLower(path.Ext(file.Name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Return the preview of an image, or nil if it isn't available. The first time an image is asked
+// for it's loaded in the background, and `loaded` is called once it's ready.
+func (p *ImagePreviews) Preview(conn gateway.Connection, file *gateway.File, loaded func()) image.Image {
+	if !IsPreviewableImage(file) {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if img, ok := p.images[file.Id]; ok {
+		return img
+	}
+	if p.loading[file.Id] {
+		return nil
+	}
+	p.loading[file.Id] = true
+
+	go func() {
+		img, err := loadImagePreview(conn, file)
+		if err != nil {
+			log.Printf("Couldn't preview %s: %s", file.Name, err)
+			return
+		}
+
+		p.mutex.Lock()
+		p.images[file.Id] = img
+		p.mutex.Unlock()
+		loaded()
+	}()
+
+	return nil
+}
+
+// Read an image from the cache, or download it (and cache it) if it isn't there.
+func loadImagePreview(conn gateway.Connection, file *gateway.File) (image.Image, error) {
+	cachePath := PathToImageCache() + file.Id
+
+	data, err := ioutil.ReadFile(cachePath)
+	if err != nil {
+		body, _, err := conn.FetchFile(file)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		// Read one byte past the limit to tell if the image is too large.
+		data, err = ioutil.ReadAll(io.LimitReader(body, maxImagePreviewBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxImagePreviewBytes {
+			return nil, errors.New("Image is too large to preview.")
+		}
+
+		if err := os.MkdirAll(PathToImageCache(), 0755); err == nil {
+			if err := ioutil.WriteFile(cachePath, data, 0644); err != nil {
+				log.Printf("Couldn't cache %s: %s", file.Name, err)
+			}
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Shrink large images, keeping their aspect ratio.
+	bounds := img.Bounds()
+	if bounds.Dx() > maxImagePreviewPixels || bounds.Dy() > maxImagePreviewPixels {
+		width, height := maxImagePreviewPixels, maxImagePreviewPixels
+		if bounds.Dx() > bounds.Dy() {
+			height = bounds.Dy() * maxImagePreviewPixels / bounds.Dx()
+		} else {
+			width = bounds.Dx() * maxImagePreviewPixels / bounds.Dy()
+		}
+		if width < 1 {
+			width = 1
+		}
+		if height < 1 {
+			height = 1
+		}
+		img = frontend.ScaleImage(img, width, height)
+	}
+
+	return img, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// How many messages are remembered for each channel.
+const inputHistorySize = 100
+
+func PathToInputHistory() string {
+	return PathToCache() + "history"
+}
+
+// Messages and commands sent in each channel, so that they can be brought back into the command
+// bar with the up and down arrows. Pass an empty path to keep the history only in memory.
+type InputHistory struct {
+	mutex   sync.Mutex
+	entries map[string][]string
+	path    string
+
+	// While moving through the history, how far back the command bar is (0 is the command the
+	// user was typing), and what the user was typing before they started.
+	position int
+	draft    []rune
+}
+
+func NewInputHistory(path string) *InputHistory {
+	return &InputHistory{entries: make(map[string][]string), path: path}
+}
+
+// Read the history saved in the given file. If it can't be read, start with an empty history.
+func LoadInputHistory(path string) *InputHistory {
+	history := NewInputHistory(path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return history
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&history.entries); err != nil {
+		log.Printf("Couldn't read input history %s: %s", path, err)
+		history.entries = make(map[string][]string)
+	}
+	return history
+}
+
+// The key that history is stored under for the active channel.
+func InputHistoryKey(state *State) string {
+	if state.ActiveConnection() == nil || state.ActiveConnection().SelectedChannel() == nil {
+		return ""
+	}
+	return state.ActiveConnection().Name() + "/" + state.ActiveConnection().SelectedChannel().Name
+}
+
+// Remember that the given text was sent, then save the history.
+func (h *InputHistory) Add(key string, text string) {
+	h.mutex.Lock()
+	h.position = 0
+	h.draft = nil
+
+	entries := h.entries[key]
+	if len(text) == 0 || (len(entries) > 0 && entries[len(entries)-1] == text) {
+		h.mutex.Unlock()
+		return
+	}
+	entries = append(entries, text)
+	if len(entries) > inputHistorySize {
+		entries = entries[len(entries)-inputHistorySize:]
+	}
+	h.entries[key] = entries
+	h.mutex.Unlock()
+
+	if err := h.Save(); err != nil {
+		log.Printf("Couldn't save input history: %s", err)
+	}
+}
+
+// Everything sent with the given key, oldest first.
+func (h *InputHistory) Entries(key string) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]string{}, h.entries[key]...)
+}
+
+// Move one entry further back in the history. `current` is what's in the command bar, and is
+// restored once the user moves forward past the newest entry. Returns false at the oldest entry.
+func (h *InputHistory) Previous(key string, current []rune) ([]rune, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entries := h.entries[key]
+	if h.position >= len(entries) {
+		return nil, false
+	}
+	if h.position == 0 {
+		h.draft = append([]rune{}, current...)
+	}
+	h.position += 1
+	return []rune(entries[len(entries)-h.position]), true
+}
+
+// Move one entry forward in the history. Returns false if the user isn't moving through the
+// history.
+func (h *InputHistory) Next(key string) ([]rune, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.position == 0 {
+		return nil, false
+	}
+	h.position -= 1
+	if h.position == 0 {
+		return h.draft, true
+	}
+	entries := h.entries[key]
+	return []rune(entries[len(entries)-h.position]), true
+}
+
+// Stop moving through the history.
+func (h *InputHistory) Reset() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.position = 0
+	h.draft = nil
+}
+
+func (h *InputHistory) Save() error {
+	if len(h.path) == 0 {
+		return nil
+	}
+
+	h.mutex.Lock()
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(h.entries)
+	h.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.path, buf.Bytes(), 0644)
+}
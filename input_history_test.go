@@ -0,0 +1,41 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/1egoman/slick"
+)
+
+func TestInputHistoryIsSavedAndLoaded(t *testing.T) {
+	directory, err := ioutil.TempDir("", "slick-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "history")
+
+	history := NewInputHistory(path)
+	history.Add("team/general", "hello")
+	history.Add("team/general", "world")
+	history.Add("team/general", "world") // Repeated messages are only remembered once
+	history.Add("team/random", "hi")
+
+	loaded := LoadInputHistory(path)
+	if entries := loaded.Entries("team/general"); !reflect.DeepEqual(entries, []string{"hello", "world"}) {
+		t.Errorf("Loaded the wrong history for #general: %v", entries)
+	}
+	if entries := loaded.Entries("team/random"); !reflect.DeepEqual(entries, []string{"hi"}) {
+		t.Errorf("Loaded the wrong history for #random: %v", entries)
+	}
+}
+
+func TestInputHistoryIsEmptyWithoutAFile(t *testing.T) {
+	history := LoadInputHistory(filepath.Join(os.TempDir(), "slick-history-that-doesnt-exist"))
+	if entries := history.Entries("team/general"); len(entries) != 0 {
+		t.Errorf("Expected no history, got %v", entries)
+	}
+}
@@ -107,6 +107,11 @@ func OnMessageInteraction(state *State, key rune, quantity int) {
 			if err != nil {
 				state.Status.Errorf(err.Error())
 			}
+		case 'D': // Download a file
+			err := GetCommand("Download").Handler([]string{"__INTERNAL__"}, state)
+			if err != nil {
+				state.Status.Errorf(err.Error())
+			}
 		case 'l': // Open link in attachment
 			err := GetCommand("OpenAttachmentLink").Handler(
 				[]string{"__INTERNAL__", fmt.Sprintf("%d", quantity)},
@@ -147,6 +152,22 @@ func OnMessageInteraction(state *State, key rune, quantity int) {
 			if err != nil {
 				state.Status.Errorf(err.Error())
 			}
+		case 'u': // Show the profile of the message's sender
+			err := GetCommand("Whois").Handler(
+				[]string{"__INTERNAL__"},
+				state,
+			)
+			if err != nil {
+				state.Status.Errorf(err.Error())
+			}
+		case 'i': // Press a button or pick an option in a menu in the message
+			err := GetCommand("Interact").Handler(
+				[]string{"__INTERNAL__"},
+				state,
+			)
+			if err != nil {
+				state.Status.Errorf(err.Error())
+			}
 		}
 	} else {
 		state.Status.Printf("No message selected.")
@@ -265,6 +286,7 @@ func OnCommandExecuted(state *State, term *frontend.TerminalDisplay, quit chan s
 	// Since these commands need access to "privileged" things, they are harded here.
 	// `quit` - needs to be able to close the `quit` channel
 	// `require` - needs `term` to pass to `ParseScript`.
+	// `compose` - needs `term` to give the terminal to the user's editor.
 	if arg0 == "quit" || arg0 == "q" {
 		// :q or :quit closes the app, and is a special case.
 		log.Println("CLOSE QUIT 2")
@@ -292,6 +314,16 @@ func OnCommandExecuted(state *State, term *frontend.TerminalDisplay, quit chan s
 		} else {
 			return nil
 		}
+	} else if arg0 == "compose" {
+		// /compose [initial text] writes a message in the user's editor, and puts it in the command
+		// bar to be sent once the editor is closed, like Ctrl+O.
+		text, err := EditInEditor(term, strings.Join(args[1:], " "))
+		if err != nil {
+			state.Status.Errorf(err.Error())
+			return nil
+		}
+		state.Command = []rune(text)
+		state.CommandCursorPosition = len(state.Command)
 	} else {
 		// Otherwise, find the command that the user typed.
 		for _, command := range COMMANDS {
@@ -320,10 +352,21 @@ func resetKeyStack(state *State) {
 
 // Fetch more messages when the user has scrolled to the end of the previous message list.
 func FetchMessageHistoryScrollback(state *State) error {
+	selectedChannel := state.ActiveConnection().SelectedChannel()
+	if selectedChannel == nil {
+		return nil
+	}
+
+	// Once the start of the channel has been reached, there's nothing more to fetch.
+	if !state.ActiveConnection().HasMoreMessageHistory(*selectedChannel) {
+		return nil
+	}
+
 	msgHistory := state.ActiveConnection().MessageHistory()
 	messages, err := state.ActiveConnection().FetchChannelMessages(
-		*state.ActiveConnection().SelectedChannel(), // Channel
-		&(msgHistory[0].Hash),                       // *string
+		*selectedChannel,      // Channel
+		&(msgHistory[0].Hash), // Latest
+		nil,                   // Oldest
 	)
 
 	if err != nil {
@@ -340,7 +383,7 @@ func FetchMessageHistoryScrollback(state *State) error {
 // Break out function to handle only keyboard events. Called by `keyboardEvents`.
 func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.TerminalDisplay, quit chan struct{}) error {
 	// Did the user press a key in the keymap?
-	if state.Mode != "writ" && state.Mode != "modl" && ev.Key() == tcell.KeyRune {
+	if state.Mode != "writ" && state.Mode != "norm" && state.Mode != "modl" && ev.Key() == tcell.KeyRune {
 		// Add pressed key to the stack of keys
 		state.KeyStack = append(state.KeyStack, ev.Rune())
 
@@ -364,14 +407,41 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 		close(quit)
 		return nil
 
+	// Escape in chat mode cancels any uploads that are happening.
+	case ev.Key() == tcell.KeyEscape && state.Mode == "chat" && state.Uploads.Cancel() > 0:
+		resetKeyStack(state)
+
+	// Escape while completing a mention or emoji closes the completions, but keeps writing.
+	case ev.Key() == tcell.KeyEscape && state.Mode == "writ" && IsCompleting(state):
+		state.SelectionInput.Hide()
+
+	// With vi mode enabled, escape in writ mode moves to normal mode, like vi.
+	case ev.Key() == tcell.KeyEscape && state.Mode == "writ" && state.Configuration["CommandBar.ViMode"] == "true":
+		EmitEvent(state, EVENT_MODE_CHANGE, map[string]string{"from": state.Mode, "to": "norm"})
+		state.Mode = "norm"
+		state.SelectionInput.Hide()
+		state.CommandCursorPosition -= 1
+		clampNormalModeCursor(state)
+
 	// Escape reverts back to chat mode and clears the key stack.
 	case ev.Key() == tcell.KeyEscape:
 		EmitEvent(state, EVENT_MODE_CHANGE, map[string]string{"from": state.Mode, "to": "chat"})
 		state.Mode = "chat"
 		state.SelectionInput.Hide()
+		state.InputHistory.Reset()
 		resetKeyStack(state)
 		state.Status.Clear()
 
+	// Pressing enter in a modal that asks for confirmation runs the confirmed action.
+	case state.Mode == "modl" && !state.Modal.Editable && state.Modal.Confirm != nil && ev.Key() == tcell.KeyEnter:
+		confirm := state.Modal.Confirm
+		EmitEvent(state, EVENT_MODE_CHANGE, map[string]string{"from": state.Mode, "to": "chat"})
+		state.Mode = "chat"
+		state.Modal.Reset()
+		if err := confirm(); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+
 	// If an editable modal is active, suck up all key events into it.
 	case state.Mode == "modl" && state.Modal.Editable && ev.Key() == tcell.KeyRune:
 		state.Modal.Body = fmt.Sprintf(
@@ -381,6 +451,13 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 			state.Modal.Body[state.Modal.CursorPosition+1:],
 		)
 		state.Modal.CursorPosition += 1
+	case state.Mode == "modl" && state.Modal.Editable && ev.Key() == tcell.KeyCtrlO:
+		text, err := EditInEditor(term, state.Modal.Body)
+		if err != nil {
+			state.Status.Errorf(err.Error())
+		} else {
+			state.Modal.SetContent(text)
+		}
 	case state.Mode == "modl" && state.Modal.Editable && ev.Key() == tcell.KeyDEL:
 		if state.Modal.CursorPosition >= 0 {
 			state.Modal.Body = fmt.Sprintf(
@@ -421,12 +498,17 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 					if !channel.IsMember {
 						accessories += "(not a member) "
 					}
+					if channel.SubType == gateway.TYPE_DIRECT_MESSAGE && len(channel.DirectMessageUser) > 0 &&
+						connection.UserOnline(&gateway.User{Id: channel.DirectMessageUser}) {
+						accessories += "(online) "
+					}
 
 					// Add string representation of item to `stringItems`
-					// Follows the pattern of "my-team #my-channel"
+					// Follows the pattern of "#my-channel my-team", or "@alice my-team" for a direct
+					// message.
 					stringItems = append(stringItems, fmt.Sprintf(
-						"#%s %s\t%s",
-						channel.Name,
+						"%s %s\t%s",
+						channel.Label(),
 						connection.Name(),
 						accessories,
 					))
@@ -481,6 +563,17 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 		enableCommandAutocompletion(state, term, quit)
 		resetKeyStack(state)
 
+	//
+	// TAB-COMPLETE FOR USERS, CHANNELS, AND EMOJI
+	//
+	// Tab after a `@`, `#`, or `:` completes a user, channel, or emoji.
+	case state.Mode == "writ" && ev.Key() == tcell.KeyTab && !state.SelectionInput.Visible && OpenCompletion(state):
+
+	// Tab or enter while completing puts the selected completion in the command.
+	case state.Mode == "writ" && IsCompleting(state) && (ev.Key() == tcell.KeyTab || ev.Key() == tcell.KeyEnter):
+		state.Frecency.Record(state.SelectionInput.SelectedKey())
+		InsertCompletion(state)
+
 	//
 	// TAB-COMPLETE FOR FILE PATHS
 	//
@@ -588,6 +681,39 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 			}
 		})
 
+	//
+	// SIDEBAR
+	//
+	// Ctrl-b shows and hides the sidebar.
+	case state.Mode == "chat" && ev.Key() == tcell.KeyCtrlB:
+		if state.Configuration["Sidebar.Visible"] == "true" {
+			state.Configuration["Sidebar.Visible"] = "false"
+		} else {
+			state.Configuration["Sidebar.Visible"] = "true"
+		}
+
+	// Alt-j and Alt-k move to the next and previous channels in the sidebar.
+	case state.Mode == "chat" && ev.Modifiers() == tcell.ModAlt && (ev.Key() == tcell.KeyDown || (len(keystackCommand) == 1 && keystackCommand[0] == 'j')):
+		if err := SelectSidebarChannel(state, quantity); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+		resetKeyStack(state)
+	case state.Mode == "chat" && ev.Modifiers() == tcell.ModAlt && (ev.Key() == tcell.KeyUp || (len(keystackCommand) == 1 && keystackCommand[0] == 'k')):
+		if err := SelectSidebarChannel(state, -1*quantity); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+		resetKeyStack(state)
+
+	//
+	// SPLIT PANES
+	//
+	// Ctrl-w focuses the next pane.
+	case state.Mode == "chat" && ev.Key() == tcell.KeyCtrlW:
+		if err := FocusPane(state, quantity); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+		resetKeyStack(state)
+
 	//
 	// MOVEMENT UP AND DOWN THROUGH MESSAGES AND ACTIONS ON THE MESSAGES
 	//
@@ -613,7 +739,16 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 
 	// `G` will go to the bottom (newest) of the message history
 	case state.Mode == "chat" && len(keystackCommand) == 1 && keystackCommand[0] == 'G': // Select first message
-		if state.ActiveConnection() != nil && len(state.ActiveConnection().MessageHistory()) > 0 {
+		if state.ActiveConnection() != nil && state.ActiveConnection().HasNewerMessageHistory() {
+			// After jumping into the past, `G` goes back to the present.
+			go func(state *State) {
+				if err := FetchNewestMessageHistory(state); err != nil {
+					state.Status.Errorf("Error fetching newest messages: %s", err)
+				} else if term != nil {
+					render(state, term)
+				}
+			}(state)
+		} else if state.ActiveConnection() != nil && len(state.ActiveConnection().MessageHistory()) > 0 {
 			state.SelectedMessageIndex = 0
 			state.BottomDisplayedItem = 0
 			log.Printf("Selecting first message")
@@ -703,11 +838,14 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 		resetKeyStack(state)
 	case state.Mode == "chat" && (string(keystackCommand) == "o" ||
 		string(keystackCommand) == "c" ||
+		string(keystackCommand) == "D" ||
 		string(keystackCommand) == "l" ||
 		string(keystackCommand) == "m" ||
 		string(keystackCommand) == "x" ||
 		string(keystackCommand) == "s" ||
-		string(keystackCommand) == "e"): // Message interaction
+		string(keystackCommand) == "e" ||
+		string(keystackCommand) == "u" ||
+		string(keystackCommand) == "i"): // Message interaction
 		// When a user presses a key to interact with a message, handle it.
 		OnMessageInteraction(state, keystackCommand[0], quantity)
 		resetKeyStack(state)
@@ -819,14 +957,47 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 			}
 		}
 
+	//
+	// CHANNEL BROWSER
+	//
+
+	// Preview the highlighted channel's history without joining it
+	case state.Mode == "pick" && IsBrowsing(state) && ev.Key() == tcell.KeyCtrlO:
+		if err := BrowsePreviewChannel(state); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+
+	// Leave the highlighted channel
+	case state.Mode == "pick" && IsBrowsing(state) && ev.Key() == tcell.KeyCtrlR:
+		if err := BrowseLeaveChannel(state); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+
+	// Switch between sorting channels by member count and by name
+	case state.Mode == "pick" && IsBrowsing(state) && ev.Key() == tcell.KeyCtrlS:
+		BrowseToggleSort(state)
+
 	//
 	// COMMAND BAR
 	//
 
-	case (state.Mode == "writ" || state.Mode == "pick") && ev.Key() == tcell.KeyEnter:
+	case (state.Mode == "writ" || state.Mode == "norm" || state.Mode == "pick") && ev.Key() == tcell.KeyEnter:
 		log.Println("Enter pressed")
+		generation := state.SelectionInput.generation
+
+		// Remember what was sent, so it can be brought back with the up arrow.
+		if state.Mode == "writ" || state.Mode == "norm" {
+			state.InputHistory.Add(InputHistoryKey(state), string(state.Command))
+		}
+
+		typed := string(state.Command)
 		if state.SelectionInput.Visible {
+			// Remember the pick, so that it's ranked higher next time.
+			state.Frecency.Record(state.SelectionInput.SelectedKey())
 			state.SelectionInput.OnSelected(state)
+			if err := state.Frecency.Save(); err != nil {
+				log.Printf("Couldn't save frecency: %s", err)
+			}
 			// Clear the letters the user typed in order to search through the list
 			resetKeyStack(state)
 
@@ -840,49 +1011,39 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 			}
 
 			// Otherwise, send as a message.
-		} else if state.Mode == "writ" && state.ActiveConnection() != nil {
-			// Emit event to to be handled by lua scripts
-			EmitEvent(state, EVENT_MESSAGE_SENT, map[string]string{
-				"sender": state.ActiveConnection().Self().Name,
-				"text":   string(state.Command),
-			})
-
-			// Append the message the the history, but make it disabled.
-			message := gateway.Message{
-				Sender:    state.ActiveConnection().Self(),
-				Text:      string(state.Command),
-				Confirmed: false,
-			}
-			state.ActiveConnection().AppendMessageHistory(message)
-
-			// Sometimes, a message could have a response. This is for example true in the
-			// case of slash commands, sometimes.
-			responseMessage, err := state.ActiveConnection().SendMessage(
-				message,
-				state.ActiveConnection().SelectedChannel(),
-			)
-
-			if err != nil {
-				state.Status.Errorf("Error sending message: %s", err)
-			} else if responseMessage != nil {
-				// Got a response command? Append it to the message history.
-				state.ActiveConnection().AppendMessageHistory(*responseMessage)
-				message.Confirmed = true
+		} else if (state.Mode == "writ" || state.Mode == "norm") && state.ActiveConnection() != nil {
+			if err := SendMessageToActiveChannel(state, string(state.Command)); err != nil {
+				state.Status.Errorf(err.Error())
 			}
 		}
 
+		// A command that put text in the command bar (ie, `/compose`) leaves it there to be sent.
+		if len(state.Command) > 0 && string(state.Command) != typed {
+			state.SelectionInput.Hide()
+			state.Mode = "writ"
+			break
+		}
+
 		// Clear the command that was typed, and move back to chat mode. Also hide the fuzzy picker
 		// is its open.
 		state.Command = []rune{}
 		state.CommandCursorPosition = 0
+		// A command that opened a new fuzzy picker (ie, `/browse`) keeps it open.
+		if state.SelectionInput.Visible && state.SelectionInput.generation != generation {
+			break
+		}
 		EmitEvent(state, EVENT_MODE_CHANGE, map[string]string{"from": state.Mode, "to": "chat"})
 		state.SelectionInput.Hide()
 		// Reset to chat mode only if a command hasn't intentionally switched the mode to something
 		// special.
-		if state.Mode == "pick" || state.Mode == "writ" {
+		if state.Mode == "pick" || state.Mode == "writ" || state.Mode == "norm" {
 			state.Mode = "chat"
 		}
 
+	// In the `/dm` user picker, tab selects more than one user for a group direct message.
+	case state.Mode == "pick" && IsPickingUsers(state) && ev.Key() == tcell.KeyTab:
+		ToggleDirectMessageUser(state)
+
 	case state.Mode == "pick" && state.SelectionInput.Visible && len(state.SelectionInput.StringItems) > 0 && ev.Key() == tcell.KeyTab:
 		// Pressing tab when in the fuzzy picker takes the displayed item and updates the command
 		// bar with its contents
@@ -894,6 +1055,21 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 	// EDITING OPERATIONS
 	//
 
+	// In normal mode, keys edit the command rather than being typed into it.
+	case state.Mode == "norm" && ev.Key() == tcell.KeyRune:
+		HandleNormalModeKey(state, ev.Rune())
+
+	// Alt+b / Alt+f move by word, and Alt+d deletes the word after the cursor.
+	case (state.Mode == "writ" || state.Mode == "pick") && ev.Key() == tcell.KeyRune && ev.Modifiers()&tcell.ModAlt != 0:
+		switch ev.Rune() {
+		case 'b':
+			state.CommandCursorPosition = PreviousWordBoundary(state.Command, state.CommandCursorPosition)
+		case 'f':
+			state.CommandCursorPosition = NextWordBoundary(state.Command, state.CommandCursorPosition)
+		case 'd':
+			KillCommandText(state, state.CommandCursorPosition, NextWordBoundary(state.Command, state.CommandCursorPosition))
+		}
+
 	// Backslash adds a newline to a message.
 	case state.Mode == "writ" && ev.Key() == tcell.KeyRune && ev.Rune() == '\\':
 		state.Command = append(
@@ -956,8 +1132,46 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 			}
 		}
 
-		state.Command = append(state.Command[:lastSpaceIndex], state.Command[state.CommandCursorPosition:]...)
-		state.CommandCursorPosition = lastSpaceIndex
+		KillCommandText(state, lastSpaceIndex, state.CommandCursorPosition)
+
+	// Ctrl+K / Ctrl+U delete to the end or start of the command, and Ctrl+Y puts the most recently
+	// deleted text back.
+	case (state.Mode == "writ" || state.Mode == "pick") && ev.Key() == tcell.KeyCtrlK:
+		KillCommandText(state, state.CommandCursorPosition, len(state.Command))
+	case (state.Mode == "writ" || state.Mode == "pick") && ev.Key() == tcell.KeyCtrlU:
+		KillCommandText(state, 0, state.CommandCursorPosition)
+	case (state.Mode == "writ" || state.Mode == "pick") && ev.Key() == tcell.KeyCtrlY:
+		YankIntoCommand(state)
+
+	// Ctrl+T swaps the characters around the cursor.
+	case (state.Mode == "writ" || state.Mode == "pick") && ev.Key() == tcell.KeyCtrlT:
+		TransposeCommandChars(state)
+
+	// Delete / Ctrl+D remove the character under the cursor.
+	case (state.Mode == "writ" || state.Mode == "pick") && (ev.Key() == tcell.KeyDelete || ev.Key() == tcell.KeyCtrlD):
+		DeleteCommandCharForward(state)
+
+	// The up and down arrows (or Ctrl+P / Ctrl+N) move through messages sent in this channel.
+	case state.Mode == "writ" && !state.SelectionInput.Visible && (ev.Key() == tcell.KeyUp || ev.Key() == tcell.KeyCtrlP):
+		if command, ok := state.InputHistory.Previous(InputHistoryKey(state), state.Command); ok {
+			state.Command = command
+			state.CommandCursorPosition = len(state.Command)
+		}
+	case state.Mode == "writ" && !state.SelectionInput.Visible && (ev.Key() == tcell.KeyDown || ev.Key() == tcell.KeyCtrlN):
+		if command, ok := state.InputHistory.Next(InputHistoryKey(state)); ok {
+			state.Command = command
+			state.CommandCursorPosition = len(state.Command)
+		}
+
+	// Ctrl+O opens the message in the user's editor.
+	case state.Mode == "writ" && ev.Key() == tcell.KeyCtrlO:
+		text, err := EditInEditor(term, string(state.Command))
+		if err != nil {
+			state.Status.Errorf(err.Error())
+		} else {
+			state.Command = []rune(text)
+			state.CommandCursorPosition = len(state.Command)
+		}
 
 	// Ctrl+A / Ctrl+E go to the start and end of editing
 	case (state.Mode == "writ" || state.Mode == "pick") && ev.Key() == tcell.KeyCtrlA:
@@ -978,12 +1192,47 @@ func HandleKeyboardEvent(ev *tcell.EventKey, state *State, term *frontend.Termin
 		}(state)
 	}
 
+	// After jumping into the past, load newer messages when the user scrolls to the newest one
+	if state.ActiveConnection() != nil &&
+		state.ActiveConnection().HasNewerMessageHistory() &&
+		state.SelectedMessageIndex < messageScrollPadding &&
+		len(state.ActiveConnection().MessageHistory()) > 0 {
+		go func(state *State) {
+			err := FetchMessageHistoryScrollforward(state)
+			if err != nil {
+				state.Status.Errorf("Error fetching newer messages: %s", err)
+			}
+		}(state)
+	}
+
 	return nil
 }
 
-func keyboardEvents(state *State, term *frontend.TerminalDisplay, screen tcell.Screen, quit chan struct{}) {
+func keyboardEvents(state *State, term *frontend.TerminalDisplay, quit chan struct{}) {
+	var mouseScreen tcell.Screen
 	for {
+		// The screen is replaced after another program (ie, an editor) uses the terminal, so always
+		// poll the current one.
+		screen := term.Screen()
+
+		// Only ask the terminal for mouse events when the mouse is enabled.
+		if mouseEnabled := state.Configuration["Mouse"] == "true"; mouseEnabled && mouseScreen != screen {
+			screen.EnableMouse()
+			mouseScreen = screen
+		} else if !mouseEnabled && mouseScreen != nil {
+			screen.DisableMouse()
+			mouseScreen = nil
+		}
+
 		ev := screen.PollEvent()
+		if ev == nil {
+			// The screen was finalized, so wait for the new one. Without one, slick can't go on.
+			if err := term.WaitForScreen(); err != nil {
+				log.Fatalf(err.Error())
+			}
+			continue
+		}
+
 		switch ev := ev.(type) {
 		case *tcell.EventKey:
 			log.Printf("Keypress: %+v", ev.Name())
@@ -997,6 +1246,10 @@ func keyboardEvents(state *State, term *frontend.TerminalDisplay, screen tcell.S
 					log.Fatalf(err.Error())
 				}
 			}
+		case *tcell.EventMouse:
+			if err := HandleMouseEvent(ev, state, term, quit); err != nil {
+				log.Fatalf(err.Error())
+			}
 		case *tcell.EventResize:
 			screen.Sync()
 		}
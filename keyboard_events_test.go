@@ -56,6 +56,31 @@ func InitialMessageHistoryState(initialSelectedIndex int, initialBottomItemIndex
 	return s
 }
 
+func InitialCommandState(command string, cursor int) *State {
+	s := NewInitialStateMode("writ")
+	s.Command = []rune(command)
+	s.CommandCursorPosition = cursor
+	return s
+}
+
+func InitialViCommandState(command string, cursor int) *State {
+	s := InitialCommandState(command, cursor)
+	s.Configuration["CommandBar.ViMode"] = "true"
+	return s
+}
+
+// A state in writ mode, where the given messages have already been sent in the active channel.
+func InitialCommandHistoryState(command string, sent ...string) *State {
+	s := InitialCommandState(command, len(command))
+	s.Connections = append(s.Connections, gatewaySlack.New("token"))
+	s.SetActiveConnection(len(s.Connections) - 1)
+	s.ActiveConnection().SetSelectedChannel(&gateway.Channel{Id: "channel-id", Name: "general"})
+	for _, message := range sent {
+		s.InputHistory.Add(InputHistoryKey(s), message)
+	}
+	return s
+}
+
 func InitialModalState(scrollPosition int) *State {
 	state := NewInitialStateMode("modl")
 	state.Modal.Title = "Modal Title"
@@ -223,6 +248,186 @@ var keyEvents = []struct {
 		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyCtrlE, ' ', tcell.ModNone)},
 		func(state *State) bool { return state.CommandCursorPosition == len(state.Command) },
 	},
+	{
+		"In writ mode, alt+b moves back a word",
+		InitialCommandState("foo bar baz", 9),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModAlt)},
+		func(state *State) bool { return state.CommandCursorPosition == 8 },
+	},
+	{
+		"In writ mode, alt+b skips spaces before the previous word",
+		InitialCommandState("foo bar baz", 8),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModAlt)},
+		func(state *State) bool { return state.CommandCursorPosition == 4 },
+	},
+	{
+		"In writ mode, alt+f moves to the end of the next word",
+		InitialCommandState("foo bar baz", 3),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModAlt)},
+		func(state *State) bool { return state.CommandCursorPosition == 7 && string(state.Command) == "foo bar baz" },
+	},
+	{
+		"In writ mode, alt+d deletes the next word",
+		InitialCommandState("foo bar baz", 3),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModAlt)},
+		func(state *State) bool { return string(state.Command) == "foo baz" && state.CommandCursorPosition == 3 },
+	},
+	{
+		"In writ mode, ctrl+k deletes to the end of the command",
+		InitialCommandState("foo bar baz", 3),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyCtrlK, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "foo" && state.CommandCursorPosition == 3 },
+	},
+	{
+		"In writ mode, ctrl+u deletes to the start of the command",
+		InitialCommandState("foo bar baz", 4),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyCtrlU, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "bar baz" && state.CommandCursorPosition == 0 },
+	},
+	{
+		"In writ mode, ctrl+y puts back the text deleted with ctrl+k",
+		InitialCommandState("foo bar baz", 3),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyCtrlK, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyCtrlA, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyCtrlY, ' ', tcell.ModNone),
+		},
+		func(state *State) bool { return string(state.Command) == " bar bazfoo" && state.CommandCursorPosition == 8 },
+	},
+	{
+		"In writ mode, ctrl+y puts back the word deleted with ctrl+w",
+		InitialCommandState("foo bar", 7),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyCtrlW, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyCtrlY, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyCtrlY, ' ', tcell.ModNone),
+		},
+		func(state *State) bool { return string(state.Command) == "foo bar bar" },
+	},
+	{
+		"In writ mode, ctrl+t swaps the characters around the cursor",
+		InitialCommandState("abcd", 2),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyCtrlT, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "acbd" && state.CommandCursorPosition == 3 },
+	},
+	{
+		"In writ mode, ctrl+t at the end of the command swaps the last two characters",
+		InitialCommandState("abcd", 4),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyCtrlT, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "abdc" && state.CommandCursorPosition == 4 },
+	},
+	{
+		"In writ mode, delete removes the character under the cursor",
+		InitialCommandState("abcd", 1),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyDelete, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "acd" && state.CommandCursorPosition == 1 },
+	},
+	{
+		"In writ mode, delete at the end of the command does nothing",
+		InitialCommandState("abcd", 4),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyCtrlD, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "abcd" && state.CommandCursorPosition == 4 },
+	},
+
+	// Command bar history
+	{
+		"In writ mode, the up arrow brings back the last message sent",
+		InitialCommandHistoryState("draft", "one", "two"),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "two" && state.CommandCursorPosition == 3 },
+	},
+	{
+		"In writ mode, the up arrow stops at the oldest message sent",
+		InitialCommandHistoryState("draft", "one", "two"),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone),
+		},
+		func(state *State) bool { return string(state.Command) == "one" },
+	},
+	{
+		"In writ mode, the down arrow restores what was being typed",
+		InitialCommandHistoryState("draft", "one", "two"),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyDown, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyDown, ' ', tcell.ModNone),
+		},
+		func(state *State) bool { return string(state.Command) == "draft" },
+	},
+	{
+		"In writ mode, history is kept separately for each channel",
+		func() *State {
+			s := InitialCommandHistoryState("", "one")
+			s.ActiveConnection().SetSelectedChannel(&gateway.Channel{Id: "random-id", Name: "random"})
+			return s
+		}(),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone)},
+		func(state *State) bool { return string(state.Command) == "" },
+	},
+
+	// Vi mode
+	{
+		"In writ mode, esc moves to chat mode when vi mode is disabled",
+		InitialCommandState("foo bar", 7),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone)},
+		func(state *State) bool { return state.Mode == "chat" },
+	},
+	{
+		"In writ mode, esc moves to normal mode when vi mode is enabled",
+		InitialViCommandState("foo bar", 7),
+		[]*tcell.EventKey{tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone)},
+		func(state *State) bool { return state.Mode == "norm" && state.CommandCursorPosition == 6 },
+	},
+	{
+		"In normal mode, b, w, and x edit the command",
+		InitialViCommandState("foo bar baz", 11),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone),
+			NewRuneEvent('b'),
+			NewRuneEvent('b'),
+			NewRuneEvent('x'),
+			NewRuneEvent('w'),
+		},
+		func(state *State) bool {
+			return state.Mode == "norm" && string(state.Command) == "foo ar baz" && state.CommandCursorPosition == 7
+		},
+	},
+	{
+		"In normal mode, D deletes to the end and p puts it back",
+		InitialViCommandState("foo bar", 7),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone),
+			NewRuneEvent('0'),
+			NewRuneEvent('l'),
+			NewRuneEvent('l'),
+			NewRuneEvent('D'),
+			NewRuneEvent('p'),
+		},
+		func(state *State) bool { return string(state.Command) == "foo bar" && state.CommandCursorPosition == 6 },
+	},
+	{
+		"In normal mode, A moves back to writ mode at the end of the command",
+		InitialViCommandState("foo bar", 7),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone),
+			NewRuneEvent('0'),
+			NewRuneEvent('A'),
+			NewRuneEvent('!'),
+		},
+		func(state *State) bool { return state.Mode == "writ" && string(state.Command) == "foo bar!" },
+	},
+	{
+		"In normal mode, esc moves to chat mode",
+		InitialViCommandState("foo bar", 7),
+		[]*tcell.EventKey{
+			tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone),
+			tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone),
+		},
+		func(state *State) bool { return state.Mode == "chat" },
+	},
 
 	// Modal operations
 	{
@@ -419,7 +624,7 @@ func TestHandleMessageMovementKeyboardEvents(t *testing.T) {
 				httpmock.Activate()
 				httpmock.RegisterResponder(
 					"GET",
-					"https://slack.com/api/channels.history?token=token&channel="+selectedChannelId+"&count=100",
+					"https://slack.com/api/conversations.history?token=token&channel="+selectedChannelId+"&limit=100",
 					httpmock.NewStringResponder(200, `{"ok": true, "messages": []}`),
 				)
 			}
@@ -506,7 +711,7 @@ func TestEmojiStartingMessageWontMeTreatedAsCommand(t *testing.T) {
 	httpmock.Activate()
 	httpmock.RegisterResponder(
 		"GET",
-		"https://slack.com/api/chat.postMessage?token=token&channel=channel-id&text=%3Asmile%3A&link_names=true&parse=full&unfurl_links=true&as_user=true",
+		"https://slack.com/api/chat.postMessage?token=token&channel=channel-id&text=%3Asmile%3A&link_names=true&unfurl_links=true&as_user=true",
 		func(req *http.Request) (*http.Response, error) {
 			userSentMessage = true
 			return httpmock.NewStringResponse(200, `{"ok": true}`), nil
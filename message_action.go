@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"log"
+
+	"github.com/skratchdot/open-golang/open"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// A button, or an option in a menu, in the fuzzy picker opened by `/interact`.
+type MessageActionItem struct {
+	Message gateway.Message
+	Action  gateway.MessageAction
+}
+
+func messageActionLabel(action gateway.MessageAction) string {
+	if action.Type == "button" {
+		return action.Label + "\tbutton"
+	}
+	return action.Label + "\tmenu"
+}
+
+// List the buttons and menu options in the selected message in the fuzzy picker.
+func OpenMessageActionPicker(state *State) error {
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return errors.New("No active connection!")
+	}
+
+	selectedMessageIndex := len(conn.MessageHistory()) - 1 - state.SelectedMessageIndex
+	if selectedMessageIndex < 0 || selectedMessageIndex >= len(conn.MessageHistory()) {
+		return errors.New("No message selected.")
+	}
+	message := conn.MessageHistory()[selectedMessageIndex]
+
+	actions := message.Actions()
+	if len(actions) == 0 {
+		return errors.New("Selected message has no buttons or menus.")
+	}
+
+	state.SelectionInput.Hide()
+	state.SelectionInput.Show(OnPickMessageAction)
+	for _, action := range actions {
+		state.SelectionInput.Items = append(state.SelectionInput.Items, MessageActionItem{Message: message, Action: action})
+		state.SelectionInput.StringItems = append(state.SelectionInput.StringItems, messageActionLabel(action))
+	}
+	state.Mode = "pick"
+	return nil
+}
+
+// When the user presses enter in the picker, press the button (or pick the menu option) that's
+// highlighted.
+func OnPickMessageAction(state *State) {
+	if item, ok := state.SelectionInput.Items[state.SelectionInput.SelectedItem].(MessageActionItem); ok {
+		if err := SendMessageAction(state, item.Message, item.Action); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+	}
+}
+
+// Press a button or pick a menu option in a message. Buttons that link somewhere open the link too.
+// The bot that posted the message usually updates it in response, which is shown when the
+// `message_changed` event arrives.
+func SendMessageAction(state *State, message gateway.Message, action gateway.MessageAction) error {
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return errors.New("No active connection!")
+	}
+
+	if len(action.Url) > 0 {
+		open.Run(action.Url)
+	}
+	if err := conn.SendMessageAction(message, action); err != nil {
+		return err
+	}
+
+	state.Status.Printf("Sent %s", action.Label)
+	return nil
+}
+
+// When a message changes (ie, it's edited, or a bot updates it after one of its buttons was
+// pressed), replace the copy of it in `messages`. Returns whether the message was found.
+func ReplaceChangedMessage(
+	messages []gateway.Message,
+	conn gateway.Connection,
+	data map[string]interface{},
+	cachedUsers map[string]*gateway.User,
+) bool {
+	changed, ok := data["message"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for index, message := range messages {
+		if message.Hash == changed["ts"] {
+			parsed, err := conn.ParseMessage(changed, cachedUsers)
+			if err != nil {
+				log.Println("Error parsing changed message:", err)
+				return false
+			}
+			messages[index] = *parsed
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main_test
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestInteractPressesButtonInSelectedMessage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var actions string
+	httpmock.RegisterResponder("POST", "https://slack.com/api/blocks.actions?token=token",
+		func(req *http.Request) (*http.Response, error) {
+			req.ParseForm()
+			actions = req.PostForm.Get("actions")
+			return httpmock.NewStringResponse(200, `{"ok": true}`), nil
+		})
+
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelectedChannel(&gateway.Channel{Id: "C1", Name: "deploys"})
+	conn.AppendMessageHistory(gateway.Message{
+		Hash: "1500000000.000000",
+		Text: "Can I deploy?",
+		Blocks: []gateway.Block{{Type: "actions", Id: "b1", Elements: []gateway.BlockElement{
+			{Type: "button", ActionId: "approve", Text: "Approve", Value: "yes"},
+		}}},
+	})
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+
+	quit := make(chan struct{}, 1)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone), state, nil, quit)
+	if state.Mode != "pick" || len(state.SelectionInput.StringItems) != 1 || state.SelectionInput.StringItems[0] != "Approve\tbutton" {
+		t.Fatalf("Expected a picker with the message's button, got %q", state.SelectionInput.StringItems)
+	}
+
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, nil, quit)
+	if expected := `[{"action_id":"approve","block_id":"b1","text":{"text":"Approve","type":"plain_text"},"type":"button","value":"yes"}]`; actions != expected {
+		t.Errorf("Expected %s to be sent, got %s", expected, actions)
+	}
+}
+
+func TestInteractWithoutButtons(t *testing.T) {
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.AppendMessageHistory(gateway.Message{Hash: "1500000000.000000", Text: "Hello"})
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+
+	err := GetCommand("Interact").Handler([]string{"interact"}, state)
+	if err == nil || err.Error() != "Selected message has no buttons or menus." {
+		t.Errorf("Expected an error, got %v", err)
+	}
+}
+
+func TestReplaceChangedMessage(t *testing.T) {
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	messages := []gateway.Message{
+		{Hash: "1500000000.000000", Text: "Can I deploy?"},
+		{Hash: "1500000001.000000", Text: "Another message"},
+	}
+
+	replaced := ReplaceChangedMessage(messages, conn, map[string]interface{}{
+		"subtype": "message_changed",
+		"message": map[string]interface{}{
+			"ts":       "1500000000.000000",
+			"username": "approve-bot",
+			"text":     "Approved by @me",
+		},
+	}, map[string]*gateway.User{})
+	if !replaced {
+		t.Fatalf("Message wasn't replaced")
+	}
+	if messages[0].Text != "Approved by @me" || messages[1].Text != "Another message" {
+		t.Errorf("Wrong message replaced: %+v", messages)
+	}
+}
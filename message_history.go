@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Only fetch one page of newer messages at a time, so that pages aren't appended twice.
+var scrollforwardMutex sync.Mutex
+
+// Relative times look like `3d`, `2 weeks`, or `30 minutes ago`.
+var relativeTimeRegex = regexp.MustCompile(`^(\d+)\s*([a-z]+?)s?(\s+ago)?$`)
+
+var relativeTimeUnits = map[string]time.Duration{
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hr":     time.Hour,
+	"hour":   time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+	"w":      7 * 24 * time.Hour,
+	"wk":     7 * 24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// Absolute dates that can be passed to `/goto`. Formats without a year are assumed to be in the
+// last year.
+var absoluteTimeLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02t15:04", // The input is lowercased, so `2017-07-04T15:30` has a lowercase `t`.
+	"2006-01-02",
+	"Jan 2 2006",
+	"January 2 2006",
+	"01/02/2006",
+}
+var absoluteTimeLayoutsWithoutYear = []string{
+	"Jan 2",
+	"January 2",
+	"01/02",
+}
+
+// Given a date (`2017-07-04`, `jul 4`), a relative time (`3d`, `2 weeks ago`), or a day (`today`,
+// `yesterday`, `monday`), return the time it refers to.
+func ParseGotoTime(input string, now time.Time) (time.Time, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch input {
+	case "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	}
+
+	// A day of the week refers to the most recent one before today.
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if input == strings.ToLower(day.String()) || input == strings.ToLower(day.String()[:3]) {
+			daysAgo := (int(now.Weekday()) - int(day) + 7) % 7
+			if daysAgo == 0 {
+				daysAgo = 7
+			}
+			return midnight.AddDate(0, 0, -1*daysAgo), nil
+		}
+	}
+
+	if match := relativeTimeRegex.FindStringSubmatch(input); match != nil {
+		if unit, ok := relativeTimeUnits[match[2]]; ok {
+			amount, _ := strconv.Atoi(match[1])
+			return now.Add(-1 * time.Duration(amount) * unit), nil
+		}
+	}
+
+	// Titlecase month names so that `jul 4` is parsed like `Jul 4`.
+	titled := strings.Title(input)
+	for _, layout := range absoluteTimeLayouts {
+		if when, err := time.ParseInLocation(layout, titled, now.Location()); err == nil {
+			return when, nil
+		}
+	}
+	for _, layout := range absoluteTimeLayoutsWithoutYear {
+		if when, err := time.ParseInLocation(layout, titled, now.Location()); err == nil {
+			when = when.AddDate(now.Year(), 0, 0)
+			if when.After(now) {
+				when = when.AddDate(-1, 0, 0)
+			}
+			return when, nil
+		}
+	}
+
+	return time.Time{}, errors.New(fmt.Sprintf("Can't understand the time %s. Try `2017-07-04`, `jul 4`, `yesterday`, or `3d`.", input))
+}
+
+// Replace the message history of the active channel with the messages around the given time, and
+// select the first message on or after that time.
+func GotoMessageHistory(state *State, when time.Time) error {
+	conn := state.ActiveConnection()
+	if conn == nil || conn.SelectedChannel() == nil {
+		return errors.New("No active connection or selected channel!")
+	}
+	channel := *conn.SelectedChannel()
+	timestamp := fmt.Sprintf("%d.000000", when.Unix())
+
+	before, err := conn.FetchChannelMessages(channel, &timestamp, nil)
+	if err != nil {
+		return err
+	}
+	after, err := conn.FetchChannelMessages(channel, nil, &timestamp)
+	if err != nil {
+		return err
+	}
+
+	conn.SetMessageHistory(append(before, after...))
+	conn.SetHasNewerMessageHistory(len(after) > 0)
+
+	// Messages are indexed from the newest message, so the first message after the time is the
+	// oldest of the messages after it.
+	if len(after) > 0 {
+		state.SelectedMessageIndex = len(after) - 1
+	} else {
+		state.SelectedMessageIndex = 0
+	}
+	state.BottomDisplayedItem = state.SelectedMessageIndex - messageScrollPadding
+	if state.BottomDisplayedItem < 0 {
+		state.BottomDisplayedItem = 0
+	}
+
+	return nil
+}
+
+// After jumping into the past, fetch more messages when the user has scrolled to the newest
+// message that has been loaded.
+func FetchMessageHistoryScrollforward(state *State) error {
+	scrollforwardMutex.Lock()
+	defer scrollforwardMutex.Unlock()
+
+	conn := state.ActiveConnection()
+	msgHistory := conn.MessageHistory()
+	if conn.SelectedChannel() == nil || !conn.HasNewerMessageHistory() || len(msgHistory) == 0 {
+		return nil
+	}
+
+	messages, err := conn.FetchChannelMessages(
+		*conn.SelectedChannel(),               // Channel
+		nil,                                   // Latest
+		&(msgHistory[len(msgHistory)-1].Hash), // Oldest
+	)
+	if err != nil {
+		return err
+	}
+
+	// No newer messages? Then the history has caught up with the present.
+	if len(messages) == 0 {
+		conn.SetHasNewerMessageHistory(false)
+		return nil
+	}
+
+	for _, message := range messages {
+		conn.AppendMessageHistory(message)
+	}
+
+	// Keep the same message selected, since messages are indexed from the newest message.
+	state.SelectedMessageIndex += len(messages)
+	state.BottomDisplayedItem += len(messages)
+
+	return nil
+}
+
+// Replace a message history that is showing the past with the newest messages in the channel.
+func FetchNewestMessageHistory(state *State) error {
+	conn := state.ActiveConnection()
+	if conn == nil || conn.SelectedChannel() == nil {
+		return errors.New("No active connection or selected channel!")
+	}
+
+	messages, err := conn.FetchChannelMessages(*conn.SelectedChannel(), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	conn.SetMessageHistory(messages)
+	conn.SetHasNewerMessageHistory(false)
+	state.SelectedMessageIndex = 0
+	state.BottomDisplayedItem = 0
+
+	return nil
+}
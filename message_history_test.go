@@ -0,0 +1,42 @@
+package main_test
+
+import (
+	. "github.com/1egoman/slick"
+	"testing"
+	"time"
+)
+
+func TestParseGotoTime(t *testing.T) {
+	// Wednesday, July 5th 2017
+	now := time.Date(2017, time.July, 5, 14, 30, 0, 0, time.Local)
+
+	for _, test := range []struct {
+		Input  string
+		Output time.Time
+	}{
+		{"today", time.Date(2017, time.July, 5, 0, 0, 0, 0, time.Local)},
+		{"yesterday", time.Date(2017, time.July, 4, 0, 0, 0, 0, time.Local)},
+		{"monday", time.Date(2017, time.July, 3, 0, 0, 0, 0, time.Local)},
+		{"Wed", time.Date(2017, time.June, 28, 0, 0, 0, 0, time.Local)},
+		{"3d", now.Add(-3 * 24 * time.Hour)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"2 weeks ago", now.Add(-14 * 24 * time.Hour)},
+		{"30 minutes", now.Add(-30 * time.Minute)},
+		{"2017-07-04", time.Date(2017, time.July, 4, 0, 0, 0, 0, time.Local)},
+		{"2017-07-04 15:30", time.Date(2017, time.July, 4, 15, 30, 0, 0, time.Local)},
+		{"2017-07-04T15:30", time.Date(2017, time.July, 4, 15, 30, 0, 0, time.Local)},
+		{"jul 4", time.Date(2017, time.July, 4, 0, 0, 0, 0, time.Local)},
+		{"December 25", time.Date(2016, time.December, 25, 0, 0, 0, 0, time.Local)}, // In the past
+	} {
+		output, err := ParseGotoTime(test.Input, now)
+		if err != nil {
+			t.Errorf("Error parsing `%s`: %s", test.Input, err)
+		} else if !output.Equal(test.Output) {
+			t.Errorf("`%s` was parsed as %s, expected %s", test.Input, output, test.Output)
+		}
+	}
+
+	if _, err := ParseGotoTime("the day after tomorrow", now); err == nil {
+		t.Errorf("Parsing an invalid time didn't return an error")
+	}
+}
@@ -7,12 +7,20 @@ type Modal struct {
 	CursorPosition int
 
 	ScrollPosition int
+
+	// If set, the body is code in this language (ie, `Python`), and is highlighted.
+	Language string
+
+	// If set, pressing enter in a modal that isn't editable closes the modal and calls this.
+	Confirm func() error
 }
 
 // Called when the modal is originally opened, to reset the state from the previous use.
 func (m *Modal) Reset() {
 	m.ScrollPosition = 0
 	m.Editable = false
+	m.Language = ""
+	m.Confirm = nil
 }
 
 func (m *Modal) SetContent(data string) {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/gdamore/tcell"
+)
+
+// Handle a mouse event. Only sent when the `Mouse` option is enabled. The wheel scrolls, and clicks
+// act on what was drawn under the mouse in the last render.
+func HandleMouseEvent(ev *tcell.EventMouse, state *State, term *frontend.TerminalDisplay, quit chan struct{}) error {
+	// tcell sends an event for every movement of the mouse while a button is held, so a click is only
+	// when the button goes down.
+	buttons := ev.Buttons()
+	clicked := buttons&tcell.Button1 != 0 && state.mouseButtons&tcell.Button1 == 0
+	state.mouseButtons = buttons
+
+	switch {
+	// Scrolling in the modal or fuzzy picker works like Ctrl-k and Ctrl-j.
+	case (state.Mode == "modl" || state.SelectionInput.Visible) && buttons&tcell.WheelUp != 0:
+		return HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlK, ' ', tcell.ModNone), state, term, quit)
+	case (state.Mode == "modl" || state.SelectionInput.Visible) && buttons&tcell.WheelDown != 0:
+		return HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlJ, ' ', tcell.ModNone), state, term, quit)
+
+	// Scrolling anywhere else moves through messages.
+	case buttons&tcell.WheelUp != 0:
+		GetCommand("MoveForwardMessage").Handler([]string{}, state)
+	case buttons&tcell.WheelDown != 0:
+		GetCommand("MoveBackMessage").Handler([]string{}, state)
+
+	// Clicks don't do anything while the modal is open.
+	case clicked && state.Mode != "modl":
+		x, y := ev.Position()
+		target, ok := term.ClickTargetAt(x, y)
+		if !ok {
+			return nil
+		}
+		log.Printf("Clicked on %+v", target)
+		if err := OnClickTarget(state, term, target, quit); err != nil {
+			state.Status.Errorf(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Act on something that was clicked on.
+func OnClickTarget(state *State, term *frontend.TerminalDisplay, target frontend.ClickTarget, quit chan struct{}) error {
+	// Only the fuzzy picker can be clicked on while it's open.
+	if state.SelectionInput.Visible && target.Type != frontend.CLICK_TARGET_SELECTION_ITEM {
+		return nil
+	}
+
+	switch target.Type {
+	case frontend.CLICK_TARGET_SELECTION_ITEM:
+		// Picking an item works like pressing enter on it.
+		state.SelectionInput.SelectedItem = target.Index
+		return HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, term, quit)
+
+	case frontend.CLICK_TARGET_CONNECTION:
+		if target.Index < len(state.Connections) && target.Index != state.ActiveConnectionIndex() {
+			state.SetActiveConnection(target.Index)
+		}
+
+	case frontend.CLICK_TARGET_PANE:
+		rects, _ := state.Panes.Layout(0, 0, 0, 0)
+		for index, rect := range rects {
+			if rect.Focused {
+				return FocusPane(state, target.Index-index)
+			}
+		}
+
+	case frontend.CLICK_TARGET_MESSAGE, frontend.CLICK_TARGET_LINK, frontend.CLICK_TARGET_ATTACHMENT:
+		if state.ActiveConnection() == nil || target.Index >= len(state.ActiveConnection().MessageHistory()) {
+			return nil
+		}
+		// The selected message is counted back from the newest message.
+		state.SelectedMessageIndex = len(state.ActiveConnection().MessageHistory()) - 1 - target.Index
+
+		if target.Type == frontend.CLICK_TARGET_LINK {
+			return GetCommand("OpenMessageLink").Handler([]string{"__INTERNAL__", strconv.Itoa(target.Part)}, state)
+		} else if target.Type == frontend.CLICK_TARGET_ATTACHMENT {
+			return GetCommand("OpenAttachmentLink").Handler([]string{"__INTERNAL__", strconv.Itoa(target.Part)}, state)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package main_test
+
+import (
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+)
+
+func click(state *State, term *frontend.TerminalDisplay, x int, y int) {
+	quit := make(chan struct{}, 1)
+	HandleMouseEvent(tcell.NewEventMouse(x, y, tcell.Button1, tcell.ModNone), state, term, quit)
+	HandleMouseEvent(tcell.NewEventMouse(x, y, tcell.ButtonNone, tcell.ModNone), state, term, quit)
+}
+
+func TestMouseClickSelectsMessageAndConnection(t *testing.T) {
+	state := NewInitialStateMode("chat")
+	first := gatewaySlack.NewWithName("first", "token")
+	second := gatewaySlack.NewWithName("second", "token")
+	first.SetMessageHistory([]gateway.Message{
+		gateway.Message{Text: "Hello", Confirmed: true},
+		gateway.Message{Text: "World", Confirmed: true},
+	})
+	state.Connections = append(state.Connections, first, second)
+	state.SetActiveConnection(0)
+
+	term := frontend.NewTerminalDisplay(frontend.NewAsciiScreen())
+	term.DrawMessages(first.MessageHistory(), -1, 0, "", first.UserById, first.UserOnline, nil, state.Configuration)
+	term.DrawStatusBar(state.Mode, state.Connections, first, state.Status, state.Configuration)
+
+	// The oldest message is on the row above the newest message.
+	click(state, term, 10, 24-frontend.BottomPadding-2)
+	if state.SelectedMessageIndex != 1 {
+		t.Errorf("Expected the oldest message to be selected, got %d", state.SelectedMessageIndex)
+	}
+
+	// "chat | 1: first 2: second"
+	click(state, term, 17, 23)
+	if state.ActiveConnectionIndex() != 1 {
+		t.Errorf("Expected the second connection to be active, got %d", state.ActiveConnectionIndex())
+	}
+}
+
+func TestMouseClickPicksItem(t *testing.T) {
+	state := NewInitialStateMode("pick")
+	var picked string
+	state.SelectionInput.Show(func(state *State) {
+		picked = state.SelectionInput.StringItems[state.SelectionInput.SelectedItem]
+		state.SelectionInput.Hide()
+	})
+	state.SelectionInput.Items = []interface{}{"foo", "bar", "baz"}
+	state.SelectionInput.StringItems = []string{"foo", "bar", "baz"}
+
+	term := frontend.NewTerminalDisplay(frontend.NewAsciiScreen())
+	term.DrawSelectionInput(state.SelectionInput.StringItems, 0, 0, state.SelectionInput.Match, state.Configuration)
+
+	// Items are listed from the bottom up, so "bar" is the second row from the bottom.
+	click(state, term, 5, 24-frontend.BottomPadding-2)
+	if picked != "bar" {
+		t.Errorf("Expected bar to be picked, got %q", picked)
+	}
+}
+
+func TestMouseWheelScrollsModal(t *testing.T) {
+	state := NewInitialStateMode("modl")
+	state.Modal.Body = "one\ntwo\nthree\nfour"
+	term := frontend.NewTerminalDisplay(frontend.NewAsciiScreen())
+	quit := make(chan struct{}, 1)
+
+	HandleMouseEvent(tcell.NewEventMouse(0, 0, tcell.WheelDown, tcell.ModNone), state, term, quit)
+	HandleMouseEvent(tcell.NewEventMouse(0, 0, tcell.WheelDown, tcell.ModNone), state, term, quit)
+	HandleMouseEvent(tcell.NewEventMouse(0, 0, tcell.WheelUp, tcell.ModNone), state, term, quit)
+	if state.Modal.ScrollPosition != 1 {
+		t.Errorf("Expected the modal to be scrolled down one line, got %d", state.Modal.ScrollPosition)
+	}
+}
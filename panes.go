@@ -0,0 +1,359 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+const (
+	SPLIT_HORIZONTAL = "horizontal" // One pane above the other
+	SPLIT_VERTICAL   = "vertical"   // One pane beside the other
+)
+
+// A channel shown in a pane. The focused pane is shown with the active connection and its selected
+// channel, like when there's only one pane. Every other pane keeps its own copy of its channel's
+// messages, which is kept up to date as messages arrive.
+type Pane struct {
+	Connection             gateway.Connection
+	Channel                gateway.Channel
+	Messages               []gateway.Message
+	HasNewerMessageHistory bool
+	SelectedMessageIndex   int
+	BottomDisplayedItem    int
+}
+
+// Panes are laid out in a tree. A leaf holds a pane, and every other node is split in two.
+type PaneLayout struct {
+	Split  string
+	First  *PaneLayout // Above or to the left
+	Second *PaneLayout // Below or to the right
+	Pane   *Pane
+
+	parent *PaneLayout
+}
+
+// Where a pane is drawn on the screen.
+type PaneRect struct {
+	Pane    Pane
+	Focused bool
+	X, Y    int
+	Width   int
+	Height  int
+}
+
+// Where the border between two panes beside each other is drawn.
+type PaneBorder struct {
+	X, Y   int
+	Height int
+}
+
+// The panes the screen is split into, and which one is focused.
+type Panes struct {
+	mutex   sync.Mutex
+	root    *PaneLayout
+	focused *PaneLayout
+}
+
+func NewPanes() *Panes {
+	return &Panes{}
+}
+
+// Is the screen split into more than one pane?
+func (p *Panes) IsSplit() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.root != nil && p.root.Pane == nil
+}
+
+// Every pane, in order from top left to bottom right.
+func (p *Panes) leaves() []*PaneLayout {
+	var leaves []*PaneLayout
+	var walk func(node *PaneLayout)
+	walk = func(node *PaneLayout) {
+		if node == nil {
+			return
+		} else if node.Pane != nil {
+			leaves = append(leaves, node)
+		} else {
+			walk(node.First)
+			walk(node.Second)
+		}
+	}
+	walk(p.root)
+	return leaves
+}
+
+// Divide the given area of the screen between every pane.
+func (p *Panes) Layout(x int, y int, width int, height int) ([]PaneRect, []PaneBorder) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var rects []PaneRect
+	var borders []PaneBorder
+	var layout func(node *PaneLayout, x int, y int, width int, height int)
+	layout = func(node *PaneLayout, x int, y int, width int, height int) {
+		if node == nil {
+			return
+		} else if node.Pane != nil {
+			rects = append(rects, PaneRect{*node.Pane, node == p.focused, x, y, width, height})
+		} else if node.Split == SPLIT_VERTICAL {
+			// The panes are separated by a one column border.
+			firstWidth := (width - 1) / 2
+			layout(node.First, x, y, firstWidth, height)
+			borders = append(borders, PaneBorder{x + firstWidth, y, height})
+			layout(node.Second, x+firstWidth+1, y, width-firstWidth-1, height)
+		} else {
+			firstHeight := height / 2
+			layout(node.First, x, y, width, firstHeight)
+			layout(node.Second, x, y+firstHeight, width, height-firstHeight)
+		}
+	}
+	layout(p.root, x, y, width, height)
+	return rects, borders
+}
+
+// Is the channel shown in a pane that isn't focused?
+func (p *Panes) Showing(conn gateway.Connection, channelId string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, leaf := range p.leaves() {
+		if leaf != p.focused && leaf.Pane.Connection == conn && leaf.Pane.Channel.Id == channelId {
+			return true
+		}
+	}
+	return false
+}
+
+// Add a message that just arrived to every pane (other than the focused one) showing its channel.
+func (p *Panes) AddMessage(conn gateway.Connection, data map[string]interface{}, cachedUsers map[string]*gateway.User) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	channelId, _ := data["channel"].(string)
+	for _, leaf := range p.leaves() {
+		pane := leaf.Pane
+		if leaf == p.focused || pane.Connection != conn || pane.Channel.Id != channelId {
+			continue
+		}
+
+		if data["subtype"] == "message_deleted" {
+			for index, message := range pane.Messages {
+				if message.Hash == data["deleted_ts"] {
+					pane.Messages = append(pane.Messages[:index], pane.Messages[index+1:]...)
+					break
+				}
+			}
+			continue
+		}
+		if data["subtype"] == "message_changed" {
+			ReplaceChangedMessage(pane.Messages, conn, data, cachedUsers)
+			continue
+		}
+
+		alreadyInHistory := false
+		for _, message := range pane.Messages {
+			if message.Hash == data["ts"] {
+				alreadyInHistory = true
+				break
+			}
+		}
+		if alreadyInHistory || pane.HasNewerMessageHistory {
+			continue
+		}
+
+		if message, err := conn.ParseMessage(data, cachedUsers); err == nil {
+			pane.Messages = append(pane.Messages, *message)
+		}
+	}
+}
+
+// Copy what's shown on the screen into the focused pane, so it can be shown again when it's focused
+// later.
+func savePane(state *State, pane *Pane) {
+	conn := state.ActiveConnection()
+	pane.Connection = conn
+	if channel := conn.SelectedChannel(); channel != nil {
+		pane.Channel = *channel
+	}
+	pane.Messages = append([]gateway.Message{}, conn.MessageHistory()...)
+	pane.HasNewerMessageHistory = conn.HasNewerMessageHistory()
+	pane.SelectedMessageIndex = state.SelectedMessageIndex
+	pane.BottomDisplayedItem = state.BottomDisplayedItem
+}
+
+// Show a pane on the screen by selecting its connection and channel. If the pane doesn't have any
+// messages yet, they're fetched when the connection refreshes.
+func restorePane(state *State, pane *Pane) error {
+	connectionIndex := -1
+	for index, conn := range state.Connections {
+		if conn == pane.Connection {
+			connectionIndex = index
+			break
+		}
+	}
+	if connectionIndex == -1 {
+		return errors.New("The connection shown in this pane is no longer connected.")
+	}
+
+	channel := pane.Channel
+	if connectionIndex != state.ActiveConnectionIndex() || len(pane.Messages) == 0 {
+		state.SetActiveConnection(connectionIndex)
+	}
+	pane.Connection.SetSelectedChannel(&channel)
+	pane.Connection.SetMessageHistory(append([]gateway.Message{}, pane.Messages...))
+	pane.Connection.SetHasNewerMessageHistory(pane.HasNewerMessageHistory)
+	state.SelectedMessageIndex = pane.SelectedMessageIndex
+	state.BottomDisplayedItem = pane.BottomDisplayedItem
+	return nil
+}
+
+// Split the focused pane in two, and focus the new pane showing the given channel.
+func SplitPane(state *State, direction string, conn gateway.Connection, channel gateway.Channel) error {
+	if state.ActiveConnection() == nil || state.ActiveConnection().SelectedChannel() == nil {
+		return errors.New("No channel is selected to split.")
+	}
+
+	panes := state.Panes
+	panes.mutex.Lock()
+	defer panes.mutex.Unlock()
+
+	if panes.root == nil {
+		panes.root = &PaneLayout{Pane: &Pane{}}
+		panes.focused = panes.root
+	}
+
+	current := &Pane{}
+	savePane(state, current)
+
+	// Splitting a pane showing the same channel starts with the same messages.
+	pane := &Pane{Connection: conn, Channel: channel}
+	if conn == current.Connection && channel.Id == current.Channel.Id {
+		*pane = *current
+		pane.Messages = append([]gateway.Message{}, current.Messages...)
+	}
+
+	node := panes.focused
+	node.Split = direction
+	node.First = &PaneLayout{Pane: current, parent: node}
+	node.Second = &PaneLayout{Pane: pane, parent: node}
+	node.Pane = nil
+
+	panes.focused = node.Second
+	return restorePane(state, pane)
+}
+
+// Close the focused pane, and focus the pane that takes its place.
+func ClosePane(state *State) error {
+	panes := state.Panes
+	panes.mutex.Lock()
+	defer panes.mutex.Unlock()
+
+	if panes.focused == nil || panes.focused.parent == nil {
+		return errors.New("Can't close the only pane.")
+	}
+
+	// The other half of the split takes up the space of both halves.
+	parent := panes.focused.parent
+	sibling := parent.First
+	if sibling == panes.focused {
+		sibling = parent.Second
+	}
+	parent.Split = sibling.Split
+	parent.First = sibling.First
+	parent.Second = sibling.Second
+	parent.Pane = sibling.Pane
+	for _, child := range []*PaneLayout{parent.First, parent.Second} {
+		if child != nil {
+			child.parent = parent
+		}
+	}
+
+	panes.focused = panes.leaves()[0]
+	for _, leaf := range panes.leaves() {
+		if isWithin(leaf, parent) {
+			panes.focused = leaf
+			break
+		}
+	}
+	return restorePane(state, panes.focused.Pane)
+}
+
+func isWithin(node *PaneLayout, ancestor *PaneLayout) bool {
+	for ; node != nil; node = node.parent {
+		if node == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Focus the pane `offset` places after the focused pane, wrapping around at either end. A negative
+// offset moves backwards.
+func FocusPane(state *State, offset int) error {
+	panes := state.Panes
+	panes.mutex.Lock()
+	defer panes.mutex.Unlock()
+
+	leaves := panes.leaves()
+	if len(leaves) < 2 {
+		return errors.New("There's only one pane.")
+	}
+
+	current := 0
+	for index, leaf := range leaves {
+		if leaf == panes.focused {
+			current = index
+		}
+	}
+	index := (current + offset) % len(leaves)
+	if index < 0 {
+		index += len(leaves)
+	}
+
+	savePane(state, panes.focused.Pane)
+	panes.focused = leaves[index]
+	return restorePane(state, panes.focused.Pane)
+}
+
+// The channel to show in a new pane, from the arguments passed to `/split`: a connection and a
+// channel, a channel in the active connection, or the selected channel when nothing is passed.
+func paneChannelFromArgs(args []string, state *State) (gateway.Connection, *gateway.Channel, error) {
+	conn := state.ActiveConnection()
+	if conn == nil {
+		return nil, nil, errors.New("No active connection!")
+	}
+
+	var channelName string
+	if len(args) == 3 { // /split "connection name" "channel name"
+		conn = nil
+		for _, connection := range state.Connections {
+			if connection.Name() == args[1] {
+				conn = connection
+				break
+			}
+		}
+		if conn == nil {
+			return nil, nil, errors.New("No such connection: " + args[1])
+		}
+		channelName = args[2]
+	} else if len(args) == 2 { // /split "channel name"
+		channelName = args[1]
+	} else if len(args) == 1 {
+		if channel := conn.SelectedChannel(); channel != nil {
+			return conn, channel, nil
+		}
+		return nil, nil, errors.New("Didn't pass a channel and no channel is selected. Explicitly pass a channel?")
+	} else {
+		return nil, nil, errors.New("Please use less arguments. /split [connection name] [channel name]")
+	}
+
+	for _, channel := range conn.Channels() {
+		if channel.Name == strings.TrimPrefix(channelName, "#") || channel.Label() == channelName {
+			return conn, &channel, nil
+		}
+	}
+	return nil, nil, errors.New("No such channel: " + channelName)
+}
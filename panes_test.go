@@ -0,0 +1,101 @@
+package main_test
+
+import (
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+)
+
+func panesTestState() (*State, *gatewaySlack.SlackConnection) {
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	channels := []gateway.Channel{
+		gateway.Channel{Id: "C1", Name: "general", IsMember: true},
+		gateway.Channel{Id: "C2", Name: "incidents", IsMember: true},
+	}
+	conn.SetChannels(channels)
+	conn.SetSelectedChannel(&channels[0])
+	conn.SetMessageHistory([]gateway.Message{
+		gateway.Message{Text: "Hello", Hash: "1.0"},
+		gateway.Message{Text: "World", Hash: "2.0"},
+	})
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+	return state, conn
+}
+
+func TestSplitPaneKeepsEachPanesMessages(t *testing.T) {
+	state, conn := panesTestState()
+	state.SelectedMessageIndex = 1
+
+	if err := GetCommand("Split").Handler([]string{"split", "#incidents"}, state); err != nil {
+		t.Fatalf("Error splitting: %s", err)
+	}
+	if !state.Panes.IsSplit() {
+		t.Fatalf("Expected the screen to be split")
+	}
+	if channel := conn.SelectedChannel(); channel.Id != "C2" || len(conn.MessageHistory()) != 0 {
+		t.Errorf("Expected the new pane to be focused with its messages not yet fetched, got %+v", channel)
+	}
+	conn.SetMessageHistory([]gateway.Message{gateway.Message{Text: "Something broke", Hash: "3.0"}})
+
+	// Focusing the first pane shows its messages and selection again.
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlW, ' ', tcell.ModNone), state, nil, make(chan struct{}, 1))
+	if channel := conn.SelectedChannel(); channel.Id != "C1" || len(conn.MessageHistory()) != 2 || state.SelectedMessageIndex != 1 {
+		t.Errorf("Expected #general to be focused with its messages, got %+v and %+v", channel, conn.MessageHistory())
+	}
+
+	rects, borders := state.Panes.Layout(0, 0, 80, 20)
+	if len(rects) != 2 || len(borders) != 0 {
+		t.Fatalf("Expected two panes with no border, got %+v and %+v", rects, borders)
+	}
+	if !rects[0].Focused || rects[0].Height != 10 || rects[1].Y != 10 || rects[1].Pane.Channel.Id != "C2" {
+		t.Errorf("Expected #general above #incidents, got %+v", rects)
+	}
+	if len(rects[1].Pane.Messages) != 1 || rects[1].Pane.Messages[0].Text != "Something broke" {
+		t.Errorf("Expected #incidents to keep its messages, got %+v", rects[1].Pane.Messages)
+	}
+}
+
+func TestSplitPaneReceivesMessages(t *testing.T) {
+	state, conn := panesTestState()
+	if err := GetCommand("VerticalSplit").Handler([]string{"vsplit", "incidents"}, state); err != nil {
+		t.Fatalf("Error splitting: %s", err)
+	}
+	if err := FocusPane(state, -1); err != nil {
+		t.Fatalf("Error focusing: %s", err)
+	}
+
+	// A message in the unfocused pane's channel is added to it, and isn't unread.
+	data := map[string]interface{}{"type": "message", "channel": "C2", "user": "U1", "text": "Paging", "ts": "4.0"}
+	state.Panes.AddMessage(conn, data, map[string]*gateway.User{"U1": &gateway.User{Id: "U1", Name: "alice"}})
+	state.Panes.AddMessage(conn, data, map[string]*gateway.User{"U1": &gateway.User{Id: "U1", Name: "alice"}})
+	if !state.Panes.Showing(conn, "C2") || state.Panes.Showing(conn, "C1") {
+		t.Errorf("Expected only #incidents to be shown in an unfocused pane")
+	}
+
+	rects, borders := state.Panes.Layout(0, 0, 81, 20)
+	if len(borders) != 1 || borders[0].X != 40 || rects[1].X != 41 || rects[1].Width != 40 {
+		t.Errorf("Expected two panes beside each other, got %+v and %+v", rects, borders)
+	}
+	if messages := rects[1].Pane.Messages; len(messages) != 1 || messages[0].Text != "Paging" {
+		t.Errorf("Expected the message to be added to #incidents once, got %+v", messages)
+	}
+
+	// Closing the focused pane leaves #incidents, with the message that arrived.
+	if err := GetCommand("ClosePane").Handler([]string{"close"}, state); err != nil {
+		t.Fatalf("Error closing: %s", err)
+	}
+	if state.Panes.IsSplit() {
+		t.Errorf("Expected one pane to be left")
+	}
+	if channel := conn.SelectedChannel(); channel.Id != "C2" || len(conn.MessageHistory()) != 1 {
+		t.Errorf("Expected #incidents to be focused, got %+v and %+v", channel, conn.MessageHistory())
+	}
+	if err := ClosePane(state); err == nil {
+		t.Errorf("Expected the last pane not to close")
+	}
+}
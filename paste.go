@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+type PasteType int
+
+const (
+	PASTE_MESSAGE PasteType = iota // Short text, sent as a normal message
+	PASTE_SNIPPET                  // Long text, uploaded as a snippet
+	PASTE_IMAGE                    // An image, uploaded as a file
+)
+
+// Text longer than this is always sent as a snippet, no matter how many lines it has.
+const maxInlinePasteLength = 1000
+
+// Programs that can read an image from the clipboard, tried in order. The clipboard library only
+// handles text.
+var clipboardImageCommands = [][]string{
+	{"wl-paste", "--no-newline", "--type", "image/png"},           // Wayland
+	{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}, // X11
+	{"pngpaste", "-"}, // macOS, `brew install pngpaste`
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// What `/paste` is going to send.
+type Paste struct {
+	Type     PasteType
+	Text     string
+	Image    []byte
+	Language string // For snippets, the language the text is written in (ie, `go`)
+}
+
+// Try each program that can read images from the clipboard, returning the first png found.
+func ReadClipboardImage() ([]byte, error) {
+	for _, command := range clipboardImageCommands {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+
+		output, err := exec.Command(command[0], command[1:]...).Output()
+		if err == nil && bytes.HasPrefix(output, pngHeader) {
+			return output, nil
+		}
+	}
+	return nil, errors.New("No image in the clipboard.")
+}
+
+// Read the clipboard, preferring an image if there's one in it.
+func ReadPaste(maxInlineLines int) (Paste, error) {
+	if data, err := ReadClipboardImage(); err == nil {
+		return Paste{Type: PASTE_IMAGE, Image: data}, nil
+	}
+
+	text, err := clipboard.ReadAll()
+	if err != nil {
+		return Paste{}, errors.New(fmt.Sprintf("Couldn't read the clipboard: %s", err))
+	}
+	if len(strings.TrimSpace(text)) == 0 {
+		return Paste{}, errors.New("The clipboard is empty.")
+	}
+	return ClassifyPastedText(text, maxInlineLines), nil
+}
+
+// Short text is sent as a message, and anything longer is uploaded as a snippet.
+func ClassifyPastedText(text string, maxInlineLines int) Paste {
+	text = strings.TrimRight(text, "\n")
+	lines := strings.Count(text, "\n") + 1
+
+	if lines <= maxInlineLines && len(text) <= maxInlinePasteLength {
+		return Paste{Type: PASTE_MESSAGE, Text: text}
+	} else {
+		return Paste{Type: PASTE_SNIPPET, Text: text, Language: DetectLanguage(text)}
+	}
+}
+
+// Each language slick can detect, the extension of its files, and patterns that are a sign that
+// text is written in it. Languages are checked in order, and the first with the most matching
+// patterns wins.
+var pasteLanguages = []struct {
+	Filetype  string // The name slack uses for the language
+	Extension string
+	Patterns  []*regexp.Regexp
+}{
+	{"diff", "diff", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^diff --git `),
+		regexp.MustCompile(`(?m)^--- .+\n\+\+\+ `),
+		regexp.MustCompile(`(?m)^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`),
+	}},
+	{"go", "go", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^package \w+$`),
+		regexp.MustCompile(`(?m)^func (\(\w+ \*?\w+\) )?\w+\(`),
+		regexp.MustCompile(`\w+ := `),
+		regexp.MustCompile(`if err != nil \{`),
+	}},
+	{"python", "py", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s*def \w+\(.*\):$`),
+		regexp.MustCompile(`(?m)^(from [\w.]+ )?import \w+[^;]*$`),
+		regexp.MustCompile(`\bself\.\w+`),
+		regexp.MustCompile(`(?m)^if __name__ == `),
+	}},
+	{"javascript", "js", []*regexp.Regexp{
+		regexp.MustCompile(`\bfunction\s*\w*\(`),
+		regexp.MustCompile(`(?m)^\s*(const|let|var) \w+ = `),
+		regexp.MustCompile(`=> ?\{`),
+		regexp.MustCompile(`console\.log\(|require\(['"]`),
+	}},
+	{"shell", "sh", []*regexp.Regexp{
+		regexp.MustCompile(`^#!/.*\b(ba|z)?sh\b`),
+		regexp.MustCompile(`(?m)^\$ \w+`),
+		regexp.MustCompile(`(?m)^\s*(export \w+=|echo |sudo |cd |fi$|done$)`),
+	}},
+	{"html", "html", []*regexp.Regexp{
+		regexp.MustCompile(`(?i)<!doctype html|<html`),
+		regexp.MustCompile(`(?i)</(div|span|p|body|head|a)>`),
+	}},
+	{"sql", "sql", []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\s*(select .+ from|insert into|update \w+ set|create table|delete from)\b`),
+		regexp.MustCompile(`(?i)\b(where|group by|order by|left join|inner join)\b`),
+	}},
+	{"yaml", "yml", []*regexp.Regexp{
+		regexp.MustCompile(`^---\n`),
+		regexp.MustCompile(`(?m)^\w[\w-]*:( .+)?\n\s+[\w-]+: `),
+		regexp.MustCompile(`(?m)^\s*- \w+: `),
+	}},
+}
+
+// Guess the language that text is written in, returning slack's name for it. Returns `text` if
+// the language isn't known.
+func DetectLanguage(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	language := "text"
+	mostMatches := 0
+	for _, candidate := range pasteLanguages {
+		matches := 0
+		for _, pattern := range candidate.Patterns {
+			if pattern.MatchString(text) {
+				matches += 1
+			}
+		}
+		if matches > mostMatches {
+			language = candidate.Filetype
+			mostMatches = matches
+		}
+	}
+	return language
+}
+
+// The extension to give a snippet in the given language.
+func languageExtension(language string) string {
+	if language == "json" {
+		return "json"
+	}
+	for _, candidate := range pasteLanguages {
+		if candidate.Filetype == language {
+			return candidate.Extension
+		}
+	}
+	return "txt"
+}
+
+// Describe what will be sent, for the confirmation modal.
+func (paste Paste) Describe(channel string) string {
+	switch paste.Type {
+	case PASTE_IMAGE:
+		size := FormatByteSize(int64(len(paste.Image)))
+		if config, _, err := image.DecodeConfig(bytes.NewReader(paste.Image)); err == nil {
+			return fmt.Sprintf("Upload an image from the clipboard (%dx%d, %s) to #%s?", config.Width, config.Height, size, channel)
+		}
+		return fmt.Sprintf("Upload an image from the clipboard (%s) to #%s?", size, channel)
+	case PASTE_SNIPPET:
+		lines := strings.Count(paste.Text, "\n") + 1
+		return fmt.Sprintf("Upload a %s snippet (%d lines) to #%s?\n\n%s", paste.Language, lines, channel, paste.Text)
+	default:
+		return fmt.Sprintf("Send this message to #%s?\n\n%s", channel, paste.Text)
+	}
+}
+
+// Send the paste to the given channel. Images and snippets are uploaded in the background, so the
+// upload's progress is shown and it can be cancelled.
+func (paste Paste) Send(state *State, conn gateway.Connection, channel *gateway.Channel) error {
+	name := "paste-" + time.Now().Format("2006-01-02-150405")
+
+	switch paste.Type {
+	case PASTE_IMAGE:
+		return state.Uploads.StartTemporary(conn, name+".png", paste.Image, channel, "")
+
+	case PASTE_SNIPPET:
+		return state.Uploads.StartTemporary(
+			conn,
+			name+"."+languageExtension(paste.Language),
+			[]byte(paste.Text),
+			channel,
+			paste.Language,
+		)
+
+	default:
+		return SendMessage(state, conn, channel, paste.Text)
+	}
+}
+
+// How many lines of text can be pasted before it's sent as a snippet?
+func maxInlinePasteLines(state *State) int {
+	lines, err := strconv.Atoi(state.Configuration["Paste.MaxInlineLines"])
+	if err != nil {
+		return 0
+	}
+	return lines
+}
@@ -0,0 +1,88 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	for _, test := range []struct {
+		Text     string
+		Language string
+	}{
+		{"package main\n\nfunc main() {\n\tfoo := 1\n}", "go"},
+		{"import os\n\ndef main():\n    print(os.getcwd())", "python"},
+		{"const foo = require('foo');\nfoo.on('bar', () => {\n  console.log('baz');\n});", "javascript"},
+		{"#!/bin/bash\nexport FOO=bar\necho $FOO", "shell"},
+		{`{"foo": [1, 2, 3], "bar": null}`, "json"},
+		{"diff --git a/foo b/foo\n--- a/foo\n+++ b/foo\n@@ -1,2 +1,2 @@\n-foo\n+bar", "diff"},
+		{"SELECT id, name FROM users\nWHERE id = 1\nORDER BY name;", "sql"},
+		{"Hello there,\nthis is just some text\nthat someone copied.", "text"},
+	} {
+		if language := DetectLanguage(test.Text); language != test.Language {
+			t.Errorf("Expected %s to be detected as %s, got %s", test.Text, test.Language, language)
+		}
+	}
+}
+
+func TestClassifyPastedText(t *testing.T) {
+	if paste := ClassifyPastedText("Hello world\n", 5); paste.Type != PASTE_MESSAGE || paste.Text != "Hello world" {
+		t.Errorf("Short text wasn't sent as a message: %+v", paste)
+	}
+
+	code := "package main\n\nfunc main() {\n\tfoo := 1\n\tbar := 2\n\tbaz := 3\n}"
+	if paste := ClassifyPastedText(code, 5); paste.Type != PASTE_SNIPPET || paste.Language != "go" {
+		t.Errorf("Long text wasn't sent as a snippet: %+v", paste)
+	}
+
+	if paste := ClassifyPastedText(strings.Repeat("a", 2000), 5); paste.Type != PASTE_SNIPPET {
+		t.Errorf("A single very long line wasn't sent as a snippet: %+v", paste)
+	}
+}
+
+func TestPasteSnippetIsUploadedInBackground(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	httpmock.RegisterResponder("POST", "https://slack.com/api/files.upload?token=token&channels=C1&filetype=go",
+		func(req *http.Request) (*http.Response, error) {
+			data, _ := ioutil.ReadAll(req.Body)
+			body = string(data)
+			return httpmock.NewStringResponse(200, `{"ok": true}`), nil
+		})
+
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	paste := Paste{Type: PASTE_SNIPPET, Text: "package main", Language: "go"}
+	if err := paste.Send(state, conn, &gateway.Channel{Id: "C1", Name: "general"}); err != nil {
+		t.Fatalf("Error sending paste: %s", err)
+	}
+
+	// Wait for the upload to finish.
+	var upload Upload
+	for upload = range state.Uploads.Updates {
+		if !upload.Active() {
+			break
+		}
+	}
+
+	if upload.Status != UPLOAD_COMPLETE || !strings.Contains(body, "package main") {
+		t.Errorf("Snippet wasn't uploaded: %+v", upload)
+	}
+	if !strings.HasPrefix(filepath.Base(upload.Path), "paste-") || filepath.Ext(upload.Path) != ".go" {
+		t.Errorf("Snippet should be named like paste-<time>.go, got %s", upload.Path)
+	}
+	if _, err := os.Stat(filepath.Dir(upload.Path)); !os.IsNotExist(err) {
+		t.Errorf("Temporary directory %s wasn't deleted", filepath.Dir(upload.Path))
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/1egoman/slick/gateway"
+	"github.com/kyokomi/emoji"
+)
+
+// Find a user on the active connection by name, ie `alice` or `@alice`.
+func FindUserByName(conn gateway.Connection, name string) (*gateway.User, error) {
+	name = strings.TrimPrefix(name, "@")
+
+	// Users are fetched the first time they're needed, since large teams have a lot of them.
+	if len(conn.Users()) == 0 {
+		if _, err := conn.FetchUsers(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, user := range conn.Users() {
+		if user.Name == name {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("No such user: " + name)
+}
+
+// Render a user's profile for a modal. `now` is used to show the user's local time.
+func UserProfileBody(user gateway.User, online bool, now time.Time) string {
+	var body []string
+
+	if len(user.RealName) > 0 {
+		body = append(body, fmt.Sprintf("%s (@%s)", user.RealName, user.Name))
+	} else {
+		body = append(body, "@"+user.Name)
+	}
+	if len(user.Title) > 0 {
+		body = append(body, user.Title)
+	}
+	body = append(body, "")
+
+	if online {
+		body = append(body, "Presence: online")
+	} else {
+		body = append(body, "Presence: away")
+	}
+	if status := strings.TrimSpace(emoji.Sprint(user.StatusEmoji) + " " + user.Status); len(status) > 0 {
+		body = append(body, "Status: "+status)
+	}
+
+	if len(user.Timezone) > 0 {
+		localTime := now.In(time.FixedZone(user.Timezone, user.TimezoneOffset))
+		timezone := user.Timezone
+		if len(user.TimezoneLabel) > 0 {
+			timezone = user.TimezoneLabel
+		}
+		body = append(body, fmt.Sprintf("Local time: %s (%s)", localTime.Format("3:04 PM"), timezone))
+	}
+
+	for _, field := range []struct {
+		Name  string
+		Value string
+	}{
+		{"Email", user.Email},
+		{"Phone", user.Phone},
+		{"Skype", user.Skype},
+	} {
+		if len(field.Value) > 0 {
+			body = append(body, field.Name+": "+field.Value)
+		}
+	}
+
+	body = append(body, "", fmt.Sprintf("Press enter to send a direct message to @%s.", user.Name))
+	return strings.Join(body, "\n")
+}
+
+// Open a modal with a user's profile. Pressing enter in the modal opens a direct message with them.
+func OpenUserProfile(state *State, user *gateway.User) {
+	conn := state.ActiveConnection()
+
+	state.Mode = "modl"
+	state.Modal.Reset()
+	state.Modal.Title = "@" + user.Name
+	state.Modal.Body = UserProfileBody(*user, conn.UserOnline(user), time.Now())
+	state.Modal.Confirm = func() error {
+		return OpenConversationWithUsers(state, []*gateway.User{user})
+	}
+}
@@ -0,0 +1,74 @@
+package main_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestUserProfileBody(t *testing.T) {
+	user := gateway.User{
+		Name:           "alice",
+		RealName:       "Alice Smith",
+		Title:          "Engineer",
+		Status:         "On vacation",
+		Email:          "alice@example.com",
+		Timezone:       "America/New_York",
+		TimezoneLabel:  "Eastern Daylight Time",
+		TimezoneOffset: -4 * 60 * 60,
+	}
+	now := time.Date(2017, 6, 1, 18, 30, 0, 0, time.UTC)
+
+	expected := strings.Join([]string{
+		"Alice Smith (@alice)",
+		"Engineer",
+		"",
+		"Presence: online",
+		"Status: On vacation",
+		"Local time: 2:30 PM (Eastern Daylight Time)",
+		"Email: alice@example.com",
+		"",
+		"Press enter to send a direct message to @alice.",
+	}, "\n")
+	if body := UserProfileBody(user, true, now); body != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, body)
+	}
+}
+
+func TestWhoisOpensDirectMessage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://slack.com/api/users.list?token=token&limit=200",
+		httpmock.NewStringResponder(200, `{"ok": true, "members": [{"id": "U1", "name": "alice"}]}`))
+	httpmock.RegisterResponder("GET", "https://slack.com/api/conversations.open?token=token&users=U1&return_im=true",
+		httpmock.NewStringResponder(200, `{"ok": true, "channel": {"id": "D1"}}`))
+
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelf(gateway.User{Id: "U0", Name: "me"})
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+
+	if err := GetCommand("Whois").Handler([]string{"whois", "@alice"}, state); err != nil {
+		t.Fatalf("Error opening profile: %s", err)
+	}
+	if state.Mode != "modl" || state.Modal.Title != "@alice" {
+		t.Fatalf("Profile modal wasn't opened, in mode %s", state.Mode)
+	}
+
+	quit := make(chan struct{}, 1)
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), state, nil, quit)
+	channel := conn.SelectedChannel()
+	if channel == nil || channel.Id != "D1" || channel.SubType != gateway.TYPE_DIRECT_MESSAGE || channel.Name != "im-me-alice" {
+		t.Errorf("Expected the direct message with alice to be selected, got %+v", channel)
+	}
+	if len(conn.Channels()) != 1 {
+		t.Errorf("The new direct message wasn't added to the channels: %+v", conn.Channels())
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"fmt"
+	"image"
 	"log"
-	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
 )
 
 // Given application state and a frontend, render the state to the screen.
@@ -37,24 +40,84 @@ func render(state *State, term *frontend.TerminalDisplay) {
 		}()
 	}
 
+	// Everything that can be clicked on is found again as it's drawn.
+	term.ClearClickTargets()
+
 	// Before rendering any controls, make sure that we take into account multiline commandS:
 	// Status bar: 1 high
 	// Sommand Bar: the hight is the nmber of lines in the command.
 	frontend.BottomPadding = 1 + len(strings.Split(string(state.Command), "\n"))
 
-	// Render messages provided by the active connection
-	if state.ActiveConnection() != nil {
-		state.RenderedMessageNumber, state.RenderedAllMessages = term.DrawMessages(
-			state.ActiveConnection().MessageHistory(),                                   // List of messages
-			len(state.ActiveConnection().MessageHistory())-1-state.SelectedMessageIndex, // Is a message selected?
-			state.BottomDisplayedItem,                                                   // Bottommost item
-			state.ActiveConnection().UserById,
-			state.ActiveConnection().UserOnline,
-			state.Configuration,
-		)
+	// The sidebar is drawn on the left of the screen, and the messages are drawn next to it.
+	messageDisplay := term
+	if state.Configuration["Sidebar.Visible"] == "true" {
+		width, height := term.Screen().Size()
+		sidebarWidth, err := strconv.Atoi(state.Configuration["Sidebar.Width"])
+		if err == nil && sidebarWidth > 1 && sidebarWidth < width {
+			term.Region(0, 0, sidebarWidth, height-frontend.BottomPadding).DrawSidebar(
+				state.Connections,
+				state.ActiveConnection(),
+				state.Unreads.Count,
+				state.Configuration,
+			)
+			messageDisplay = term.Region(sidebarWidth, 0, width-sidebarWidth, height)
+		}
+	}
+
+	// When the screen is split, each pane shows a channel with its title above it.
+	if state.Panes.IsSplit() {
+		width, height := messageDisplay.Screen().Size()
+		rects, borders := state.Panes.Layout(0, 0, width, height-frontend.BottomPadding)
+		for _, border := range borders {
+			messageDisplay.Region(border.X, border.Y, 1, border.Height).DrawPaneBorder(state.Configuration)
+		}
+		for index, rect := range rects {
+			if rect.Height < 2 {
+				continue
+			}
+
+			pane := rect.Pane
+			if rect.Focused && state.ActiveConnection() != nil {
+				pane.Connection = state.ActiveConnection()
+				if channel := pane.Connection.SelectedChannel(); channel != nil {
+					pane.Channel = *channel
+				}
+			}
+			messageDisplay.Region(rect.X, rect.Y, rect.Width, 1).DrawPaneTitle(
+				fmt.Sprintf("%s (%s)", pane.Channel.Label(), pane.Connection.Name()),
+				rect.Focused,
+				state.Configuration,
+			)
+
+			// Messages are drawn above the bottom padding, so the region extends past the pane.
+			paneDisplay := messageDisplay.Region(rect.X, rect.Y+1, rect.Width, rect.Height-1+frontend.BottomPadding)
+			if rect.Focused {
+				renderActiveConnectionMessages(state, term, paneDisplay)
+			} else {
+				paneDisplay.DrawMessages(
+					pane.Messages,
+					-1, // Only the focused pane has a selected message
+					pane.BottomDisplayedItem,
+					pane.Connection.LastRead(pane.Channel),
+					pane.Connection.UserById,
+					pane.Connection.UserOnline,
+					imagePreviewFor(state, term, pane.Connection),
+					state.Configuration,
+				)
+
+				// Clicking on a pane that isn't focused focuses it.
+				messageDisplay.AddClickTarget(frontend.ClickTarget{
+					Type:   frontend.CLICK_TARGET_PANE,
+					Index:  index,
+					X:      rect.X,
+					Y:      rect.Y,
+					Width:  rect.Width,
+					Height: rect.Height,
+				})
+			}
+		}
 	} else {
-		term.DrawBlankLines(0, -1*frontend.BottomPadding)
-		term.DrawInfoPage()
+		renderActiveConnectionMessages(state, term, messageDisplay)
 	}
 
 	if state.ActiveConnection() == nil {
@@ -62,6 +125,7 @@ func render(state *State, term *frontend.TerminalDisplay) {
 			string(state.Command),       // The command that the user is typing
 			state.CommandCursorPosition, // The cursor position
 			nil,                  // The selected channel
+			nil,                  // Is a user online?
 			"(no active connec)", // The selected team name
 			state.Offline,        // Is the client offline?
 			state.Configuration,
@@ -71,6 +135,7 @@ func render(state *State, term *frontend.TerminalDisplay) {
 			string(state.Command),                      // The command that the user is typing
 			state.CommandCursorPosition,                // The cursor position
 			state.ActiveConnection().SelectedChannel(), // The selected channel
+			state.ActiveConnection().UserOnline,        // Is a user online?
 			state.ActiveConnection().Name(),            // The selected team name
 			state.Offline,                              // Is the client offline?
 			state.Configuration,
@@ -88,7 +153,7 @@ func render(state *State, term *frontend.TerminalDisplay) {
 	if state.SelectionInput.Visible {
 		// Sort items by the search command
 		state.SelectionInput.Needle = string(state.Command)
-		sort.Sort(state.SelectionInput)
+		state.SelectionInput.Sort(state.Frecency)
 		if state.SelectionInput.OnResort != nil {
 			state.SelectionInput.OnResort(state)
 		}
@@ -100,13 +165,21 @@ func render(state *State, term *frontend.TerminalDisplay) {
 				state.SelectionInput.StringItems,
 				state.SelectionInput.SelectedItem,
 				state.SelectionInput.BottomItem,
-				state.SelectionInput.Rank,
+				state.SelectionInput.Match,
 				state.Configuration,
 			)
 		}
 	}
 
-	if state.Mode == "modl" {
+	if state.Mode == "modl" && len(state.Modal.Language) > 0 {
+		term.DrawCodeModal(
+			state.Modal.Title,
+			state.Modal.Body,
+			state.Modal.Language,
+			state.Configuration["Message.Part.CodeBlockTheme"],
+			state.Modal.ScrollPosition,
+		)
+	} else if state.Mode == "modl" {
 		term.DrawModal(
 			state.Modal.Title,
 			state.Modal.Body,
@@ -117,3 +190,39 @@ func render(state *State, term *frontend.TerminalDisplay) {
 
 	term.Render()
 }
+
+// Only preview images when they're enabled, since they have to be downloaded.
+func imagePreviewFor(state *State, term *frontend.TerminalDisplay, conn gateway.Connection) func(file *gateway.File) image.Image {
+	if state.Configuration["Message.ImagePreview"] != "true" {
+		return nil
+	}
+	return func(file *gateway.File) image.Image {
+		return state.ImagePreviews.Preview(conn, file, func() { render(state, term) })
+	}
+}
+
+// Render messages provided by the active connection
+func renderActiveConnectionMessages(state *State, term *frontend.TerminalDisplay, messageDisplay *frontend.TerminalDisplay) {
+	if state.ActiveConnection() != nil {
+		var lastRead string
+		if channel := state.ActiveConnection().SelectedChannel(); channel != nil {
+			lastRead = state.ActiveConnection().LastRead(*channel)
+			// The messages in the channel being looked at have been read.
+			state.Unreads.Clear(state.ActiveConnection(), *channel)
+		}
+
+		state.RenderedMessageNumber, state.RenderedAllMessages = messageDisplay.DrawMessages(
+			state.ActiveConnection().MessageHistory(),                                   // List of messages
+			len(state.ActiveConnection().MessageHistory())-1-state.SelectedMessageIndex, // Is a message selected?
+			state.BottomDisplayedItem,                                                   // Bottommost item
+			lastRead,                                                                    // Last read message
+			state.ActiveConnection().UserById,
+			state.ActiveConnection().UserOnline,
+			imagePreviewFor(state, term, state.ActiveConnection()),
+			state.Configuration,
+		)
+	} else {
+		messageDisplay.DrawBlankLines(0, -1*frontend.BottomPadding)
+		messageDisplay.DrawInfoPage()
+	}
+}
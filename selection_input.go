@@ -1,7 +1,9 @@
 package main
 
 import (
+	"sort"
 	"strings"
+	"time"
 )
 
 /*/ / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / / /
@@ -42,6 +44,27 @@ type SelectionInput struct {
 
 	// Number of characters at the start of the search string to disregard
 	ThrowAwayPrefix int
+
+	// Items picked often aren't boosted in pickers that are already in an order the user chose (ie,
+	// `/browse`).
+	IgnoreFrecency bool
+
+	// Counts how many times the fuzzy picker has been shown, so that a picker opened while handling
+	// a selection (ie, by running `/browse` from the command picker) isn't hidden afterwards.
+	generation int
+
+	// The score of each item, and the results of the last sort. Items are only scored again when
+	// the needle or the items change, so large lists stay fast.
+	scores       []int
+	matches      map[string]selectionInputMatch
+	sortedNeedle string
+	sortedItems  *string
+	sortedLength int
+}
+
+type selectionInputMatch struct {
+	Score     int
+	Positions []int
 }
 
 func (p SelectionInput) Len() int {
@@ -49,32 +72,117 @@ func (p SelectionInput) Len() int {
 }
 
 func (p SelectionInput) Less(i, j int) bool {
-	iItem := p.StringItems[i]
-	jItem := p.StringItems[j]
-	return p.Rank(iItem) > p.Rank(jItem)
+	return p.scores[i] > p.scores[j]
 }
 
 func (p SelectionInput) Swap(i, j int) {
 	p.Items[i], p.Items[j] = p.Items[j], p.Items[i]
 	p.StringItems[i], p.StringItems[j] = p.StringItems[j], p.StringItems[i]
+	p.scores[i], p.scores[j] = p.scores[j], p.scores[i]
+}
+
+// The part of the needle to search for.
+func (p SelectionInput) search() string {
+	if len(p.Needle) < p.ThrowAwayPrefix {
+		return p.Needle
+	} else {
+		return p.Needle[p.ThrowAwayPrefix:]
+	}
+}
+
+// The part of an item that's searched, and remembered when it's picked. Anything after a tab is
+// only shown on the right of the picker.
+func SelectionInputKey(item string) string {
+	if index := strings.Index(item, "\t"); index >= 0 {
+		return item[:index]
+	}
+	return item
+}
+
+// How much lower items starting with a `.` are ranked.
+const hiddenItemPenalty = 20
+
+// Score an item against the needle. Items that don't match score -1, and aren't shown.
+func (p SelectionInput) match(item string, needle string, frecency *Frecency, now time.Time) selectionInputMatch {
+	key := SelectionInputKey(item)
+
+	score, positions := FuzzyMatch(needle, key)
+	if score < 0 {
+		return selectionInputMatch{Score: score, Positions: positions}
+	}
+
+	// Hidden files are ranked lower.
+	if len(key) > 0 && key[0] == '.' {
+		score -= hiddenItemPenalty
+		if score < 0 {
+			score = 0
+		}
+	}
+	if frecency != nil {
+		score += frecency.Score(key, now)
+	}
+	return selectionInputMatch{Score: score, Positions: positions}
+}
+
+// Score an item, returning the index of each character that matched the needle.
+func (p SelectionInput) Match(item string) (int, []int) {
+	if result, ok := p.matches[item]; ok {
+		return result.Score, result.Positions
+	}
+	result := p.match(item, p.search(), nil, time.Now())
+	return result.Score, result.Positions
 }
 
 func (p SelectionInput) Rank(item string) int {
-	var delta int = 0
-	if len(item) > 0 && item[0] == '.' {
-		delta -= 20
+	score, _ := p.Match(item)
+	return score
+}
+
+// Sort the items so the best matches for the needle come first. Items picked often (according to
+// `frecency`, which can be nil) are boosted.
+func (p *SelectionInput) Sort(frecency *Frecency) {
+	needle := p.search()
+	if p.IgnoreFrecency {
+		frecency = nil
+	}
+	var first *string
+	if len(p.StringItems) > 0 {
+		first = &p.StringItems[0]
+	}
+	if p.matches != nil && needle == p.sortedNeedle && first == p.sortedItems && len(p.StringItems) == p.sortedLength {
+		return
 	}
 
-	if len(p.Needle) < p.ThrowAwayPrefix {
-		return strings.Index(item, p.Needle) + delta
-	} else {
-		return strings.Index(item, p.Needle[p.ThrowAwayPrefix:]) + delta
+	now := time.Now()
+	p.scores = make([]int, len(p.StringItems))
+	p.matches = make(map[string]selectionInputMatch, len(p.StringItems))
+	for index, item := range p.StringItems {
+		result, ok := p.matches[item]
+		if !ok {
+			result = p.match(item, needle, frecency, now)
+			p.matches[item] = result
+		}
+		p.scores[index] = result.Score
+	}
+	sort.Stable(p)
+
+	p.sortedNeedle = needle
+	p.sortedItems = first
+	p.sortedLength = len(p.StringItems)
+}
+
+// The key of the selected item, for remembering that it was picked.
+func (p SelectionInput) SelectedKey() string {
+	if p.SelectedItem < 0 || p.SelectedItem >= len(p.StringItems) {
+		return ""
 	}
+	return SelectionInputKey(p.StringItems[p.SelectedItem])
 }
 
 // Show the fuzzy picker
 func (p *SelectionInput) Show(callbackOnSelected func(*State)) {
 	p.Visible = true
+	p.generation += 1
 	p.OnSelected = callbackOnSelected
 	p.SelectedItem = 0
 	p.BottomItem = 0
@@ -89,8 +197,11 @@ func (p *SelectionInput) Hide() {
 	p.Items = []interface{}{}
 	p.StringItems = []string{}
 	p.ThrowAwayPrefix = 0
+	p.IgnoreFrecency = false
 	p.OnSelected = nil
 	p.OnResort = nil
+	p.scores = nil
+	p.matches = nil
 }
 
 type SelectionInputConnectionChannelItem struct {
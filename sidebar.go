@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/1egoman/slick/frontend"
+	"github.com/1egoman/slick/gateway"
+)
+
+// Select the channel `offset` places after the selected channel in the sidebar, moving between
+// connections at the end of each connection's channels. A negative offset moves backwards.
+func SelectSidebarChannel(state *State, offset int) error {
+	type sidebarItem struct {
+		ConnectionIndex int
+		Channel         gateway.Channel
+	}
+
+	current := -1
+	var items []sidebarItem
+	for connectionIndex, conn := range state.Connections {
+		var selectedChannelId string
+		if selected := conn.SelectedChannel(); selected != nil {
+			selectedChannelId = selected.Id
+		}
+
+		for _, channel := range frontend.SidebarChannels(conn) {
+			if connectionIndex == state.ActiveConnectionIndex() && channel.Id == selectedChannelId {
+				current = len(items)
+			}
+			items = append(items, sidebarItem{connectionIndex, channel})
+		}
+	}
+
+	if len(items) == 0 {
+		return errors.New("No channels in the sidebar!")
+	}
+
+	// Wrap around at either end of the list.
+	index := (current + offset) % len(items)
+	if index < 0 {
+		index += len(items)
+	}
+
+	item := items[index]
+	state.SelectChannel(item.ConnectionIndex, &item.Channel)
+	return nil
+}
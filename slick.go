@@ -78,8 +78,9 @@ func main() {
 	tcell.SetEncodingFallback(tcell.EncodingFallbackASCII)
 	screen, _ = tcell.NewScreen()
 	screen.Init()
-	defer screen.Fini()
 	term = frontend.NewTerminalDisplay(screen)
+	defer term.Close() // The screen can be replaced, ie after composing a message in an editor.
+	term.SetImageOutput(os.Stdout)
 
 	// Initial render.
 	render(state, term)
@@ -98,6 +99,12 @@ func main() {
 	// Apply saved global state to state
 	ApplyGlobalStateToState(state)
 
+	// Load the messages sent in previous sessions, so they can be brought back with the arrow keys
+	state.InputHistory = LoadInputHistory(PathToInputHistory())
+
+	// Load which items have been picked in the fuzzy picker, so they can be ranked first
+	state.Frecency = LoadFrecency(PathToFrecency())
+
 	// GOROUTINE: On start, check for a new release and if found update to it.
 	go func() {
 		if _, ok := state.Configuration["AutoUpdate"]; ok {
@@ -112,22 +119,51 @@ func main() {
 		}
 	}()
 
+	// GOROUTINE: Show the progress of downloads as they happen.
+	go func() {
+		for download := range state.Downloads.Updates {
+			if download.Status == DOWNLOAD_FAILED {
+				state.Status.Errorf("%s", state.Downloads.StatusMessage(download))
+			} else {
+				state.Status.Printf("%s", state.Downloads.StatusMessage(download))
+			}
+
+			// Keep the list of downloads up to date if it's open.
+			if state.Mode == "modl" && state.Modal.Title == downloadsModalTitle {
+				state.Modal.Body = state.Downloads.ModalBody()
+			}
+			render(state, term)
+		}
+	}()
+
+	// GOROUTINE: Show the progress of uploads as they happen.
+	go func() {
+		for upload := range state.Uploads.Updates {
+			if upload.Status == UPLOAD_FAILED {
+				state.Status.Errorf("%s", state.Uploads.StatusMessage(upload))
+			} else {
+				state.Status.Printf("%s", state.Uploads.StatusMessage(upload))
+			}
+			render(state, term)
+		}
+	}()
+
 	// GOROUTINE: Handle events coming from the input device (ie, keyboard).
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				screen.Fini()
+				term.Close()
 				panic(r)
 			}
 		}()
-		keyboardEvents(state, term, screen, quit)
+		keyboardEvents(state, term, quit)
 	}()
 
 	// GOROUTINE: Handle events coming from slack.
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				screen.Fini()
+				term.Close()
 				panic(r)
 			}
 		}()
@@ -141,6 +177,11 @@ func main() {
 	// Save global state
 	SaveGlobalState(state)
 
+	// Save which items have been picked, including completions that haven't been saved yet.
+	if err := state.Frecency.Save(); err != nil {
+		log.Printf("Error saving frecency: %s", err)
+	}
+
 	// Save each connection and close it in turn.
 	if _, ok := state.Configuration["Connection.Cache"]; ok {
 		err := os.MkdirAll(PathToSavedConnections(), 0755)
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"github.com/gdamore/tcell"
+
 	"github.com/1egoman/slick/gateway" // The thing to interface with slack
 	"github.com/1egoman/slick/modal"
 	"github.com/1egoman/slick/status"
@@ -13,12 +15,19 @@ type State struct {
 	Command               []rune
 	CommandCursorPosition int
 
+	// Text killed in the command bar, and messages sent previously in each channel
+	KillRing     KillRing
+	InputHistory *InputHistory
+
 	// Is the current session of the client offline?
 	Offline bool
 
 	// A list of all keys that have been pressed to make up the current command.
 	KeyStack []rune
 
+	// Which mouse buttons were held down in the last mouse event
+	mouseButtons tcell.ButtonMask
+
 	// All the connections that are currently made to outside services.
 	Connections      []gateway.Connection
 	activeConnection int
@@ -35,6 +44,18 @@ type State struct {
 	SelectionInputSelectedItem        int
 	SelectionInputBottomDisplayedItem int
 
+	// How often and how recently each item in the fuzzy picker has been picked
+	Frecency *Frecency
+
+	// How the channel browser is sorted, "members" or "name"
+	BrowseSort string
+
+	// Messages that arrived in channels that weren't being looked at
+	Unreads *Unreads
+
+	// Channels shown beside the selected channel when the screen is split
+	Panes *Panes
+
 	// Status message
 	Status status.Status
 
@@ -45,6 +66,15 @@ type State struct {
 	// switch connections, etc...
 	EventActions []EventAction
 
+	// Previews of images posted in messages
+	ImagePreviews *ImagePreviews
+
+	// Files downloaded during this session
+	Downloads *Downloads
+
+	// Files being uploaded
+	Uploads *Uploads
+
 	// A map of configuration options for the editor.
 	Configuration map[string]string
 }
@@ -65,6 +95,9 @@ func NewInitialStateMode(mode string) *State {
 		Command:               []rune{},
 		CommandCursorPosition: 0,
 
+		// Input history is only kept in memory until it's loaded from the cache
+		InputHistory: NewInputHistory(""),
+
 		// Connection to the server
 		Connections: []gateway.Connection{},
 
@@ -80,6 +113,7 @@ func NewInitialStateMode(mode string) *State {
 
 		// Selection Input data
 		SelectionInput: SelectionInput{},
+		Frecency:       NewFrecency(""),
 
 		// Status message
 		Status: status.Status{},
@@ -87,10 +121,31 @@ func NewInitialStateMode(mode string) *State {
 		// Modal
 		Modal: modal.Modal{},
 
+		// Image previews
+		ImagePreviews: NewImagePreviews(),
+
+		// Downloads
+		Downloads: NewDownloads(),
+
+		// Uploads
+		Uploads: NewUploads(),
+
+		// Unread messages
+		Unreads: NewUnreads(),
+
+		// Split panes
+		Panes: NewPanes(),
+
 		// Configuration options
 		Configuration: map[string]string{
 			// Disable connection caching
 			"Connection.Cache": "true",
+
+			// Where files are downloaded to by default
+			"Download.Directory": "~/Downloads",
+
+			// Pasted text with more lines than this is uploaded as a snippet
+			"Paste.MaxInlineLines": "5",
 			// Should relative line numbers be shown for each message?
 			// "Message.RelativeLine": "true",
 
@@ -104,6 +159,14 @@ func NewInitialStateMode(mode string) *State {
 			// How many messages should Ctrl-U / Ctrl-D page by?
 			"Message.PageAmount": "12",
 
+			// Messages sent by the same user within this many seconds are grouped together.
+			"Message.GroupWindow": "300",
+
+			// Should images be previewed underneath the files they were posted in?
+			"Message.ImagePreview":           "false",
+			"Message.ImagePreview.Backend":   "halfblock",
+			"Message.ImagePreview.MaxHeight": "12",
+
 			// User online status settings
 			"Message.Sender.OnlinePrefix":       "*",
 			"Message.Sender.OnlinePrefixColor":  "green::",
@@ -122,13 +185,31 @@ func NewInitialStateMode(mode string) *State {
 			"Message.Part.AtMentionGroupColor":   "yellow::B",
 			"Message.Part.ChannelColor":          "blue::B",
 			"Message.Part.LinkColor":             "cyan::BU",
+			"Message.Part.BoldColor":             "::B",
+			"Message.Part.ItalicColor":           "silver::",
+			"Message.Part.StrikethroughColor":    "gray::",
+			"Message.Part.CodeColor":             "black:silver:",
+			"Message.Part.CodeBlockColor":        "::",
+			"Message.Part.CodeBlockBorderColor":  "gray::",
+			"Message.Part.CodeBlockTheme":        "monokai",
+			"Message.Part.BlockquoteColor":       "gray::",
+			"Message.Part.ListItemColor":         "::B",
+			"Message.Part.ContextColor":          "gray::",
+			"Message.Part.DividerColor":          "gray::",
+			"Message.Part.ElementColor":          "blue::B",
 			"Message.LineNumber.Color":           "white::",
 			"Message.LineNumber.ActiveColor":     "teal::",
 			"Message.UnconfirmedColor":           "gray::",
+			"Message.DateSeparatorColor":         "gray::",
+			"Message.NewMessagesColor":           "red::",
+
+			// Should escape move the command bar into vi's normal mode rather than chat mode?
+			"CommandBar.ViMode": "false",
 
 			"CommandBar.PrefixColor":  "::",
 			"CommandBar.TextColor":    "::",
 			"CommandBar.NewLineColor": "gray::B",
+			"CommandBar.TopicColor":   "gray::",
 
 			"StatusBar.Color":                  "::",
 			"StatusBar.ModeColor":              "::",
@@ -136,12 +217,34 @@ func NewInitialStateMode(mode string) *State {
 			"StatusBar.GatewayConnectedColor":  "white::",
 			"StatusBar.GatewayConnectingColor": ":darkmagenta:",
 			"StatusBar.GatewayFailedColor":     ":red:",
+			"StatusBar.GatewayThrottledColor":  "black:yellow:",
 			"StatusBar.LogColor":               "white::",
 			"StatusBar.ErrorColor":             "darkmagenta::B",
 			"StatusBar.TopBorderColor":         ":gray:",
 
 			"FuzzyPicker.TopBorderColor":  ":gray:",
 			"FuzzyPicker.ActiveItemColor": "::B",
+			"FuzzyPicker.MatchColor":      "yellow::",
+
+			// Scroll and click with the mouse?
+			"Mouse": "false",
+
+			// Show a list of connections and channels on the left? Toggle it with Ctrl-b.
+			"Sidebar.Visible":               "false",
+			"Sidebar.Width":                 "24",
+			"Sidebar.Color":                 "::",
+			"Sidebar.BorderColor":           ":gray:",
+			"Sidebar.ConnectionColor":       "::B",
+			"Sidebar.ActiveConnectionColor": "white:blue:",
+			"Sidebar.HeadingColor":          "gray::",
+			"Sidebar.ActiveChannelColor":    "white:blue:",
+			"Sidebar.UnreadColor":           "::B",
+			"Sidebar.MentionColor":          "red::B",
+
+			// When the screen is split, each pane has a title showing its channel.
+			"Pane.TitleColor":       "white:gray:",
+			"Pane.ActiveTitleColor": "white:blue:",
+			"Pane.BorderColor":      ":gray:",
 		},
 	}
 }
@@ -178,6 +281,14 @@ func (s *State) SetPrevActiveConnection() {
 		s.activeConnection = 0
 	}
 }
+
+// Switch to a channel in a connection, and start at its newest message.
+func (s *State) SelectChannel(connectionIndex int, channel *gateway.Channel) {
+	s.SetActiveConnection(connectionIndex)
+	s.Connections[connectionIndex].SetSelectedChannel(channel)
+	s.SelectedMessageIndex = 0
+	s.BottomDisplayedItem = 0
+}
 func (s *State) ConnectionIsStale() bool {
 	return !s.connectionSynced
 }
@@ -0,0 +1,94 @@
+package textwidth
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// A grapheme cluster: what a reader sees as one character. Accents, emoji modifiers, and runes
+// joined with a zero width joiner are drawn in the same cell as the rune before them.
+type Cluster struct {
+	Base      rune
+	Combining []rune
+	Width     int // How many cells the cluster takes up on the screen, usually 1 or 2.
+	Bytes     int // How many bytes of the string the cluster was made from.
+}
+
+const zeroWidthJoiner = '\u200d'
+
+// Is the rune drawn on top of the rune before it?
+func isCombining(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		(r >= '\ufe00' && r <= '\ufe0f') || // Variation selectors
+		(r >= 0x1f3fb && r <= 0x1f3ff) || // Emoji skin tone modifiers
+		r == zeroWidthJoiner
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// How many cells a cluster starting with `r` takes up.
+func baseWidth(r rune) int {
+	width := runewidth.RuneWidth(r)
+	if width == 0 && unicode.IsPrint(r) {
+		// A combining mark on its own is still drawn.
+		return 1
+	}
+	return width
+}
+
+// Split a string into grapheme clusters.
+func Clusters(s string) []Cluster {
+	var clusters []Cluster
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+
+		if len(clusters) > 0 {
+			last := &clusters[len(clusters)-1]
+			lastRune := last.Base
+			if len(last.Combining) > 0 {
+				lastRune = last.Combining[len(last.Combining)-1]
+			}
+
+			// A pair of regional indicators is a flag, which is drawn as one wide character.
+			joinsFlag := isRegionalIndicator(r) && isRegionalIndicator(last.Base) && len(last.Combining) == 0
+			if isCombining(r) || lastRune == zeroWidthJoiner || joinsFlag {
+				last.Combining = append(last.Combining, r)
+				last.Bytes += size
+				if joinsFlag {
+					last.Width = 2
+				}
+				continue
+			}
+		}
+
+		clusters = append(clusters, Cluster{Base: r, Width: baseWidth(r), Bytes: size})
+	}
+	return clusters
+}
+
+// How many cells a string takes up on the screen.
+func Width(s string) int {
+	total := 0
+	for _, cluster := range Clusters(s) {
+		total += cluster.Width
+	}
+	return total
+}
+
+// Return the longest start of a string that fits in `width` cells, without splitting a cluster.
+func Truncate(s string, width int) string {
+	total, bytes := 0, 0
+	for _, cluster := range Clusters(s) {
+		if total+cluster.Width > width {
+			break
+		}
+		total += cluster.Width
+		bytes += cluster.Bytes
+	}
+	return s[:bytes]
+}
@@ -0,0 +1,60 @@
+package textwidth_test
+
+import (
+	"testing"
+
+	"github.com/1egoman/slick/textwidth"
+)
+
+func TestWidth(t *testing.T) {
+	for _, test := range []struct {
+		Text  string
+		Width int
+	}{
+		{"hello", 5},
+		{"", 0},
+		{"日本語", 6},        // East Asian wide characters
+		{"café", 4},       // Precomposed accent
+		{"cafe\u0301", 4}, // Combining accent
+		{"😀 hi", 5},       // Emoji
+		{"👍🏽", 2},         // Emoji with a skin tone
+		{"👩\u200d💻", 2},   // Emoji joined with a zero width joiner
+		{"🇺🇸", 2},         // Flag
+		{"❤\ufe0f", 1},    // Variation selector
+	} {
+		if result := textwidth.Width(test.Text); result != test.Width {
+			t.Errorf("Expected %q to be %d cells wide, got %d", test.Text, test.Width, result)
+		}
+	}
+}
+
+func TestClusters(t *testing.T) {
+	clusters := textwidth.Clusters("é日")
+	if len(clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %+v", clusters)
+	}
+	if clusters[0].Base != 'e' || len(clusters[0].Combining) != 1 || clusters[0].Width != 1 || clusters[0].Bytes != 3 {
+		t.Errorf("Expected an e with a combining accent, got %+v", clusters[0])
+	}
+	if clusters[1].Base != '日' || clusters[1].Width != 2 || clusters[1].Bytes != 3 {
+		t.Errorf("Expected a wide character, got %+v", clusters[1])
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, test := range []struct {
+		Text   string
+		Width  int
+		Result string
+	}{
+		{"hello", 3, "hel"},
+		{"hello", 10, "hello"},
+		{"日本語", 3, "日"},        // A wide character is never split in half
+		{"cafés", 4, "café"}, // The accent stays with its letter
+		{"ab", 0, ""},
+	} {
+		if result := textwidth.Truncate(test.Text, test.Width); result != test.Result {
+			t.Errorf("Expected %q truncated to %d to be %q, got %q", test.Text, test.Width, test.Result, result)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// How many messages in a channel haven't been read, and how many of those mention the user.
+type UnreadCount struct {
+	Messages int
+	Mentions int
+}
+
+// Keeps track of messages that arrive in channels other than the one being looked at, so they can
+// be marked in the sidebar.
+type Unreads struct {
+	mutex  sync.Mutex
+	counts map[string]UnreadCount
+}
+
+func NewUnreads() *Unreads {
+	return &Unreads{counts: make(map[string]UnreadCount)}
+}
+
+func unreadKey(conn gateway.Connection, channel gateway.Channel) string {
+	return conn.Name() + "/" + channel.Id
+}
+
+func (u *Unreads) Add(conn gateway.Connection, channel gateway.Channel, mention bool) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	count := u.counts[unreadKey(conn, channel)]
+	count.Messages += 1
+	if mention {
+		count.Mentions += 1
+	}
+	u.counts[unreadKey(conn, channel)] = count
+}
+
+// Once a channel is looked at, all of its messages have been read.
+func (u *Unreads) Clear(conn gateway.Connection, channel gateway.Channel) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	delete(u.counts, unreadKey(conn, channel))
+}
+
+func (u *Unreads) Count(conn gateway.Connection, channel gateway.Channel) (int, int) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	count := u.counts[unreadKey(conn, channel)]
+	return count.Messages, count.Mentions
+}
+
+// When a message arrives in a channel that isn't selected, mark it as unread. Messages in direct
+// messages, and messages that would send a notification, are mentions.
+func MarkMessageUnread(state *State, conn gateway.Connection, data map[string]interface{}) {
+	if subtype, ok := data["subtype"].(string); ok && (subtype == "message_deleted" || subtype == "message_changed") {
+		return
+	}
+	if userId, ok := data["user"].(string); ok && conn.Self() != nil && userId == conn.Self().Id {
+		return
+	}
+
+	channelId, _ := data["channel"].(string)
+	for _, channel := range conn.Channels() {
+		if channel.Id == channelId {
+			text, _ := data["text"].(string)
+			mention := channel.SubType != gateway.TYPE_CHANNEL || ShouldMessageNotifyUser(text, &channel, conn.Self())
+			state.Unreads.Add(conn, channel, mention)
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main_test
+
+import (
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/gdamore/tcell"
+)
+
+func sidebarTestState() (*State, *gatewaySlack.SlackConnection) {
+	state := NewInitialStateMode("chat")
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	conn.SetSelf(gateway.User{Id: "U0", Name: "me"})
+	channels := []gateway.Channel{
+		gateway.Channel{Id: "C1", Name: "general", SubType: gateway.TYPE_CHANNEL, IsMember: true},
+		gateway.Channel{Id: "C2", Name: "random", SubType: gateway.TYPE_CHANNEL, IsMember: true},
+		gateway.Channel{Id: "D1", Name: "im-me-alice", DisplayName: "alice", SubType: gateway.TYPE_DIRECT_MESSAGE, IsMember: true},
+	}
+	conn.SetChannels(channels)
+	conn.SetSelectedChannel(&channels[0])
+	state.Connections = append(state.Connections, conn)
+	state.SetActiveConnection(0)
+	return state, conn
+}
+
+func TestMarkMessageUnread(t *testing.T) {
+	state, conn := sidebarTestState()
+	random, dm := conn.Channels()[1], conn.Channels()[2]
+
+	MarkMessageUnread(state, conn, map[string]interface{}{"channel": "C2", "user": "U1", "text": "Hello"})
+	MarkMessageUnread(state, conn, map[string]interface{}{"channel": "C2", "user": "U1", "text": "Hey <@U0>"})
+	MarkMessageUnread(state, conn, map[string]interface{}{"channel": "C2", "user": "U0", "text": "My own message"})
+	MarkMessageUnread(state, conn, map[string]interface{}{"channel": "C2", "subtype": "message_deleted"})
+	MarkMessageUnread(state, conn, map[string]interface{}{"channel": "D1", "user": "U1", "text": "Hello"})
+
+	if messages, mentions := state.Unreads.Count(conn, random); messages != 2 || mentions != 1 {
+		t.Errorf("Expected 2 unread messages and 1 mention in #random, got %d and %d", messages, mentions)
+	}
+	// Every message in a direct message is a mention.
+	if messages, mentions := state.Unreads.Count(conn, dm); messages != 1 || mentions != 1 {
+		t.Errorf("Expected 1 unread message and 1 mention in the direct message, got %d and %d", messages, mentions)
+	}
+
+	state.Unreads.Clear(conn, random)
+	if messages, mentions := state.Unreads.Count(conn, random); messages != 0 || mentions != 0 {
+		t.Errorf("Expected no unread messages in #random after clearing, got %d and %d", messages, mentions)
+	}
+}
+
+func TestSidebarToggleAndNavigation(t *testing.T) {
+	state, conn := sidebarTestState()
+	quit := make(chan struct{}, 1)
+
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlB, ' ', tcell.ModNone), state, nil, quit)
+	if state.Configuration["Sidebar.Visible"] != "true" {
+		t.Errorf("Expected Ctrl-b to show the sidebar")
+	}
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyCtrlB, ' ', tcell.ModNone), state, nil, quit)
+	if state.Configuration["Sidebar.Visible"] != "false" {
+		t.Errorf("Expected Ctrl-b to hide the sidebar")
+	}
+
+	// Channels are visited in the order they're listed in the sidebar, wrapping around at the end.
+	for _, expected := range []string{"C2", "D1", "C1"} {
+		HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModAlt), state, nil, quit)
+		if channel := conn.SelectedChannel(); channel == nil || channel.Id != expected {
+			t.Errorf("Expected Alt-j to select %s, got %+v", expected, channel)
+		}
+	}
+	HandleKeyboardEvent(tcell.NewEventKey(tcell.KeyRune, 'k', tcell.ModAlt), state, nil, quit)
+	if channel := conn.SelectedChannel(); channel == nil || channel.Id != "D1" {
+		t.Errorf("Expected Alt-k to select D1, got %+v", channel)
+	}
+	if len(state.KeyStack) != 0 {
+		t.Errorf("Expected the keystack to be empty, got %q", state.KeyStack)
+	}
+}
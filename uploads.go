@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/1egoman/slick/gateway"
+)
+
+// How often progress updates are sent while a file is uploading.
+const uploadProgressInterval = 250 * time.Millisecond
+
+type UploadStatus int
+
+const (
+	UPLOAD_QUEUED UploadStatus = iota
+	UPLOAD_IN_PROGRESS
+	UPLOAD_COMPLETE
+	UPLOAD_FAILED
+	UPLOAD_CANCELLED
+)
+
+type Upload struct {
+	Path    string
+	Channel string // The name of the channel being uploaded into
+	Size    int64
+	Sent    int64
+	Status  UploadStatus
+	Error   error
+
+	cancel    context.CancelFunc
+	filetype  string // Optional, ie `go` to highlight a snippet
+	directory string // A private directory holding the file, deleted once the upload is over
+}
+
+func (upload Upload) Active() bool {
+	return upload.Status == UPLOAD_QUEUED || upload.Status == UPLOAD_IN_PROGRESS
+}
+
+// Keeps track of files being uploaded. Whenever an upload makes progress, finishes, fails, or is
+// cancelled, a copy of it is sent to `Updates`.
+type Uploads struct {
+	mutex   sync.Mutex
+	items   []*Upload
+	Updates chan Upload
+}
+
+func NewUploads() *Uploads {
+	return &Uploads{Updates: make(chan Upload, 1)}
+}
+
+// Given a list of paths or globs (ie, `~/Desktop/*.png`), return every file that they match.
+func ExpandUploadPaths(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(ExpandHomeDirectory(pattern))
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Bad file pattern %s: %s", pattern, err))
+		}
+		if len(matches) == 0 {
+			return nil, errors.New(fmt.Sprintf("No files match %s", pattern))
+		}
+
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil {
+				return nil, errors.New(fmt.Sprintf("Couldn't read file %s: %s", match, err))
+			} else if info.IsDir() {
+				return nil, errors.New(fmt.Sprintf("Can't upload %s, it's a directory", match))
+			}
+			paths = append(paths, match)
+		}
+	}
+	return paths, nil
+}
+
+// Upload each file in turn to the given channel in the background. The title is only used when
+// uploading a single file, and the comment is posted with the first file.
+func (u *Uploads) Start(
+	conn gateway.Connection,
+	paths []string,
+	channel *gateway.Channel,
+	title string,
+	comment string,
+) error {
+	if channel == nil {
+		return errors.New("No channel to upload into!")
+	}
+	if len(paths) > 1 {
+		title = ""
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var uploads []*Upload
+	for _, uploadPath := range paths {
+		info, err := os.Stat(uploadPath)
+		if err != nil {
+			cancel()
+			return errors.New(fmt.Sprintf("Couldn't read file %s: %s", uploadPath, err))
+		}
+		uploads = append(uploads, &Upload{
+			Path:    uploadPath,
+			Channel: channel.Name,
+			Size:    info.Size(),
+			Status:  UPLOAD_QUEUED,
+			cancel:  cancel,
+		})
+	}
+
+	u.launch(ctx, cancel, conn, uploads, channel, title, comment)
+	return nil
+}
+
+// Upload content that isn't in a file yet (ie, something pasted from the clipboard) in the
+// background. It's written to a file called `name` in a new directory that only the user can read,
+// and the directory is deleted once the upload is over.
+func (u *Uploads) StartTemporary(
+	conn gateway.Connection,
+	name string,
+	content []byte,
+	channel *gateway.Channel,
+	filetype string,
+) error {
+	if channel == nil {
+		return errors.New("No channel to upload into!")
+	}
+
+	directory, err := ioutil.TempDir("", "slick-paste")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(directory, name)
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		os.RemoveAll(directory)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	u.launch(ctx, cancel, conn, []*Upload{{
+		Path:      path,
+		Channel:   channel.Name,
+		Size:      int64(len(content)),
+		Status:    UPLOAD_QUEUED,
+		cancel:    cancel,
+		filetype:  filetype,
+		directory: directory,
+	}}, channel, "", "")
+	return nil
+}
+
+// Add uploads to the list, then upload them one after another in the background.
+func (u *Uploads) launch(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	conn gateway.Connection,
+	uploads []*Upload,
+	channel *gateway.Channel,
+	title string,
+	comment string,
+) {
+	u.mutex.Lock()
+	u.items = append(u.items, uploads...)
+	u.mutex.Unlock()
+
+	go func() {
+		defer cancel()
+		for index, upload := range uploads {
+			if index > 0 {
+				comment = ""
+			}
+			u.run(ctx, conn, upload, channel, title, comment)
+		}
+	}()
+}
+
+func (u *Uploads) run(
+	ctx context.Context,
+	conn gateway.Connection,
+	upload *Upload,
+	channel *gateway.Channel,
+	title string,
+	comment string,
+) {
+	// Cancelled while waiting for the uploads before it?
+	if ctx.Err() != nil {
+		u.finish(upload, func() { upload.Status = UPLOAD_CANCELLED })
+		return
+	}
+
+	file, err := os.Open(upload.Path)
+	if err != nil {
+		u.finish(upload, func() {
+			upload.Status = UPLOAD_FAILED
+			upload.Error = err
+		})
+		return
+	}
+	defer file.Close()
+
+	u.update(upload, func() { upload.Status = UPLOAD_IN_PROGRESS })
+	log.Printf("Uploading %s to %s", upload.Path, channel.Name)
+
+	var lastUpdate time.Time
+	err = conn.PostFile(gateway.FileUpload{
+		Filename:       filepath.Base(upload.Path),
+		Title:          title,
+		InitialComment: comment,
+		Filetype:       upload.filetype,
+		Channel:        channel,
+		Content:        file,
+		Size:           upload.Size,
+		Context:        ctx,
+		Progress: func(sent int64) {
+			u.mutex.Lock()
+			upload.Sent = sent
+			u.mutex.Unlock()
+
+			if time.Since(lastUpdate) > uploadProgressInterval {
+				lastUpdate = time.Now()
+				u.update(upload, func() {})
+			}
+		},
+	})
+
+	if ctx.Err() != nil {
+		log.Printf("Upload of %s was cancelled", upload.Path)
+		u.finish(upload, func() { upload.Status = UPLOAD_CANCELLED })
+	} else if err != nil {
+		log.Printf("Error uploading %s: %s", upload.Path, err)
+		u.finish(upload, func() {
+			upload.Status = UPLOAD_FAILED
+			upload.Error = err
+		})
+	} else {
+		u.finish(upload, func() { upload.Status = UPLOAD_COMPLETE })
+	}
+}
+
+// Change an upload, then let anyone listening know about the change if they aren't busy.
+func (u *Uploads) update(upload *Upload, change func()) {
+	u.mutex.Lock()
+	change()
+	snapshot := *upload
+	u.mutex.Unlock()
+
+	select {
+	case u.Updates <- snapshot:
+	default:
+	}
+}
+
+// Like update, but always sent, so that an upload finishing is never missed. Temporary files are
+// deleted first.
+func (u *Uploads) finish(upload *Upload, change func()) {
+	if len(upload.directory) > 0 {
+		os.RemoveAll(upload.directory)
+	}
+
+	u.mutex.Lock()
+	change()
+	snapshot := *upload
+	u.mutex.Unlock()
+
+	u.Updates <- snapshot
+}
+
+// Return a copy of every upload, oldest first.
+func (u *Uploads) List() []Upload {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	var uploads []Upload
+	for _, upload := range u.items {
+		uploads = append(uploads, *upload)
+	}
+	return uploads
+}
+
+// Cancel every upload that hasn't finished. Returns how many uploads were cancelled.
+func (u *Uploads) Cancel() int {
+	var cancelled int
+	for _, upload := range u.List() {
+		if upload.Active() {
+			upload.cancel()
+			cancelled += 1
+		}
+	}
+	return cancelled
+}
+
+// A message to show in the status bar when an upload changes.
+func (u *Uploads) StatusMessage(upload Upload) string {
+	name := filepath.Base(upload.Path)
+
+	var message string
+	switch upload.Status {
+	case UPLOAD_COMPLETE:
+		message = fmt.Sprintf("Uploaded %s to #%s", name, upload.Channel)
+	case UPLOAD_FAILED:
+		message = fmt.Sprintf("Couldn't upload %s: %s", name, upload.Error)
+	case UPLOAD_CANCELLED:
+		message = fmt.Sprintf("Cancelled uploading %s", name)
+	default:
+		message = fmt.Sprintf(
+			"Uploading %s to #%s %s, press Escape to cancel",
+			name,
+			upload.Channel,
+			FormatTransferProgress(upload.Sent, upload.Size),
+		)
+	}
+
+	// Mention the files that are waiting to be uploaded.
+	var queued int
+	for _, item := range u.List() {
+		if item.Status == UPLOAD_QUEUED {
+			queued += 1
+		}
+	}
+	if queued > 0 {
+		message += fmt.Sprintf(" (%d more to upload)", queued)
+	}
+	return message
+}
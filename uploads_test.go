@@ -0,0 +1,78 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/1egoman/slick"
+	"github.com/1egoman/slick/gateway"
+	"github.com/1egoman/slick/gateway/slack"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestExpandUploadPaths(t *testing.T) {
+	directory, err := ioutil.TempDir("", "slick-uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	for _, name := range []string{"a.png", "b.png", "notes.txt"} {
+		ioutil.WriteFile(filepath.Join(directory, name), []byte(name), 0644)
+	}
+
+	paths, err := ExpandUploadPaths([]string{filepath.Join(directory, "*.png"), filepath.Join(directory, "notes.txt")})
+	if err != nil {
+		t.Fatalf("Error expanding paths: %s", err)
+	}
+	if len(paths) != 3 || filepath.Base(paths[0]) != "a.png" || filepath.Base(paths[2]) != "notes.txt" {
+		t.Errorf("Paths weren't expanded: %v", paths)
+	}
+
+	if _, err := ExpandUploadPaths([]string{filepath.Join(directory, "*.gif")}); err == nil {
+		t.Errorf("A pattern that matches nothing didn't return an error")
+	}
+	if _, err := ExpandUploadPaths([]string{directory}); err == nil {
+		t.Errorf("Uploading a directory didn't return an error")
+	}
+}
+
+func TestUploadsReportSlackErrors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://slack.com/api/files.upload?token=token&channels=C1",
+		httpmock.NewStringResponder(200, `{"ok": false, "error": "not_in_channel"}`))
+
+	file, err := ioutil.TempFile("", "slick-upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("Hello World")
+	file.Close()
+	defer os.Remove(file.Name())
+
+	uploads := NewUploads()
+	conn := gatewaySlack.NewWithName("my-team", "token")
+	err = uploads.Start(conn, []string{file.Name()}, &gateway.Channel{Id: "C1", Name: "general"}, "", "")
+	if err != nil {
+		t.Fatalf("Error starting upload: %s", err)
+	}
+
+	// Wait for the upload to finish.
+	var upload Upload
+	for upload = range uploads.Updates {
+		if !upload.Active() {
+			break
+		}
+	}
+
+	if upload.Status != UPLOAD_FAILED || upload.Error == nil || upload.Error.Error() != "not_in_channel" {
+		t.Errorf("Upload didn't fail with slack's error: %+v", upload)
+	}
+	if uploads.Cancel() != 0 {
+		t.Errorf("A finished upload was cancelled")
+	}
+}